@@ -0,0 +1,214 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
+	"github.com/qiniu/reviewbot/internal/runner"
+	"github.com/qiniu/reviewbot/internal/storage"
+	"github.com/qiniu/reviewbot/internal/util"
+	"github.com/qiniu/x/log"
+)
+
+var errBaselineLinterTimedOut = errors.New("linter timed out")
+
+type baselineOptions struct {
+	repoDir string
+	org     string
+	repo    string
+	branch  string
+	config  string
+	output  string
+	linters string
+	logDir  string
+}
+
+func gatherBaselineOptions(args []string) baselineOptions {
+	o := baselineOptions{}
+	fs := flag.NewFlagSet("baseline", flag.ExitOnError)
+	fs.StringVar(&o.repoDir, "repo-dir", ".", "the root dir of the repository to generate the baseline for")
+	fs.StringVar(&o.org, "org", "", "org of the repository, used to pick up the custom config, default to the name of the parent dir")
+	fs.StringVar(&o.repo, "repo", "", "name of the repository, used to pick up the custom config, default to the name of the repo dir")
+	fs.StringVar(&o.branch, "branch", "", "the base branch of the PRs the baseline is for, to pick up the branch specific config")
+	fs.StringVar(&o.config, "config", "", "config file")
+	fs.StringVar(&o.output, "output", "", "the baseline file to generate or update, default to "+lint.BaselineFile+" under the repo dir")
+	fs.StringVar(&o.linters, "linters", "", "comma separated linters to run, default to all related linters")
+	fs.StringVar(&o.logDir, "log-dir", os.TempDir(), "log storage dir of the linters")
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("failed to parse flags: %v", err)
+	}
+	return o
+}
+
+// runBaseline runs the linters on the whole repository and records all findings into the baseline file.
+// The findings of linters which are not run this time are kept in the baseline.
+func runBaseline(args []string) error {
+	o := gatherBaselineOptions(args)
+
+	repoDir, err := filepath.Abs(o.repoDir)
+	if err != nil {
+		return err
+	}
+	if o.repo == "" {
+		o.repo = filepath.Base(repoDir)
+	}
+	if o.org == "" {
+		o.org = filepath.Base(filepath.Dir(repoDir))
+	}
+	if o.output == "" {
+		o.output = filepath.Join(repoDir, lint.BaselineFile)
+	}
+
+	var cfg config.Config
+	if o.config != "" {
+		cfg, err = config.NewConfig(o.config)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := lint.ValidateFilters(cfg); err != nil {
+			return err
+		}
+	}
+	// keep consistent with the repo-local config used in pull requests
+	if data, err := os.ReadFile(filepath.Join(repoDir, config.RepoConfigFile)); err == nil {
+		cfg = withRepoConfig(context.Background(), cfg, o.org, o.repo, data)
+	}
+	for linterName, customLinter := range cfg.CustomLinters {
+		lint.RegisterPullRequestHandler(linterName, lint.GeneralLinterHandler)
+		lint.RegisterLinterLanguages(linterName, customLinter.Languages)
+	}
+
+	files, err := listRepoFiles(repoDir)
+	if err != nil {
+		return fmt.Errorf("failed to list files of %s: %w", repoDir, err)
+	}
+
+	logStorage, err := storage.NewLocalStorage(o.logDir)
+	if err != nil {
+		return err
+	}
+
+	baseline, err := lint.LoadBaseline(o.output)
+	if err != nil {
+		return err
+	}
+	if baseline == nil {
+		baseline = &lint.Baseline{}
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(o.linters, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			selected[name] = true
+		}
+	}
+
+	handlers := lint.TotalPullRequestHandlers()
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		if len(selected) == 0 || selected[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	ctx := context.WithValue(context.Background(), util.EventGUIDKey, fmt.Sprintf("baseline-%d", time.Now().Unix()))
+	provider := lint.NewLocalProvider(o.org, o.repo, files)
+	detector := lint.NewGeneratedFileDetector(repoDir, cfg.GetGeneratedFiles(o.org, o.repo))
+	for _, name := range names {
+		linterConfig := cfg.GetLinterConfigForBranch(o.org, o.repo, o.branch, name, config.GitHub)
+		if linterConfig.Enable != nil && !*linterConfig.Enable {
+			continue
+		}
+		if linterConfig.WorkDir != "" {
+			linterConfig.WorkDir = repoDir + "/" + linterConfig.WorkDir
+		} else {
+			linterConfig.WorkDir = repoDir
+		}
+		linterConfig.Workspace = filepath.Dir(repoDir)
+
+		agent := lint.Agent{
+			ID:           util.GetEventGUID(ctx),
+			LinterConfig: linterConfig,
+			RepoDir:      repoDir,
+			Provider:     provider,
+			Runner:       runner.NewLocalRunner(),
+			Storage:      logStorage,
+			// keep consistent with the generated files skipped in pull requests
+			GeneratedFileDetector: detector,
+			GenLogViewURL: func() string {
+				return ""
+			},
+		}
+		agent.GenLogKey = func() string {
+			return fmt.Sprintf("%s/%s/%s/%s", name, o.org, o.repo, agent.ID)
+		}
+		if !lint.LinterRelated(name, agent) {
+			continue
+		}
+
+		log.Infof("[%s] running on %s", name, repoDir)
+		if err := handlers[name](ctx, agent); err != nil {
+			return fmt.Errorf("failed to run linter %s: %w", name, err)
+		}
+	}
+
+	if timedOut := provider.TimedOut(); len(timedOut) > 0 {
+		// do not update the baseline with incomplete results
+		return fmt.Errorf("%w: %v", errBaselineLinterTimedOut, timedOut)
+	}
+
+	var total int
+	for name, results := range provider.Results() {
+		baseline.Update(repoDir, name, results)
+		for _, outputs := range results {
+			total += len(outputs)
+		}
+	}
+	if err := baseline.Save(o.output); err != nil {
+		return err
+	}
+	log.Infof("baseline %s updated with %d findings", o.output, total)
+	return nil
+}
+
+// listRepoFiles lists the files tracked by git in the repository.
+func listRepoFiles(repoDir string) ([]string, error) {
+	out, err := exec.Command("git", "-C", repoDir, "ls-files").Output()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range strings.Split(string(out), "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
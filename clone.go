@@ -19,7 +19,7 @@ import (
 
 var errUnsupportedPlatform = errors.New("unsupported platform")
 
-func (s *Server) prepareGitRepos(ctx context.Context, org, repo string, num int, platform config.Platform, installationID int64, provider lint.Provider) (workspace string, workDir string, err error) {
+func (s *Server) prepareGitRepos(ctx context.Context, cfg config.Config, org, repo string, num int, platform config.Platform, installationID int64, provider lint.Provider) (workspace string, workDir string, err error) {
 	log := util.FromContext(ctx)
 	workspace, err = prepareRepoDir(org, repo, num)
 	if err != nil {
@@ -27,7 +27,7 @@ func (s *Server) prepareGitRepos(ctx context.Context, org, repo string, num int,
 		return "", "", err
 	}
 
-	refs, workDir := s.fixRefs(workspace, org, repo)
+	refs, workDir := s.fixRefs(cfg, workspace, org, repo)
 	log.Debugf("refs: %+v", refs)
 	for _, ref := range refs {
 		if err := s.handleSingleRef(ctx, ref, org, repo, platform, installationID, num, provider); err != nil {
@@ -139,19 +139,16 @@ func updateSubmodulesIfExisted(ctx context.Context, repoDir, repo string) error
 	return nil
 }
 
-func (s *Server) fixRefs(workspace string, org, repo string) ([]config.Refs, string) {
-	var repoCfg config.RepoConfig
-	if v, ok := s.config.CustomRepos[org]; ok {
-		repoCfg = v
-	}
-	if v, ok := s.config.CustomRepos[org+"/"+repo]; ok {
-		repoCfg = v
+func (s *Server) fixRefs(cfg config.Config, workspace string, org, repo string) ([]config.Refs, string) {
+	repoRefs, key := cfg.GetRefs(org, repo)
+	if key != "" {
+		log.Debugf("refs of %s/%s are from customRepos[%s]", org, repo, key)
 	}
 
 	var mainRepoFound bool
 	var workDir string
-	refs := make([]config.Refs, 0, len(repoCfg.Refs))
-	for _, ref := range repoCfg.Refs {
+	refs := make([]config.Refs, 0, len(repoRefs))
+	for _, ref := range repoRefs {
 		if ref.PathAlias != "" {
 			ref.PathAlias = filepath.Join(workspace, ref.PathAlias)
 		} else {
@@ -1,15 +1,19 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/qiniu/x/log"
+	"github.com/bmatcuk/doublestar/v4"
 	"sigs.k8s.io/yaml"
 )
 
@@ -18,6 +22,8 @@ type Config struct {
 	GlobalDefaultConfig GlobalConfig `json:"globalDefaultConfig,omitempty"`
 
 	// CustomConfig is the custom org or repo config.
+	// The key can also be a glob pattern like "qiniu/kodo-*", or a regular expression like "re:^qiniu/.*-service$",
+	// see MatchRepoConfigs for the precedence when several keys match the same repo.
 	// e.g.
 	// * "org/repo": {"extraRefs":{org:xxx, repo:xxx, path_alias:github.com/repo }, "golangci-lint": {"enable": true, "workDir": "", "command": "golangci-lint", "args": ["run", "--config", ".golangci.yml"], "reportFormat": "github_checks"}}
 	// * "org": {"extraRefs":{org:xxx, repo:xxx, path_alias:github.com/repo }, "golangci-lint": {"enable": true, "workDir": "", "command": "golangci-lint", "args": ["run", "--config", ".golangci.yml"], "reportFormat": "github_checks"}}
@@ -26,9 +32,7 @@ type Config struct {
 	// IssueReferences is the issue references config.
 	// key is the linter name.
 	// value is the issue references config.
-	// Considering this is the information of kinds of issue which should be maintained carefully,
-	// so we *HardCode* it to store in github.com/qiniu/reviewbot/issues.
-	// feel free to submit a issue if you want to discuss it.
+	// the issue can be any GitHub or GitLab issue, or a markdown file in GlobalConfig.IssueReferencesDir.
 	IssueReferences map[string][]IssueReference `json:"issueReferences,omitempty"`
 	// compiledIssueReferences is the compiled issue references config.
 	compiledIssueReferences map[string][]CompiledIssueReference
@@ -39,20 +43,49 @@ type Config struct {
 	// the custom linter config will be used as the default config for the linter.
 	// it can be overridden by CustomRepos linter config for the specific repo.
 	CustomLinters map[string]CustomLinter `json:"customLinters,omitempty"`
+
+	// Profiles is the named bundles of linter settings and refs.
+	// key is the profile name, which can be extended by the CustomRepos entries and the other profiles.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+
+	// repoLinters is the linter config of the repo-local config, keyed by "org/repo", see WithRepoConfig.
+	// It's applied as the last layer, so it takes precedence over all the central config of the repo.
+	repoLinters map[string]map[string]Linter
 }
 
 type CustomLinter struct {
 	Linter
-	// Languages is the languages of the linter.
+	// Languages is the languages of the linter, see ParseLanguage for the syntax,
+	// e.g. [".sh", "#!bash"] or ["Dockerfile", "Dockerfile.*"].
 	Languages []string `json:"languages,omitempty"`
 }
 
 type RepoConfig struct {
+	// Extends is the profiles the entry inherits from, applied in order before the entry itself.
+	// e.g. ["go-service", "strict"]
+	Extends []string `json:"extends,omitempty"`
 	// Refs are repositories that need to be cloned.
 	// The main repository is cloned by default and does not need to be specified here if not specified.
 	// extra refs must be specified.
 	Refs    []Refs            `json:"refs,omitempty"`
 	Linters map[string]Linter `json:"linters,omitempty"`
+	// GeneratedFiles is the extra rules to detect generated files of the org or repo.
+	GeneratedFiles GeneratedFiles `json:"generatedFiles,omitempty"`
+	// MaxInlineCommentsPerPR is the max inline comments posted by all linters in a PR/MR of the org or repo.
+	// Optional, if zero, globalDefaultConfig.maxInlineCommentsPerPR is used.
+	MaxInlineCommentsPerPR int `json:"maxInlineCommentsPerPR,omitempty"`
+	// Priority is the precedence of the entry when several entries match the same repo, see MatchRepoConfigs.
+	// Optional, the entry with the greater priority takes precedence, default to 0.
+	Priority int `json:"priority,omitempty"`
+	// Branches is the linter config scoped by the base branch of the PR/MR, which overrides the Linters above.
+	// The key is the branch name, a glob pattern like "release/**", or a regular expression like "re:^v\d+\.\d+$",
+	// see MatchBranchConfigs for the precedence when several keys match the same branch.
+	Branches map[string]BranchConfig `json:"branches,omitempty"`
+}
+
+// BranchConfig is the config for the PRs/MRs targeting the matched branches.
+type BranchConfig struct {
+	Linters map[string]Linter `json:"linters,omitempty"`
 }
 
 type Refs struct {
@@ -101,6 +134,37 @@ type GlobalConfig struct {
 	// 2. /path/to/ssh/key:/another/path/to/ssh/key => will copy the key to the target path(/another/path/to/ssh/key) in the container
 	// it can be overridden by linter.DockerAsRunner.CopySSHKeyToContainer.
 	CopySSHKeyToContainer string `json:"copySSHKeyToContainer,omitempty"`
+
+	// GeneratedFiles is the extra rules to detect generated files, lint errors on which are ignored.
+	// it will be merged with the rules of the org and repo.
+	GeneratedFiles GeneratedFiles `json:"generatedFiles,omitempty"`
+
+	// IssueReferencesDir is the local directory of the markdown files referenced by issueReferences[].file,
+	// which is usually shipped with the deployment. optional, relative file paths are resolved against it.
+	IssueReferencesDir string `json:"issueReferencesDir,omitempty"`
+
+	// MaxInlineCommentsPerPR is the max inline comments posted by all linters in a PR/MR.
+	// The most important issues across linters are selected, see Linter.MaxInlineComments for the ranking.
+	// Optional, if zero, there is no budget across linters.
+	MaxInlineCommentsPerPR int `json:"maxInlineCommentsPerPR,omitempty"`
+
+	// RepoConfigAllowedFields is the fields that the repo-local config file (.reviewbot.yaml) can set.
+	// The names are the json names of the linter config like "args", or the repo config like "generatedFiles".
+	// Optional, if not set, DefaultRepoConfigAllowedFields is used. Set it to [] to disable the repo-local config.
+	// NOTE: allowing "args" or "command" grants the code execution on the runner to anyone who can push to the repo.
+	RepoConfigAllowedFields []string `json:"repoConfigAllowedFields,omitempty"`
+}
+
+// GeneratedFiles is the rules to detect generated files.
+// Besides these rules, reviewbot always detects generated files by the official Go comment,
+// the common headers like "@generated" and the linguist-generated attribute in .gitattributes.
+type GeneratedFiles struct {
+	// Paths is the glob patterns of the generated files, relative to the repo root.
+	// The doublestar syntax is supported, e.g. "**/*.pb.go", "api/gen/**".
+	Paths []string `json:"paths,omitempty"`
+	// HeaderPatterns is the regexps to match the lines of the file head, keyed by file extension like ".ts".
+	// "*" means all files. e.g. {".ts": ["^// This file is generated by my-tool"]}
+	HeaderPatterns map[string][]string `json:"headerPatterns,omitempty"`
 }
 
 // DockerAsRunner provides the way to run the linter using the docker.
@@ -140,18 +204,44 @@ type KubernetesAsRunner struct {
 type IssueReference struct {
 	// Pattern is the regex pattern to match the issue message.
 	Pattern string `json:"pattern"`
-	// URL is the url of the issue reference.
-	URL string `json:"url"`
+	// URL is the url of the issue reference, which can be any GitHub or GitLab issue, e.g.
+	// * https://github.com/qiniu/reviewbot/issues/398
+	// * https://gitlab.com/group/subgroup/project/-/issues/12
+	// the content of the issue is fetched from the url, unless File is set.
+	URL string `json:"url,omitempty"`
+	// File is the markdown file in the local knowledge base to explain the issue, see GlobalConfig.IssueReferencesDir.
+	// optional, if set, the content is read from the file, and URL is only used as the link.
+	File string `json:"file,omitempty"`
 	// Severity is the severity of the issue reference.
 	Severity Severity `json:"severity"`
 }
 
 type CompiledIssueReference struct {
-	Pattern     *regexp.Regexp
-	URL         string
+	Pattern *regexp.Regexp
+	URL     string
+	// Platform is the platform of the issue, GitHub or GitLab. empty if the content is from a local File.
+	Platform Platform
+	// Host is the base url of the platform, e.g. "https://gitlab.com".
+	Host string
+	// Project is the owner/repo on GitHub or the full path of the project on GitLab.
+	Project     string
 	IssueNumber int
+	// File is the path of the local markdown file of the issue content.
+	File string
+	// Severity overrides the severity of the matched linter outputs if not empty.
+	Severity Severity
 }
 
+// ContentKey returns the key to cache the content of the issue reference.
+func (r CompiledIssueReference) ContentKey() string {
+	if r.File != "" {
+		return "file://" + r.File
+	}
+	return r.URL
+}
+
+// Severity is the severity of a linter output.
+// Empty severity is treated as SeverityWarning.
 type Severity string
 
 const (
@@ -161,6 +251,15 @@ const (
 	SeveritySuggest Severity = "suggestion"
 )
 
+// IsValid reports whether the severity is one of the known severities.
+func (s Severity) IsValid() bool {
+	switch s {
+	case SeverityError, SeverityWarning, SeverityInfo, SeveritySuggest:
+		return true
+	}
+	return false
+}
+
 type Linter struct {
 	// Name is the linter name.
 	Name string
@@ -203,21 +302,103 @@ type Linter struct {
 	// If not empty, use the config to run the linter.
 	ConfigPath string `json:"configPath,omitempty"`
 
+	// OutputFormat is the format of the linter output, which decides how to parse the output.
+	// Optional, if empty, the text format `file:line:column: message` is used.
+	OutputFormat OutputFormat `json:"outputFormat,omitempty"`
+
+	// Timeout is the maximum duration of the linter execution, e.g. "10m", "90s".
+	// Once exceeded, the linter process, container or job will be killed and reported as timed out.
+	// Optional, if empty or zero, there is no timeout.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	// Include is the glob patterns of the files that the linter cares about, relative to the repo root.
+	// Optional, if empty, all files are included.
+	// The doublestar syntax is supported, e.g. "pkg/**/*.go". see https://github.com/bmatcuk/doublestar#patterns
+	Include []string `json:"include,omitempty"`
+	// Exclude is the glob patterns of the files that the linter should skip, relative to the repo root.
+	// It takes precedence over Include, e.g. ["vendor/**", "third_party/**", "**/testdata/**"].
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Scope is the scope of the lint results to report, see Scope for details.
+	// Optional, if empty, the results related to the changes are reported.
+	Scope Scope `json:"scope,omitempty"`
+	// DiffMode decides which lines of the changes are considered related, see DiffMode for details.
+	// Optional, if empty, only the added or modified lines are considered.
+	DiffMode DiffMode `json:"diffMode,omitempty"`
+	// DiffContext is the number of lines to extend the diff hunks on both sides when DiffMode is "context".
+	// Optional, if zero, 3 lines are used.
+	DiffContext int `json:"diffContext,omitempty"`
+
+	// Filters is the names of the filters applied on the lint results in order, e.g. ["paths", "pr-changed", "generated"].
+	// Optional, if empty, the default filters and the filters registered by the linter are used.
+	// If not empty, it replaces the whole filter pipeline, so the default filters should be listed explicitly if needed.
+	Filters []string `json:"filters,omitempty"`
+
+	// MaxInlineComments is the max inline comments the linter posts in a PR/MR, the rest are only reported in the check run or log.
+	// The issues are ranked by severity, issue reference match, rule diversity and file order, so reruns are stable.
+	// Optional, if zero, 10 is used for github_mix and there is no limit for others.
+	MaxInlineComments int `json:"maxInlineComments,omitempty"`
+	// FailOn is the min severity of the lint results which fails the GitHub check run of the linter, e.g. "error".
+	// Optional, if empty, the check run is neutral if any lint result is reported, so it never blocks the PR.
+	// NOTE: the severities of some linters are guessed, e.g. by the linter name of golangci-lint, so be careful to enable it.
+	FailOn Severity `json:"failOn,omitempty"`
+	// Unset is the fields inherited from the former layers to reset before applying this config,
+	// so the defaults are used, e.g. ["args", "env"]. The names are the same as the config fields.
+	Unset []string `json:"unset,omitempty"`
+
 	// Modifier knowns how to modify the linter command.
 	Modifier Modifier
 }
 
+// PathMatched reports whether the file is in the scope of the linter, according to Include and Exclude.
+// file is the path relative to the repo root.
+func (l Linter) PathMatched(file string) bool {
+	file = strings.TrimPrefix(filepath.ToSlash(file), "./")
+	if len(l.Include) > 0 && !matchAny(l.Include, file) {
+		return false
+	}
+	return !matchAny(l.Exclude, file)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func matchAny(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := doublestar.Match(pattern, file); matched {
+			return true
+		}
+	}
+	return false
+}
+
 func (l Linter) String() string {
 	return fmt.Sprintf(
-		"Linter{Enable: %v, DockerAsRunner: %v, Workspace: %v, WorkDir: %v, Command: %v, Args: %v, ReportType: %v, ConfigPath: %v}",
-		*l.Enable, l.DockerAsRunner, l.Workspace, l.WorkDir, l.Command, l.Args, l.ReportType, l.ConfigPath)
+		"Linter{Enable: %v, DockerAsRunner: %v, Workspace: %v, WorkDir: %v, Command: %v, Args: %v, ReportType: %v, ConfigPath: %v, Timeout: %v, Include: %v, Exclude: %v, Scope: %v, DiffMode: %v, Filters: %v, MaxInlineComments: %v}",
+		*l.Enable, l.DockerAsRunner, l.Workspace, l.WorkDir, l.Command, l.Args, l.ReportType, l.ConfigPath, l.Timeout, l.Include, l.Exclude, l.Scope, l.DiffMode, l.Filters, l.MaxInlineComments)
 }
 
 var (
-	ErrEmptyRepoOrOrg                    = errors.New("empty repo or org")
-	ErrIssueReferenceMustInReviewbotRepo = errors.New("issue reference must in reviewbot repo")
-	ErrInvalidIssueNumber                = errors.New("invalid issue number")
-	ErrCustomLinterConfig                = errors.New("custom linter must specify at least one language")
+	ErrEmptyRepoOrOrg            = errors.New("empty repo or org")
+	ErrUnsupportedIssueReference = errors.New("issue reference must be a GitHub or GitLab issue url, or a local file")
+	ErrInvalidIssueNumber        = errors.New("invalid issue number")
+	ErrInvalidSeverity           = errors.New("invalid severity, must be one of error, warning, info, suggestion")
+	ErrCustomLinterConfig        = errors.New("custom linter must specify at least one language")
+	ErrInvalidOutputFormat       = errors.New("invalid output format")
+	ErrInvalidDuration           = errors.New("invalid duration")
+	ErrInvalidPathPattern        = errors.New("invalid path pattern")
+	ErrInvalidHeaderPattern      = errors.New("invalid header pattern")
+	ErrInvalidFilter             = errors.New("invalid filter")
+	ErrInvalidDiffMode           = errors.New("invalid diff mode, must be one of added, hunk, context, file")
+	ErrInvalidScope              = errors.New("invalid scope, must be one of diff, files, repo")
+	ErrInvalidMaxInlineComments  = errors.New("invalid max inline comments, must not be negative")
+	ErrInvalidCloneURL           = errors.New("invalid clone url")
 )
 
 // NewConfig returns a new Config.
@@ -233,17 +414,17 @@ func NewConfig(conf string) (Config, error) {
 	}
 
 	// ============ validate and update the config ============
-	if err = c.validateCustomLinters(); err != nil {
-		return c, err
-	}
-	if err = c.parseCloneURLs(); err != nil {
-		return c, err
-	}
-	if err = c.validateRefs(); err != nil {
-		return c, err
-	}
-	if err = c.parseIssueReferences(); err != nil {
-		return c, err
+	// NOTE: all errors are collected rather than the first one, so that they can be fixed at once.
+	errs := []error{
+		c.validateRepoKeys(),
+		c.validateProfiles(),
+		c.validateCustomLinters(),
+		c.validateLinters(),
+		c.validateGeneratedFiles(),
+		c.validateMaxInlineComments(),
+		c.parseCloneURLs(),
+		c.validateRefs(),
+		c.parseIssueReferences(),
 	}
 
 	// set default value
@@ -259,29 +440,51 @@ func NewConfig(conf string) (Config, error) {
 	if c.GlobalDefaultConfig.GolangCiLintConfig != "" {
 		c.GlobalDefaultConfig.GolangCiLintConfig = filepath.Join(absPath, c.GlobalDefaultConfig.GolangCiLintConfig)
 		if _, err := os.Stat(c.GlobalDefaultConfig.GolangCiLintConfig); err != nil {
-			return c, fmt.Errorf("golangci-lint config file not found: %v", c.GlobalDefaultConfig.GolangCiLintConfig)
+			errs = append(errs, fmt.Errorf("golangci-lint config file not found: %v", c.GlobalDefaultConfig.GolangCiLintConfig))
 		}
 	}
 	if c.GlobalDefaultConfig.JavaPmdCheckRuleConfig != "" {
 		c.GlobalDefaultConfig.JavaPmdCheckRuleConfig = filepath.Join(absPath, c.GlobalDefaultConfig.JavaPmdCheckRuleConfig)
 		if _, err := os.Stat(c.GlobalDefaultConfig.JavaPmdCheckRuleConfig); err != nil {
-			return c, fmt.Errorf("java pmd check config file not found: %v", c.GlobalDefaultConfig.JavaPmdCheckRuleConfig)
+			errs = append(errs, fmt.Errorf("java pmd check config file not found: %v", c.GlobalDefaultConfig.JavaPmdCheckRuleConfig))
 		}
 	}
 	// check java style check config path
 	if c.GlobalDefaultConfig.JavaStyleCheckRuleConfig != "" {
 		c.GlobalDefaultConfig.JavaStyleCheckRuleConfig = filepath.Join(absPath, c.GlobalDefaultConfig.JavaStyleCheckRuleConfig)
 		if _, err := os.Stat(c.GlobalDefaultConfig.JavaStyleCheckRuleConfig); err != nil {
-			return c, fmt.Errorf("java style check config file not found: %v", c.GlobalDefaultConfig.JavaStyleCheckRuleConfig)
+			errs = append(errs, fmt.Errorf("java style check config file not found: %v", c.GlobalDefaultConfig.JavaStyleCheckRuleConfig))
 		}
 	}
 
 	// TODO(CarlJi): do we need to check the format of the copy ssh key here?
 
-	return c, nil
+	return c, errors.Join(errs...)
 }
 
 func (c Config) GetLinterConfig(org, repo, ln string, repoType Platform) Linter {
+	return c.GetLinterConfigForBranch(org, repo, "", ln, repoType)
+}
+
+// GetLinterConfigForBranch returns the linter config for the PR/MR targeting the given base branch,
+// the Branches config of the matched CustomRepos entries is applied. Empty branch means no branch config.
+func (c Config) GetLinterConfigForBranch(org, repo, branch, ln string, repoType Platform) Linter {
+	layers := c.LinterLayers(org, repo, branch, ln, repoType)
+	return layers[len(layers)-1].Linter
+}
+
+// LinterLayer is a layer of the linter config, see Config.LinterLayers.
+type LinterLayer struct {
+	// Name is where the layer comes from, e.g. "globalDefaultConfig", "customRepos[qiniu]".
+	Name string
+	// Linter is the linter config after the layer is applied.
+	Linter Linter
+}
+
+// LinterLayers returns the layers applied in order to get the linter config of the repo and base branch,
+// which are default, globalDefaultConfig, customLinters, org, repo, their branches and the repo-local config.
+// The last one is the effective config.
+func (c Config) LinterLayers(org, repo, branch, ln string, repoType Platform) []LinterLayer {
 	linter := Linter{
 		Enable:   boolPtr(true),
 		Modifier: NewBaseModifier(),
@@ -289,6 +492,8 @@ func (c Config) GetLinterConfig(org, repo, ln string, repoType Platform) Linter
 		Org:      org,
 		Repo:     repo,
 	}
+	layers := []LinterLayer{{Name: "default", Linter: linter}}
+
 	if repoType == GitLab {
 		linter.ReportType = c.GlobalDefaultConfig.GitLabReportType
 	}
@@ -314,27 +519,74 @@ func (c Config) GetLinterConfig(org, repo, ln string, repoType Platform) Linter
 		linter.DockerAsRunner.CopySSHKeyToContainer = c.GlobalDefaultConfig.CopySSHKeyToContainer
 	}
 
+	layers = append(layers, LinterLayer{Name: "globalDefaultConfig", Linter: linter})
+
 	if custom, ok := c.CustomLinters[ln]; ok {
 		linter = applyCustomLintersConfig(linter, custom)
+		layers = append(layers, LinterLayer{Name: fmt.Sprintf("customLinters[%s]", ln), Linter: linter})
 	}
 
-	if orgConfig, ok := c.CustomRepos[org]; ok {
-		if l, ok := orgConfig.Linters[ln]; ok {
+	for _, matched := range c.MatchRepoConfigs(org, repo) {
+		for _, name := range c.ExpandProfiles(matched.Extends) {
+			if l, ok := c.Profiles[name].Linters[ln]; ok {
+				linter = applyCustomConfig(linter, l)
+				layers = append(layers, LinterLayer{Name: fmt.Sprintf("customRepos[%s].extends[%s]", matched.Key, name), Linter: linter})
+			}
+		}
+		if l, ok := matched.Linters[ln]; ok {
 			linter = applyCustomConfig(linter, l)
+			layers = append(layers, LinterLayer{Name: fmt.Sprintf("customRepos[%s]", matched.Key), Linter: linter})
+		}
+		for _, b := range matched.MatchBranchConfigs(branch) {
+			if l, ok := b.Linters[ln]; ok {
+				linter = applyCustomConfig(linter, l)
+				layers = append(layers, LinterLayer{Name: fmt.Sprintf("customRepos[%s].branches[%s]", matched.Key, b.Key), Linter: linter})
+			}
 		}
 	}
 
-	if repoConfig, ok := c.CustomRepos[org+"/"+repo]; ok {
-		if l, ok := repoConfig.Linters[ln]; ok {
-			linter = applyCustomConfig(linter, l)
-		}
+	if l, ok := c.repoLinters[org+"/"+repo][ln]; ok {
+		linter = applyCustomConfig(linter, l)
+		layers = append(layers, LinterLayer{Name: RepoConfigFile, Linter: linter})
 	}
 
 	if linter.Command == nil {
 		linter.Command = []string{ln}
+		layers = append(layers, LinterLayer{Name: "default", Linter: linter})
 	}
 
-	return linter
+	return layers
+}
+
+// GetGeneratedFiles returns the rules to detect generated files for the given org and repo.
+// The rules of global, org and repo are merged.
+func (c Config) GetGeneratedFiles(org, repo string) GeneratedFiles {
+	merged := GeneratedFiles{
+		HeaderPatterns: make(map[string][]string),
+	}
+	merge := func(g GeneratedFiles) {
+		merged.Paths = append(merged.Paths, g.Paths...)
+		for ext, patterns := range g.HeaderPatterns {
+			merged.HeaderPatterns[ext] = append(merged.HeaderPatterns[ext], patterns...)
+		}
+	}
+	merge(c.GlobalDefaultConfig.GeneratedFiles)
+	for _, matched := range c.MatchRepoConfigs(org, repo) {
+		merge(matched.GeneratedFiles)
+	}
+	return merged
+}
+
+// GetMaxInlineCommentsPerPR returns the max inline comments posted by all linters in a PR/MR of the given org and repo.
+// The matched CustomRepos entries take precedence over the global config, see MatchRepoConfigs. 0 means no limit.
+func (c Config) GetMaxInlineCommentsPerPR(org, repo string) int {
+	matched := c.MatchRepoConfigs(org, repo)
+	for i := len(matched) - 1; i >= 0; i-- {
+		if matched[i].MaxInlineCommentsPerPR != 0 {
+			return matched[i].MaxInlineCommentsPerPR
+		}
+	}
+	return c.GlobalDefaultConfig.MaxInlineCommentsPerPR
 }
 
 // GetCompiledIssueReferences returns the compiled issue references config for the given linter name.
@@ -349,6 +601,8 @@ func (c Config) GetCompiledIssueReferences(linterName string) []CompiledIssueRef
 }
 
 func applyCustomConfig(legacy Linter, custom Linter) Linter {
+	legacy = unsetLinterFields(legacy, custom.Unset)
+
 	if custom.Enable != nil {
 		legacy.Enable = custom.Enable
 	}
@@ -377,6 +631,46 @@ func applyCustomConfig(legacy Linter, custom Linter) Linter {
 		legacy.Env = custom.Env
 	}
 
+	if custom.OutputFormat != "" {
+		legacy.OutputFormat = custom.OutputFormat
+	}
+
+	if custom.Timeout != 0 {
+		legacy.Timeout = custom.Timeout
+	}
+
+	if custom.Include != nil {
+		legacy.Include = custom.Include
+	}
+
+	if custom.Exclude != nil {
+		legacy.Exclude = custom.Exclude
+	}
+
+	if custom.Scope != "" {
+		legacy.Scope = custom.Scope
+	}
+
+	if custom.DiffMode != "" {
+		legacy.DiffMode = custom.DiffMode
+	}
+
+	if custom.DiffContext != 0 {
+		legacy.DiffContext = custom.DiffContext
+	}
+
+	if custom.Filters != nil {
+		legacy.Filters = custom.Filters
+	}
+
+	if custom.MaxInlineComments != 0 {
+		legacy.MaxInlineComments = custom.MaxInlineComments
+	}
+
+	if custom.FailOn != "" {
+		legacy.FailOn = custom.FailOn
+	}
+
 	if custom.DockerAsRunner.Image != "" {
 		legacy.DockerAsRunner.Image = custom.DockerAsRunner.Image
 	}
@@ -421,6 +715,16 @@ func applyCustomLintersConfig(legacy Linter, custom CustomLinter) Linter {
 		DockerAsRunner:     custom.DockerAsRunner,
 		WorkDir:            custom.WorkDir,
 		Env:                custom.Env,
+		OutputFormat:       custom.OutputFormat,
+		Timeout:            custom.Timeout,
+		Include:            custom.Include,
+		Exclude:            custom.Exclude,
+		Scope:              custom.Scope,
+		DiffMode:           custom.DiffMode,
+		DiffContext:        custom.DiffContext,
+		Filters:            custom.Filters,
+		MaxInlineComments:  custom.MaxInlineComments,
+		FailOn:             custom.FailOn,
 	}
 
 	return applyCustomConfig(legacy, tempLinter)
@@ -441,7 +745,7 @@ const (
 	// default report type for github
 	// GitHubMixType is the type of the report that mix the github_check_run and github_pr_review.
 	// which use the github_check_run to report all lint results as a check run summary,
-	// but use the github_pr_review to report top 10 lint results to pull request review comments at most.
+	// but use the github_pr_review to report the top lint results (10 by default, see Linter.MaxInlineComments) to pull request review comments.
 	// to use this report type, the auth must be github app since github_check_run is only supported on github app.
 	GitHubMixType ReportType = "github_mix"
 
@@ -454,6 +758,118 @@ const (
 	Quiet ReportType = "quiet"
 )
 
+// OutputFormat is the format of the linter output.
+type OutputFormat string
+
+const (
+	// OutputFormatText is the plain text format, each line is like `file:line:column: message`.
+	OutputFormatText OutputFormat = "text"
+	// OutputFormatSarif is the SARIF 2.1.0 format.
+	// see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+	OutputFormatSarif OutputFormat = "sarif"
+	// OutputFormatCheckstyle is the Checkstyle XML format.
+	OutputFormatCheckstyle OutputFormat = "checkstyle"
+	// OutputFormatJSONLines is the reviewbot-native JSON lines format, see lint.JSONLine for details.
+	OutputFormatJSONLines OutputFormat = "jsonl"
+)
+
+// IsValid reports whether the output format is supported.
+func (f OutputFormat) IsValid() bool {
+	switch f {
+	case OutputFormatText, OutputFormatSarif, OutputFormatCheckstyle, OutputFormatJSONLines:
+		return true
+	}
+	return false
+}
+
+// DiffMode decides which lines of the PR/MR changes are considered related, lint errors on other lines are not reported.
+type DiffMode string
+
+const (
+	// DiffModeAdded only considers the added or modified lines.
+	DiffModeAdded DiffMode = "added"
+	// DiffModeHunk considers all lines of the diff hunks, including the context lines.
+	DiffModeHunk DiffMode = "hunk"
+	// DiffModeContext considers the diff hunks extended by Linter.DiffContext lines on both sides.
+	DiffModeContext DiffMode = "context"
+	// DiffModeFile considers the whole changed files.
+	DiffModeFile DiffMode = "file"
+)
+
+// IsValid reports whether the diff mode is supported.
+func (m DiffMode) IsValid() bool {
+	switch m {
+	case DiffModeAdded, DiffModeHunk, DiffModeContext, DiffModeFile:
+		return true
+	}
+	return false
+}
+
+// Scope is the scope of the lint results to report.
+type Scope string
+
+const (
+	// ScopeDiff reports the lint results related to the changes, see Linter.DiffMode.
+	ScopeDiff Scope = "diff"
+	// ScopeFiles reports all lint results in the changed files, which is the same as DiffModeFile.
+	ScopeFiles Scope = "files"
+	// ScopeRepo reports all lint results in the repository.
+	// The results are written to the check run summary or an issue rather than the inline comments.
+	ScopeRepo Scope = "repo"
+)
+
+// IsValid reports whether the scope is supported.
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeDiff, ScopeFiles, ScopeRepo:
+		return true
+	}
+	return false
+}
+
+// Duration is a time.Duration which can be unmarshalled from a duration string like "10m" or "1h30m".
+// An integer is also accepted and treated as seconds.
+type Duration time.Duration
+
+// Duration returns the time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case float64:
+		*d = Duration(time.Duration(value * float64(time.Second)))
+	case string:
+		if value == "" {
+			*d = 0
+			return nil
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidDuration, value)
+		}
+		*d = Duration(parsed)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidDuration, string(b))
+	}
+	return nil
+}
+
 type Platform string
 
 const (
@@ -510,32 +926,51 @@ func (*baseModifier) Modify(cfg *Linter) (*Linter, error) {
 func (c *Config) parseCloneURLs() error {
 	re := regexp.MustCompile(`^(?:git@|https://)?([^:/]+)[:/]{1}(.*?)/(.*?)\.git$`)
 
-	for orgRepo, refConfig := range c.CustomRepos {
-		for k, ref := range refConfig.Refs {
+	var errs []error
+	for _, orgRepo := range sortedKeys(c.CustomRepos) {
+		for k, ref := range c.CustomRepos[orgRepo].Refs {
 			if ref.CloneURL == "" {
 				continue
 			}
 
-			if err := c.parseAndUpdateCloneURL(re, orgRepo, k); err != nil {
-				return err
+			if err := parseAndUpdateCloneURL(re, &c.CustomRepos[orgRepo].Refs[k]); err != nil {
+				errs = append(errs, fmt.Errorf("customRepos[%s].refs[%d]: %w", orgRepo, k, err))
 			}
 		}
 	}
+	for _, name := range sortedKeys(c.Profiles) {
+		for k, ref := range c.Profiles[name].Refs {
+			if ref.CloneURL == "" {
+				continue
+			}
 
-	return nil
+			if err := parseAndUpdateCloneURL(re, &c.Profiles[name].Refs[k]); err != nil {
+				errs = append(errs, fmt.Errorf("profiles[%s].refs[%d]: %w", name, k, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 func (c *Config) validateRefs() error {
-	for orgRepo, refConfig := range c.CustomRepos {
-		for _, ref := range refConfig.Refs {
+	var errs []error
+	for _, orgRepo := range sortedKeys(c.CustomRepos) {
+		for k, ref := range c.CustomRepos[orgRepo].Refs {
+			if ref.PathAlias != "" && (ref.Repo == "" || ref.Org == "") {
+				errs = append(errs, fmt.Errorf("customRepos[%s].refs[%d]: %w", orgRepo, k, ErrEmptyRepoOrOrg))
+			}
+		}
+	}
+	for _, name := range sortedKeys(c.Profiles) {
+		for k, ref := range c.Profiles[name].Refs {
 			if ref.PathAlias != "" && (ref.Repo == "" || ref.Org == "") {
-				log.Errorf("invalid ref: %v for org/repo: %s", ref, orgRepo)
-				return ErrEmptyRepoOrOrg
+				errs = append(errs, fmt.Errorf("profiles[%s].refs[%d]: %w", name, k, ErrEmptyRepoOrOrg))
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func (c *Config) parseIssueReferences() error {
@@ -545,43 +980,77 @@ func (c *Config) parseIssueReferences() error {
 
 	c.compiledIssueReferences = make(map[string][]CompiledIssueReference)
 
-	for linterName, issueReferences := range c.IssueReferences {
-		for _, ref := range issueReferences {
-			u := strings.TrimSpace(ref.URL)
-			fixedPrefix := "https://github.com/qiniu/reviewbot/issues/"
-			if !strings.HasPrefix(u, fixedPrefix) {
-				log.Errorf("invalid issue reference url: %s", u)
-				return ErrIssueReferenceMustInReviewbotRepo
-			}
-
-			num := u[len(fixedPrefix):]
-			issueNumber, err := strconv.Atoi(num)
+	var errs []error
+	for _, linterName := range sortedKeys(c.IssueReferences) {
+		for _, ref := range c.IssueReferences[linterName] {
+			compiled, err := c.compileIssueReferenceSource(ref)
 			if err != nil {
-				return ErrInvalidIssueNumber
+				errs = append(errs, fmt.Errorf("issueReferences[%s]: %w", linterName, err))
+				continue
 			}
 
 			re, err := regexp.Compile(ref.Pattern)
 			if err != nil {
-				return err
+				errs = append(errs, fmt.Errorf("issueReferences[%s]: %w", linterName, err))
+				continue
+			}
+
+			if ref.Severity != "" && !ref.Severity.IsValid() {
+				errs = append(errs, fmt.Errorf("issueReferences[%s]: %w: %q of %s", linterName, ErrInvalidSeverity, ref.Severity, compiled.ContentKey()))
+				continue
 			}
 
-			c.compiledIssueReferences[linterName] = append(c.compiledIssueReferences[linterName], CompiledIssueReference{
-				Pattern:     re,
-				URL:         ref.URL,
-				IssueNumber: issueNumber,
-			})
+			compiled.Pattern = re
+			compiled.Severity = ref.Severity
+			c.compiledIssueReferences[linterName] = append(c.compiledIssueReferences[linterName], compiled)
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// compileIssueReferenceSource parses where the content of the issue reference comes from.
+func (c *Config) compileIssueReferenceSource(ref IssueReference) (CompiledIssueReference, error) {
+	compiled := CompiledIssueReference{URL: strings.TrimSpace(ref.URL)}
+	if ref.File != "" {
+		file := ref.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(c.GlobalDefaultConfig.IssueReferencesDir, file)
+		}
+		if _, err := os.Stat(file); err != nil {
+			return compiled, fmt.Errorf("issue reference file not found: %w", err)
+		}
+		compiled.File = file
+		return compiled, nil
+	}
+
+	u, err := url.Parse(compiled.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return compiled, fmt.Errorf("%w: %s", ErrUnsupportedIssueReference, compiled.URL)
+	}
+	compiled.Host = u.Scheme + "://" + u.Host
+
+	var num string
+	urlPath := strings.Trim(u.Path, "/")
+	if project, n, ok := strings.Cut(urlPath, "/-/issues/"); ok {
+		compiled.Platform, compiled.Project, num = GitLab, project, n
+	} else if parts := strings.Split(urlPath, "/"); u.Host == "github.com" && len(parts) == 4 && parts[2] == "issues" {
+		compiled.Platform, compiled.Project, num = GitHub, parts[0]+"/"+parts[1], parts[3]
+	} else {
+		return compiled, fmt.Errorf("%w: %s", ErrUnsupportedIssueReference, compiled.URL)
+	}
+
+	compiled.IssueNumber, err = strconv.Atoi(num)
+	if err != nil || compiled.IssueNumber <= 0 {
+		return compiled, fmt.Errorf("%w: %s", ErrInvalidIssueNumber, compiled.URL)
+	}
+	return compiled, nil
 }
 
-func (c *Config) parseAndUpdateCloneURL(re *regexp.Regexp, orgRepo string, k int) error {
-	ref := &c.CustomRepos[orgRepo].Refs[k]
+func parseAndUpdateCloneURL(re *regexp.Regexp, ref *Refs) error {
 	matches := re.FindStringSubmatch(ref.CloneURL)
 	if len(matches) != 4 {
-		log.Errorf("failed to parse CloneURL, please check the format of %s", ref.CloneURL)
-		return errors.New("invalid CloneURL")
+		return fmt.Errorf("%w: %s", ErrInvalidCloneURL, ref.CloneURL)
 	}
 
 	ref.Host = matches[1]
@@ -591,16 +1060,150 @@ func (c *Config) parseAndUpdateCloneURL(re *regexp.Regexp, orgRepo string, k int
 	return nil
 }
 
+// validateLinters validates the linter configs of both custom linters and custom repos.
+func (c Config) validateLinters() error {
+	return c.WalkLinters(validateLinter)
+}
+
+// WalkLinters calls fn on the linter config of every layer, i.e. customLinters, profiles,
+// customRepos and their branches, in a deterministic order.
+// All errors are collected and wrapped with the location of the linter, e.g. "customRepos[qiniu].linters[golangci-lint]".
+func (c Config) WalkLinters(fn func(l Linter) error) error {
+	var errs []error
+	for _, name := range sortedKeys(c.CustomLinters) {
+		if err := fn(c.CustomLinters[name].Linter); err != nil {
+			errs = append(errs, fmt.Errorf("customLinters[%s]: %w", name, err))
+		}
+	}
+	for _, profile := range sortedKeys(c.Profiles) {
+		linters := c.Profiles[profile].Linters
+		for _, name := range sortedKeys(linters) {
+			if err := fn(linters[name]); err != nil {
+				errs = append(errs, fmt.Errorf("profiles[%s].linters[%s]: %w", profile, name, err))
+			}
+		}
+	}
+	for _, orgRepo := range sortedKeys(c.CustomRepos) {
+		linters := c.CustomRepos[orgRepo].Linters
+		for _, name := range sortedKeys(linters) {
+			if err := fn(linters[name]); err != nil {
+				errs = append(errs, fmt.Errorf("customRepos[%s].linters[%s]: %w", orgRepo, name, err))
+			}
+		}
+		branches := c.CustomRepos[orgRepo].Branches
+		for _, branch := range sortedKeys(branches) {
+			for _, name := range sortedKeys(branches[branch].Linters) {
+				if err := fn(branches[branch].Linters[name]); err != nil {
+					errs = append(errs, fmt.Errorf("customRepos[%s].branches[%s].linters[%s]: %w", orgRepo, branch, name, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateLinter(l Linter) error {
+	var errs []error
+	if l.OutputFormat != "" && !l.OutputFormat.IsValid() {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidOutputFormat, l.OutputFormat))
+	}
+	if l.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("%w: timeout must not be negative, got %v", ErrInvalidDuration, l.Timeout))
+	}
+	if l.Scope != "" && !l.Scope.IsValid() {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidScope, l.Scope))
+	}
+	if l.DiffMode != "" && !l.DiffMode.IsValid() {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidDiffMode, l.DiffMode))
+	}
+	if l.DiffContext < 0 {
+		errs = append(errs, fmt.Errorf("%w: diffContext must not be negative, got %d", ErrInvalidDiffMode, l.DiffContext))
+	}
+	if l.MaxInlineComments < 0 {
+		errs = append(errs, fmt.Errorf("%w: maxInlineComments got %d", ErrInvalidMaxInlineComments, l.MaxInlineComments))
+	}
+	if l.FailOn != "" && !l.FailOn.IsValid() {
+		errs = append(errs, fmt.Errorf("%w: failOn got %q", ErrInvalidSeverity, l.FailOn))
+	}
+	for _, pattern := range append(append([]string{}, l.Include...), l.Exclude...) {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidPathPattern, pattern))
+		}
+	}
+	if err := validateUnset(l.Unset); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateSecretRefs(l); err != nil {
+		errs = append(errs, err)
+	}
+	// NOTE: whether the filter is registered can only be checked when running, since filters are registered by linters.
+	for _, filter := range l.Filters {
+		if strings.TrimSpace(filter) == "" {
+			errs = append(errs, fmt.Errorf("%w: filter name must not be empty", ErrInvalidFilter))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateGeneratedFiles validates the path globs and header regexps of generated files.
+func (c Config) validateGeneratedFiles() error {
+	var errs []error
+	if err := validateGeneratedFilesRules(c.GlobalDefaultConfig.GeneratedFiles); err != nil {
+		errs = append(errs, fmt.Errorf("globalDefaultConfig.generatedFiles: %w", err))
+	}
+	for _, orgRepo := range sortedKeys(c.CustomRepos) {
+		if err := validateGeneratedFilesRules(c.CustomRepos[orgRepo].GeneratedFiles); err != nil {
+			errs = append(errs, fmt.Errorf("customRepos[%s].generatedFiles: %w", orgRepo, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateGeneratedFilesRules(g GeneratedFiles) error {
+	var errs []error
+	for _, pattern := range g.Paths {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidPathPattern, pattern))
+		}
+	}
+	for ext, patterns := range g.HeaderPatterns {
+		for _, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s of %s: %w", ErrInvalidHeaderPattern, pattern, ext, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateMaxInlineComments validates the max inline comments per PR/MR.
+func (c Config) validateMaxInlineComments() error {
+	var errs []error
+	if c.GlobalDefaultConfig.MaxInlineCommentsPerPR < 0 {
+		errs = append(errs, fmt.Errorf("globalDefaultConfig.maxInlineCommentsPerPR: %w, got %d", ErrInvalidMaxInlineComments, c.GlobalDefaultConfig.MaxInlineCommentsPerPR))
+	}
+	for _, orgRepo := range sortedKeys(c.CustomRepos) {
+		if repoConfig := c.CustomRepos[orgRepo]; repoConfig.MaxInlineCommentsPerPR < 0 {
+			errs = append(errs, fmt.Errorf("customRepos[%s].maxInlineCommentsPerPR: %w, got %d", orgRepo, ErrInvalidMaxInlineComments, repoConfig.MaxInlineCommentsPerPR))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (c Config) validateCustomLinters() error {
-	for name, linter := range c.CustomLinters {
+	var errs []error
+	for _, name := range sortedKeys(c.CustomLinters) {
+		linter := c.CustomLinters[name]
 		// skip if linter is disabled
 		if linter.Enable != nil && !*linter.Enable {
 			continue
 		}
 		if len(linter.Languages) == 0 {
-			log.Errorf("custom linter %s must specify at least one language", name)
-			return ErrCustomLinterConfig
+			errs = append(errs, fmt.Errorf("customLinters[%s]: %w", name, ErrCustomLinterConfig))
+		}
+		if err := validateLanguages(linter.Languages); err != nil {
+			errs = append(errs, fmt.Errorf("customLinters[%s].languages: %w", name, err))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
@@ -1,11 +1,14 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfig(t *testing.T) {
@@ -231,6 +234,278 @@ customRepos: # custom config for specific orgs or repos
 				},
 			},
 		},
+		{
+			name:        "invalid output format",
+			expectError: true,
+			rawConfig: `
+customLinters:
+  semgrep:
+    languages: [".go"]
+    outputFormat: "unknown"
+`,
+		},
+		{
+			name:        "linter timeout",
+			expectError: false,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        timeout: 15m
+      luacheck:
+        timeout: 90
+`,
+			expected: Config{
+				GlobalDefaultConfig: GlobalConfig{
+					GitHubReportType: GitHubMixType,
+				},
+				CustomRepos: map[string]RepoConfig{
+					"qbox": {
+						Linters: map[string]Linter{
+							"golangci-lint": {
+								Timeout: Duration(15 * time.Minute),
+							},
+							"luacheck": {
+								Timeout: Duration(90 * time.Second),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "invalid linter timeout",
+			expectError: true,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        timeout: 15 minutes
+`,
+		},
+		{
+			name:        "linter include and exclude",
+			expectError: false,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        include: ["pkg/**"]
+        exclude: ["vendor/**", "**/testdata/**"]
+`,
+			expected: Config{
+				GlobalDefaultConfig: GlobalConfig{
+					GitHubReportType: GitHubMixType,
+				},
+				CustomRepos: map[string]RepoConfig{
+					"qbox": {
+						Linters: map[string]Linter{
+							"golangci-lint": {
+								Include: []string{"pkg/**"},
+								Exclude: []string{"vendor/**", "**/testdata/**"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "invalid linter path pattern",
+			expectError: true,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        exclude: ["vendor/[a-"]
+`,
+		},
+		{
+			name:        "linter filters",
+			expectError: false,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        filters: ["paths", "pr-changed", "go-zero-tag"]
+`,
+			expected: Config{
+				GlobalDefaultConfig: GlobalConfig{
+					GitHubReportType: GitHubMixType,
+				},
+				CustomRepos: map[string]RepoConfig{
+					"qbox": {
+						Linters: map[string]Linter{
+							"golangci-lint": {
+								Filters: []string{"paths", "pr-changed", "go-zero-tag"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "empty linter filter",
+			expectError: true,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        filters: ["paths", ""]
+`,
+		},
+		{
+			name:        "linter diff mode",
+			expectError: false,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        scope: diff
+        diffMode: context
+        diffContext: 5
+`,
+			expected: Config{
+				GlobalDefaultConfig: GlobalConfig{
+					GitHubReportType: GitHubMixType,
+				},
+				CustomRepos: map[string]RepoConfig{
+					"qbox": {
+						Linters: map[string]Linter{
+							"golangci-lint": {
+								Scope:       ScopeDiff,
+								DiffMode:    DiffModeContext,
+								DiffContext: 5,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "invalid linter diff mode",
+			expectError: true,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        diffMode: lines
+`,
+		},
+		{
+			name:        "invalid linter scope",
+			expectError: true,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        scope: all
+`,
+		},
+		{
+			name:        "max inline comments",
+			expectError: false,
+			rawConfig: `
+globalDefaultConfig:
+  maxInlineCommentsPerPR: 30
+customRepos:
+  qbox:
+    maxInlineCommentsPerPR: 20
+    linters:
+      golangci-lint:
+        maxInlineComments: 5
+`,
+			expected: Config{
+				GlobalDefaultConfig: GlobalConfig{
+					GitHubReportType: GitHubMixType,
+				},
+				CustomRepos: map[string]RepoConfig{
+					"qbox": {
+						MaxInlineCommentsPerPR: 20,
+						Linters: map[string]Linter{
+							"golangci-lint": {
+								MaxInlineComments: 5,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "invalid linter max inline comments",
+			expectError: true,
+			rawConfig: `
+customRepos:
+  qbox:
+    linters:
+      golangci-lint:
+        maxInlineComments: -1
+`,
+		},
+		{
+			name:        "invalid max inline comments per PR",
+			expectError: true,
+			rawConfig: `
+globalDefaultConfig:
+  maxInlineCommentsPerPR: -1
+`,
+		},
+		{
+			name:        "generated files",
+			expectError: false,
+			rawConfig: `
+customRepos:
+  qbox/net-gslb:
+    generatedFiles:
+      paths: ["api/gen/**"]
+      headerPatterns:
+        ".ts": ["^// Generated by swagger-codegen"]
+`,
+			expected: Config{
+				GlobalDefaultConfig: GlobalConfig{
+					GitHubReportType: GitHubMixType,
+				},
+				CustomRepos: map[string]RepoConfig{
+					"qbox/net-gslb": {
+						GeneratedFiles: GeneratedFiles{
+							Paths: []string{"api/gen/**"},
+							HeaderPatterns: map[string][]string{
+								".ts": {"^// Generated by swagger-codegen"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "invalid generated files header pattern",
+			expectError: true,
+			rawConfig: `
+globalDefaultConfig:
+  generatedFiles:
+    headerPatterns:
+      "*": ["^// Generated by ("]
+`,
+		},
+		{
+			name:        "invalid issue reference severity",
+			expectError: true,
+			rawConfig: `
+issueReferences:
+  golangci-lint:
+    - pattern: "ST1003"
+      url: "https://github.com/qiniu/reviewbot/issues/398"
+      severity: "blocker"
+`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -510,3 +785,1040 @@ customRepos: # custom config for specific orgs or repos
 		})
 	}
 }
+
+func TestParseRepoConfig(t *testing.T) {
+	tcs := []struct {
+		name    string
+		allowed []string
+		raw     string
+		want    RepoConfig
+		wantErr error
+	}{
+		{
+			name: "default allowed fields",
+			raw: `
+linters:
+  golangci-lint:
+    enable: false
+    exclude: ["vendor/**"]
+`,
+			want: RepoConfig{
+				Linters: map[string]Linter{
+					"golangci-lint": {
+						Enable:  boolPtr(false),
+						Exclude: []string{"vendor/**"},
+					},
+				},
+			},
+		},
+		{
+			name: "args not allowed by default",
+			raw: `
+linters:
+  golangci-lint:
+    args: ["run", "--timeout=10m"]
+`,
+			wantErr: ErrRepoConfigFieldNotAllowed,
+		},
+		{
+			name:    "args allowed",
+			allowed: []string{"args", "workDir", "configPath"},
+			raw: `
+linters:
+  golangci-lint:
+    args: ["run", "--timeout=10m"]
+    workDir: src
+    configPath: ci/.golangci.yml
+`,
+			want: RepoConfig{
+				Linters: map[string]Linter{
+					"golangci-lint": {
+						Args:       []string{"run", "--timeout=10m"},
+						WorkDir:    "src",
+						ConfigPath: "ci/.golangci.yml",
+					},
+				},
+			},
+		},
+		{
+			name:    "workDir escaping the repo",
+			allowed: []string{"workDir"},
+			raw: `
+linters:
+  golangci-lint:
+    workDir: src/../../other-repo
+`,
+			wantErr: ErrRepoConfigPathNotAllowed,
+		},
+		{
+			name:    "secret reference of env",
+			allowed: []string{"env"},
+			raw: `
+linters:
+  golangci-lint:
+    env: ["TOKEN=${env:GITHUB_TOKEN}"]
+`,
+			wantErr: ErrRepoConfigSecretRef,
+		},
+		{
+			name:    "secret reference of file",
+			allowed: []string{"env"},
+			raw: `
+linters:
+  golangci-lint:
+    env: ["KEY=prefix-${file:/etc/reviewbot/app.pem}"]
+`,
+			wantErr: ErrRepoConfigSecretRef,
+		},
+		{
+			name:    "shell expansion in env",
+			allowed: []string{"env"},
+			raw: `
+linters:
+  golangci-lint:
+    env: ["GOFLAGS=${GOFLAGS:--mod=vendor}"]
+`,
+			want: RepoConfig{
+				Linters: map[string]Linter{
+					"golangci-lint": {Env: []string{"GOFLAGS=${GOFLAGS:--mod=vendor}"}},
+				},
+			},
+		},
+		{
+			name:    "absolute configPath",
+			allowed: []string{"configPath"},
+			raw: `
+linters:
+  golangci-lint:
+    configPath: /etc/reviewbot/app.pem
+`,
+			wantErr: ErrRepoConfigPathNotAllowed,
+		},
+		{
+			name: "linter field not allowed",
+			raw: `
+linters:
+  golangci-lint:
+    dockerAsRunner:
+      image: "evil:latest"
+`,
+			wantErr: ErrRepoConfigFieldNotAllowed,
+		},
+		{
+			name: "repo field not allowed",
+			raw: `
+generatedFiles:
+  paths: ["gen/**"]
+`,
+			wantErr: ErrRepoConfigFieldNotAllowed,
+		},
+		{
+			name:    "repo field allowed",
+			allowed: []string{"generatedFiles"},
+			raw: `
+generatedFiles:
+  paths: ["gen/**"]
+`,
+			want: RepoConfig{
+				GeneratedFiles: GeneratedFiles{Paths: []string{"gen/**"}},
+			},
+		},
+		{
+			name:    "unset of field not allowed",
+			allowed: []string{"args", "unset"},
+			raw: `
+linters:
+  golangci-lint:
+    unset: ["command"]
+`,
+			wantErr: ErrRepoConfigFieldNotAllowed,
+		},
+		{
+			name:    "unset of allowed field",
+			allowed: []string{"args", "unset"},
+			raw: `
+linters:
+  golangci-lint:
+    unset: ["args"]
+`,
+			want: RepoConfig{
+				Linters: map[string]Linter{
+					"golangci-lint": {Unset: []string{"args"}},
+				},
+			},
+		},
+		{
+			name:    "refs never allowed",
+			allowed: []string{"refs"},
+			raw: `
+refs:
+  - org: qiniu
+    repo: kodo
+`,
+			wantErr: ErrRepoConfigFieldNotAllowed,
+		},
+		{
+			name:    "disabled",
+			allowed: []string{},
+			raw: `
+linters:
+  golangci-lint:
+    enable: false
+`,
+			wantErr: ErrRepoConfigDisabled,
+		},
+		{
+			name: "invalid value",
+			raw: `
+linters:
+  golangci-lint:
+    include: ["pkg/[a-"]
+`,
+			wantErr: ErrInvalidPathPattern,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Config{GlobalDefaultConfig: GlobalConfig{RepoConfigAllowedFields: tc.allowed}}
+			got, err := c.ParseRepoConfig([]byte(tc.raw))
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	// unknown fields are rejected by the strict schema
+	if _, err := (Config{}).ParseRepoConfig([]byte("linters:\n  golangci-lint:\n    argz: []\n")); err == nil {
+		t.Errorf("expected error for unknown field, got nil")
+	}
+}
+
+func TestWithRepoConfig(t *testing.T) {
+	c := Config{
+		CustomRepos: map[string]RepoConfig{
+			"qiniu/kodo": {
+				Linters: map[string]Linter{
+					"golangci-lint": {
+						Command:        []string{"golangci-lint"},
+						Args:           []string{"run"},
+						DockerAsRunner: DockerAsRunner{Image: "golang:1.22"},
+					},
+				},
+				GeneratedFiles: GeneratedFiles{Paths: []string{"api/**"}},
+				Branches: map[string]BranchConfig{
+					"release-*": {Linters: map[string]Linter{
+						"golangci-lint": {Args: []string{"run", "--timeout=10m"}},
+						"shellcheck":    {Enable: boolPtr(false)},
+					}},
+				},
+			},
+		},
+	}
+	rc := RepoConfig{
+		Linters: map[string]Linter{
+			"golangci-lint": {Args: []string{"run", "--fast"}},
+			"gosec":         {Enable: boolPtr(false)},
+			"shellcheck":    {Enable: boolPtr(true)},
+		},
+		GeneratedFiles: GeneratedFiles{Paths: []string{"gen/**"}},
+	}
+
+	merged := c.WithRepoConfig("qiniu", "kodo", rc)
+
+	got := merged.GetLinterConfig("qiniu", "kodo", "golangci-lint", GitHub)
+	if !reflect.DeepEqual(got.Args, []string{"run", "--fast"}) {
+		t.Errorf("expected args overridden, got %v", got.Args)
+	}
+	if got.DockerAsRunner.Image != "golang:1.22" || !reflect.DeepEqual(got.Command, []string{"golangci-lint"}) {
+		t.Errorf("expected the central config kept, got %v", got)
+	}
+	if gosec := merged.GetLinterConfig("qiniu", "kodo", "gosec", GitHub); *gosec.Enable {
+		t.Errorf("expected gosec disabled")
+	}
+	if paths := merged.GetGeneratedFiles("qiniu", "kodo").Paths; !reflect.DeepEqual(paths, []string{"api/**", "gen/**"}) {
+		t.Errorf("expected generated paths merged, got %v", paths)
+	}
+
+	// the repo-local config takes precedence over the central branch config
+	layers := merged.LinterLayers("qiniu", "kodo", "release-1.0", "golangci-lint", GitHub)
+	if last := layers[len(layers)-1]; last.Name != RepoConfigFile || !reflect.DeepEqual(last.Linter.Args, []string{"run", "--fast"}) {
+		t.Errorf("expected the repo-local config applied last, got %v", last)
+	}
+	if shellcheck := merged.GetLinterConfigForBranch("qiniu", "kodo", "release-1.0", "shellcheck", GitHub); !*shellcheck.Enable {
+		t.Errorf("expected shellcheck enabled by the repo-local config")
+	}
+
+	// the original config is not modified
+	if args := c.CustomRepos["qiniu/kodo"].Linters["golangci-lint"].Args; !reflect.DeepEqual(args, []string{"run"}) {
+		t.Errorf("expected the original config not modified, got %v", args)
+	}
+	if _, ok := c.CustomRepos["qiniu/kodo"].Linters["gosec"]; ok {
+		t.Errorf("expected the original config not modified")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldConfig := Config{
+		GlobalDefaultConfig: GlobalConfig{GitHubReportType: GitHubMixType},
+		CustomLinters: map[string]CustomLinter{
+			"shellcheck": {Languages: []string{".sh"}},
+		},
+		CustomRepos: map[string]RepoConfig{
+			"qiniu/kodo": {
+				Linters: map[string]Linter{
+					"golangci-lint": {Args: []string{"run"}},
+					"gosec":         {Enable: boolPtr(false)},
+				},
+			},
+			"qbox": {},
+		},
+	}
+	newConfig := Config{
+		GlobalDefaultConfig: GlobalConfig{GitHubReportType: GitHubMixType},
+		CustomLinters: map[string]CustomLinter{
+			"buf": {Languages: []string{".proto"}},
+		},
+		CustomRepos: map[string]RepoConfig{
+			"qiniu/kodo": {
+				Linters: map[string]Linter{
+					"golangci-lint": {Args: []string{"run", "--fast"}},
+					"gosec":         {Enable: boolPtr(false)},
+				},
+				MaxInlineCommentsPerPR: 5,
+			},
+		},
+	}
+
+	got := Diff(oldConfig, newConfig)
+	want := []string{
+		`customLinters[buf] added: `,
+		`customLinters[shellcheck] removed`,
+		`customRepos[qbox] removed`,
+		`customRepos[qiniu/kodo] changed: {"generatedFiles":{}} -> {"generatedFiles":{},"maxInlineCommentsPerPR":5}`,
+		`customRepos[qiniu/kodo].linters[golangci-lint] changed: `,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("expected prefix %q, got %q", want[i], got[i])
+		}
+	}
+
+	if changes := Diff(newConfig, newConfig); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestNewConfigReportsAllErrors(t *testing.T) {
+	rawConfig := `
+globalDefaultConfig:
+  maxInlineCommentsPerPR: -1
+customRepos:
+  qiniu:
+    linters:
+      golangci-lint:
+        diffMode: bogus
+  qiniu/kodo:
+    linters:
+      golangci-lint:
+        maxInlineComments: -2
+      shellcheck:
+        failOn: fatal
+`
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte(rawConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewConfig(configFile)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, want := range []error{ErrInvalidDiffMode, ErrInvalidMaxInlineComments, ErrInvalidSeverity} {
+		if !errors.Is(err, want) {
+			t.Errorf("expected error %v, got %v", want, err)
+		}
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 4 {
+		t.Errorf("expected 4 errors, got %d: %v", len(lines), err)
+	}
+}
+
+func TestExplainLinterConfig(t *testing.T) {
+	c := Config{
+		GlobalDefaultConfig: GlobalConfig{GitHubReportType: GitHubPRReview},
+		CustomLinters: map[string]CustomLinter{
+			"shellcheck": {Linter: Linter{Command: []string{"shellcheck"}, Args: []string{"-x"}}},
+		},
+		CustomRepos: map[string]RepoConfig{
+			"qiniu": {
+				Linters: map[string]Linter{
+					"shellcheck": {Args: []string{"-x", "-a"}},
+				},
+			},
+			"qiniu/kodo": {
+				Linters: map[string]Linter{
+					"shellcheck": {Enable: boolPtr(false), WorkDir: "scripts"},
+				},
+			},
+		},
+	}
+
+	tcs := []struct {
+		name   string
+		org    string
+		repo   string
+		linter string
+		want   []FieldSource
+	}{
+		{
+			name:   "layered by org and repo",
+			org:    "qiniu",
+			repo:   "kodo",
+			linter: "shellcheck",
+			want: []FieldSource{
+				{Field: "enable", Value: boolPtr(false), Layer: "customRepos[qiniu/kodo]"},
+				{Field: "workDir", Value: "scripts", Layer: "customRepos[qiniu/kodo]"},
+				{Field: "command", Value: []string{"shellcheck"}, Layer: "customLinters[shellcheck]"},
+				{Field: "args", Value: []string{"-x", "-a"}, Layer: "customRepos[qiniu]"},
+				{Field: "reportType", Value: GitHubPRReview, Layer: "globalDefaultConfig"},
+			},
+		},
+		{
+			name:   "builtin linter without custom config",
+			org:    "qbox",
+			repo:   "net-cache",
+			linter: "golangci-lint",
+			want: []FieldSource{
+				{Field: "enable", Value: boolPtr(true), Layer: "default"},
+				{Field: "command", Value: []string{"golangci-lint"}, Layer: "default"},
+				{Field: "reportType", Value: GitHubPRReview, Layer: "globalDefaultConfig"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := c.ExplainLinterConfig(tc.org, tc.repo, "", tc.linter, GitHub)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+			// the explained values must be the same as the effective config
+			linter := c.GetLinterConfig(tc.org, tc.repo, tc.linter, GitHub)
+			for _, source := range got {
+				if source.Field == "args" && !reflect.DeepEqual(source.Value, linter.Args) {
+					t.Errorf("expected args %v, got %v", linter.Args, source.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type property struct {
+		Type                 interface{}         `json:"type"`
+		Enum                 []string            `json:"enum"`
+		Properties           map[string]property `json:"properties"`
+		AdditionalProperties interface{}         `json:"additionalProperties"`
+	}
+	var schema property
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+
+	customLinters, ok := schema.Properties["customLinters"].AdditionalProperties.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected customLinters to be a map, got %v", schema.Properties["customLinters"])
+	}
+	raw, err := json.Marshal(customLinters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var customLinter property
+	if err := json.Unmarshal(raw, &customLinter); err != nil {
+		t.Fatal(err)
+	}
+	linter := customLinter.Properties
+	for _, field := range []string{"enable", "command", "args", "languages", "timeout", "diffMode"} {
+		if _, ok := linter[field]; !ok {
+			t.Errorf("expected field %s in the custom linter schema", field)
+		}
+	}
+	if got := linter["enable"].Type; got != "boolean" {
+		t.Errorf("expected enable to be boolean, got %v", got)
+	}
+	if got, want := linter["diffMode"].Enum, []string{"added", "hunk", "context", "file"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected diffMode enum %v, got %v", want, got)
+	}
+	if _, ok := schema.Properties["customRepos"]; !ok {
+		t.Errorf("expected customRepos in the schema")
+	}
+}
+
+func TestMatchRepoConfigs(t *testing.T) {
+	c := Config{
+		CustomRepos: map[string]RepoConfig{
+			"qiniu":                  {Refs: []Refs{{Org: "qiniu", Repo: "common"}}},
+			"qiniu/kodo":             {},
+			"qiniu/kodo-*":           {Refs: []Refs{{Org: "qiniu", Repo: "kodo-common"}}},
+			"qiniu/*":                {},
+			"qi*":                    {},
+			"re:^qiniu/.*-service$":  {},
+			"re:^qiniu/kodo-.*-svc$": {Priority: -1},
+			"re:qbox/.*":             {Priority: 1},
+			"qbox/net-cache":         {},
+		},
+	}
+
+	tcs := []struct {
+		name     string
+		org      string
+		repo     string
+		wantKeys []string
+		wantRefs string
+	}{
+		{
+			name:     "exact repo",
+			org:      "qiniu",
+			repo:     "kodo",
+			wantKeys: []string{"qi*", "qiniu", "qiniu/*", "qiniu/kodo"},
+			wantRefs: "qiniu",
+		},
+		{
+			name:     "glob and regex ordered by specificity",
+			org:      "qiniu",
+			repo:     "kodo-service",
+			wantKeys: []string{"qi*", "qiniu", "qiniu/*", "re:^qiniu/.*-service$", "qiniu/kodo-*"},
+			wantRefs: "qiniu/kodo-*",
+		},
+		{
+			name:     "priority takes precedence over specificity",
+			org:      "qiniu",
+			repo:     "kodo-io-svc",
+			wantKeys: []string{"re:^qiniu/kodo-.*-svc$", "qi*", "qiniu", "qiniu/*", "qiniu/kodo-*"},
+			wantRefs: "qiniu/kodo-*",
+		},
+		{
+			name:     "regex matches the whole org/repo",
+			org:      "qbox",
+			repo:     "net-cache",
+			wantKeys: []string{"qbox/net-cache", "re:qbox/.*"},
+		},
+		{
+			name: "no match",
+			org:  "kodo",
+			repo: "qiniu",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var keys []string
+			for _, m := range c.MatchRepoConfigs(tc.org, tc.repo) {
+				keys = append(keys, m.Key)
+			}
+			if !reflect.DeepEqual(keys, tc.wantKeys) {
+				t.Errorf("expected %v, got %v", tc.wantKeys, keys)
+			}
+			if _, key := c.GetRefs(tc.org, tc.repo); key != tc.wantRefs {
+				t.Errorf("expected refs from %q, got %q", tc.wantRefs, key)
+			}
+		})
+	}
+}
+
+func TestGetLinterConfigWithPatterns(t *testing.T) {
+	c := Config{
+		CustomRepos: map[string]RepoConfig{
+			"qiniu": {
+				Linters: map[string]Linter{"golangci-lint": {Args: []string{"run"}}},
+			},
+			"qiniu/*-service": {
+				Linters: map[string]Linter{"golangci-lint": {WorkDir: "src", Args: []string{"run", "--fast"}}},
+			},
+			"qiniu/kodo-service": {
+				Linters: map[string]Linter{"golangci-lint": {WorkDir: "cmd"}},
+			},
+		},
+	}
+
+	got := c.GetLinterConfig("qiniu", "kodo-service", "golangci-lint", GitHub)
+	if got.WorkDir != "cmd" || !reflect.DeepEqual(got.Args, []string{"run", "--fast"}) {
+		t.Errorf("expected workDir cmd and args [run --fast], got %s and %v", got.WorkDir, got.Args)
+	}
+	got = c.GetLinterConfig("qiniu", "kodo", "golangci-lint", GitHub)
+	if got.WorkDir != "" || !reflect.DeepEqual(got.Args, []string{"run"}) {
+		t.Errorf("expected empty workDir and args [run], got %s and %v", got.WorkDir, got.Args)
+	}
+}
+
+func TestInvalidRepoPattern(t *testing.T) {
+	for _, key := range []string{"qiniu/[kodo", "re:qiniu/(kodo"} {
+		c := Config{CustomRepos: map[string]RepoConfig{key: {}}}
+		if err := c.validateRepoKeys(); !errors.Is(err, ErrInvalidRepoPattern) {
+			t.Errorf("expected %v for %s, got %v", ErrInvalidRepoPattern, key, err)
+		}
+	}
+}
+
+func TestNewConfigInvalidRepoPattern(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	rawConfig := `
+customRepos:
+  "qiniu/[kodo":
+    linters:
+      golangci-lint:
+        enable: false
+  qiniu/kodo:
+    branches:
+      "release/[v":
+        linters:
+          golangci-lint:
+            enable: false
+`
+	if err := os.WriteFile(configFile, []byte(rawConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewConfig(configFile)
+	for _, want := range []error{ErrInvalidRepoPattern, ErrInvalidBranchPattern} {
+		if !errors.Is(err, want) {
+			t.Errorf("expected error %v, got %v", want, err)
+		}
+	}
+}
+
+func TestGetLinterConfigForBranch(t *testing.T) {
+	c := Config{
+		CustomRepos: map[string]RepoConfig{
+			"qiniu": {
+				Linters: map[string]Linter{"golangci-lint": {Args: []string{"run"}}},
+				Branches: map[string]BranchConfig{
+					"release/**": {
+						Linters: map[string]Linter{"golangci-lint": {Args: []string{"run", "--enable-all"}}},
+					},
+				},
+			},
+			"qiniu/kodo": {
+				Linters: map[string]Linter{"golangci-lint": {WorkDir: "src"}},
+				Branches: map[string]BranchConfig{
+					"release/v1.0": {
+						Linters: map[string]Linter{"golangci-lint": {Enable: boolPtr(false)}},
+					},
+					`re:^release/v\d+\.\d+$`: {
+						Linters: map[string]Linter{"golangci-lint": {WorkDir: "release"}},
+					},
+				},
+			},
+		},
+	}
+
+	tcs := []struct {
+		name        string
+		branch      string
+		wantEnable  bool
+		wantWorkDir string
+		wantArgs    []string
+	}{
+		{
+			name:        "no branch",
+			wantEnable:  true,
+			wantWorkDir: "src",
+			wantArgs:    []string{"run"},
+		},
+		{
+			name:        "branch not matched",
+			branch:      "main",
+			wantEnable:  true,
+			wantWorkDir: "src",
+			wantArgs:    []string{"run"},
+		},
+		{
+			name:        "branch matched by patterns of org and repo",
+			branch:      "release/v2.1",
+			wantEnable:  true,
+			wantWorkDir: "release",
+			wantArgs:    []string{"run", "--enable-all"},
+		},
+		{
+			name:        "exact branch takes precedence over patterns",
+			branch:      "release/v1.0",
+			wantEnable:  false,
+			wantWorkDir: "release",
+			wantArgs:    []string{"run", "--enable-all"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := c.GetLinterConfigForBranch("qiniu", "kodo", tc.branch, "golangci-lint", GitHub)
+			if *got.Enable != tc.wantEnable || got.WorkDir != tc.wantWorkDir || !reflect.DeepEqual(got.Args, tc.wantArgs) {
+				t.Errorf("expected enable %v, workDir %s, args %v, got %v, %s, %v",
+					tc.wantEnable, tc.wantWorkDir, tc.wantArgs, *got.Enable, got.WorkDir, got.Args)
+			}
+		})
+	}
+
+	c.CustomRepos["qbox"] = RepoConfig{Branches: map[string]BranchConfig{"release/[v": {}}}
+	if err := c.validateRepoKeys(); !errors.Is(err, ErrInvalidBranchPattern) {
+		t.Errorf("expected %v, got %v", ErrInvalidBranchPattern, err)
+	}
+}
+
+func TestCompileIssueReferenceSource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ST1003.md"), []byte("naming"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := Config{GlobalDefaultConfig: GlobalConfig{IssueReferencesDir: dir}}
+
+	tcs := []struct {
+		name    string
+		ref     IssueReference
+		want    CompiledIssueReference
+		wantErr error
+	}{
+		{
+			name: "github issue of any repo",
+			ref:  IssueReference{URL: "https://github.com/myorg/rules/issues/7"},
+			want: CompiledIssueReference{URL: "https://github.com/myorg/rules/issues/7", Platform: GitHub, Host: "https://github.com", Project: "myorg/rules", IssueNumber: 7},
+		},
+		{
+			name: "gitlab issue in subgroup",
+			ref:  IssueReference{URL: "https://gitlab.example.com/group/sub/project/-/issues/12"},
+			want: CompiledIssueReference{URL: "https://gitlab.example.com/group/sub/project/-/issues/12", Platform: GitLab, Host: "https://gitlab.example.com", Project: "group/sub/project", IssueNumber: 12},
+		},
+		{
+			name: "local file with link",
+			ref:  IssueReference{URL: "https://wiki.example.com/ST1003", File: "ST1003.md"},
+			want: CompiledIssueReference{URL: "https://wiki.example.com/ST1003", File: filepath.Join(dir, "ST1003.md")},
+		},
+		{
+			name:    "local file not found",
+			ref:     IssueReference{File: "missing.md"},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			name:    "not an issue url",
+			ref:     IssueReference{URL: "https://github.com/qiniu/reviewbot/pull/1"},
+			wantErr: ErrUnsupportedIssueReference,
+		},
+		{
+			name:    "invalid issue number",
+			ref:     IssueReference{URL: "https://github.com/qiniu/reviewbot/issues/abc"},
+			wantErr: ErrInvalidIssueNumber,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := c.compileIssueReferenceSource(tc.ref)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	c := Config{
+		CustomLinters: map[string]CustomLinter{
+			"golangci-lint": {Linter: Linter{Env: []string{"GOFLAGS=-mod=vendor"}}},
+		},
+		Profiles: map[string]Profile{
+			"go": {
+				Refs: []Refs{{Org: "qiniu", Repo: "common"}},
+				Linters: map[string]Linter{
+					"golangci-lint": {Args: []string{"run", "--fast"}, WorkDir: "src"},
+					"gosec":         {Enable: boolPtr(false)},
+				},
+			},
+			"go-service": {
+				Extends: []string{"go"},
+				Refs:    []Refs{{Org: "qiniu", Repo: "common", PathAlias: "deps/common"}, {Org: "qiniu", Repo: "proto"}},
+				Linters: map[string]Linter{
+					"golangci-lint": {Unset: []string{"env"}, Timeout: Duration(10 * time.Minute)},
+				},
+			},
+			"strict": {
+				Extends: []string{"go"},
+				Linters: map[string]Linter{
+					"gosec": {Enable: boolPtr(true)},
+				},
+			},
+		},
+		CustomRepos: map[string]RepoConfig{
+			"qiniu/kodo": {
+				Extends: []string{"go-service", "strict"},
+				Linters: map[string]Linter{
+					"golangci-lint": {Unset: []string{"args", "workDir"}},
+				},
+			},
+		},
+	}
+	if err := c.validateProfiles(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := c.ExpandProfiles([]string{"go-service", "strict"}), []string{"go", "go-service", "strict"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected profiles %v, got %v", want, got)
+	}
+
+	golangci := c.GetLinterConfig("qiniu", "kodo", "golangci-lint", GitHub)
+	if golangci.Args != nil || golangci.WorkDir != "" || golangci.Env != nil || golangci.Timeout != Duration(10*time.Minute) {
+		t.Errorf("expected args, workDir and env unset with timeout 10m, got %v", golangci)
+	}
+	if gosec := c.GetLinterConfig("qiniu", "kodo", "gosec", GitHub); !*gosec.Enable {
+		t.Errorf("expected gosec enabled by the latter profile")
+	}
+	if other := c.GetLinterConfig("qiniu", "other", "golangci-lint", GitHub); !reflect.DeepEqual(other.Env, []string{"GOFLAGS=-mod=vendor"}) {
+		t.Errorf("expected env of the custom linter kept for repos without profiles, got %v", other.Env)
+	}
+
+	refs, key := c.GetRefs("qiniu", "kodo")
+	wantRefs := []Refs{{Org: "qiniu", Repo: "common", PathAlias: "deps/common"}, {Org: "qiniu", Repo: "proto"}}
+	if key != "qiniu/kodo" || !reflect.DeepEqual(refs, wantRefs) {
+		t.Errorf("expected refs %v from qiniu/kodo, got %v from %s", wantRefs, refs, key)
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	tcs := []struct {
+		name    string
+		config  Config
+		wantErr []error
+	}{
+		{
+			name: "unknown profile",
+			config: Config{
+				Profiles:    map[string]Profile{"go": {Extends: []string{"base"}}},
+				CustomRepos: map[string]RepoConfig{"qiniu": {Extends: []string{"java"}}},
+			},
+			wantErr: []error{ErrUnknownProfile},
+		},
+		{
+			name: "cycle",
+			config: Config{
+				Profiles: map[string]Profile{
+					"a": {Extends: []string{"b"}},
+					"b": {Extends: []string{"c"}},
+					"c": {Extends: []string{"a"}},
+				},
+			},
+			wantErr: []error{ErrProfileCycle},
+		},
+		{
+			name: "invalid unset field",
+			config: Config{
+				Profiles: map[string]Profile{
+					"go": {Linters: map[string]Linter{"golangci-lint": {Unset: []string{"argz"}}}},
+				},
+			},
+			wantErr: []error{ErrInvalidUnsetField},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := errors.Join(tc.config.validateProfiles(), tc.config.validateLinters())
+			for _, want := range tc.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("expected error %v, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSecretRefs(t *testing.T) {
+	tcs := []struct {
+		name    string
+		linter  Linter
+		wantErr error
+	}{
+		{
+			name: "valid references",
+			linter: Linter{
+				Env:  []string{"A=${env:TOKEN}", "B=token ${file:/etc/token}", "C=${k8s:npm/token}", "D=${HOME:-/root}"},
+				Args: []string{"echo ${ARTIFACT:-/tmp}"},
+			},
+		},
+		{
+			name:    "kubernetes secret not the whole value",
+			linter:  Linter{Env: []string{"C=Bearer ${k8s:npm/token}"}},
+			wantErr: ErrInvalidSecretRef,
+		},
+		{
+			name:    "kubernetes secret without key",
+			linter:  Linter{Env: []string{"C=${k8s:npm}"}},
+			wantErr: ErrInvalidSecretRef,
+		},
+		{
+			name:    "secret in args",
+			linter:  Linter{Args: []string{"curl -H 'token: ${env:TOKEN}'"}},
+			wantErr: ErrSecretRefNotAllowed,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSecretRefs(tc.linter)
+			if tc.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tcs := []struct {
+		lang    string
+		want    Language
+		match   []string
+		noMatch []string
+		wantErr error
+	}{
+		{
+			lang:  "*",
+			want:  Language{Kind: LanguageAny, Value: "*"},
+			match: []string{"a.go", "Makefile"},
+		},
+		{
+			lang:    ".go",
+			want:    Language{Kind: LanguageExtension, Value: ".go"},
+			match:   []string{"a.go", "pkg/b.go"},
+			noMatch: []string{"go.mod", "a.go.tpl"},
+		},
+		{
+			lang:    "go.work",
+			want:    Language{Kind: LanguageFilename, Value: "go.work"},
+			match:   []string{"go.work", "tools/go.work"},
+			noMatch: []string{"go.work.sum"},
+		},
+		{
+			lang:    ".golangci.yml",
+			want:    Language{Kind: LanguageFilename, Value: ".golangci.yml"},
+			match:   []string{".golangci.yml"},
+			noMatch: []string{"a.yml"},
+		},
+		{
+			lang:    "build/Makefile",
+			want:    Language{Kind: LanguageFilename, Value: "build/Makefile"},
+			match:   []string{"build/Makefile"},
+			noMatch: []string{"Makefile", "src/build/Makefile"},
+		},
+		{
+			lang:    "Dockerfile.*",
+			want:    Language{Kind: LanguageGlob, Value: "Dockerfile.*"},
+			match:   []string{"Dockerfile.dev", "images/Dockerfile.release"},
+			noMatch: []string{"Dockerfile"},
+		},
+		{
+			lang:    "**/Jenkinsfile",
+			want:    Language{Kind: LanguageGlob, Value: "**/Jenkinsfile"},
+			match:   []string{"Jenkinsfile", "ci/Jenkinsfile"},
+			noMatch: []string{"ci/Jenkinsfile.bak"},
+		},
+		{
+			lang:    "#!bash",
+			want:    Language{Kind: LanguageShebang, Value: "bash"},
+			noMatch: []string{"bash", "a.sh"},
+		},
+		{
+			lang:    "#!/bin/bash",
+			wantErr: ErrInvalidLanguage,
+		},
+		{
+			lang:    "#!",
+			wantErr: ErrInvalidLanguage,
+		},
+		{
+			lang:    "Dockerfile.[",
+			wantErr: ErrInvalidLanguage,
+		},
+		{
+			lang:    " .go",
+			wantErr: ErrInvalidLanguage,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.lang, func(t *testing.T) {
+			got, err := ParseLanguage(tc.lang)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+			for _, file := range tc.match {
+				if !got.MatchFile(file) {
+					t.Errorf("expected %s to match %s", tc.lang, file)
+				}
+			}
+			for _, file := range tc.noMatch {
+				if got.MatchFile(file) {
+					t.Errorf("expected %s not to match %s", tc.lang, file)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchInterpreter(t *testing.T) {
+	tcs := []struct {
+		lang     string
+		shebang  string
+		expected bool
+	}{
+		{lang: "#!bash", shebang: "#!/bin/bash", expected: true},
+		{lang: "#!bash", shebang: "#! /bin/bash -e", expected: true},
+		{lang: "#!bash", shebang: "#!/usr/bin/env bash", expected: true},
+		{lang: "#!bash", shebang: "#!/usr/bin/env -S LANG=C bash -e", expected: true},
+		{lang: "#!sh", shebang: "#!/bin/bash", expected: false},
+		{lang: "#!python", shebang: "#!/usr/bin/env python3.11", expected: true},
+		{lang: "#!python", shebang: "#!/usr/bin/env pythonw", expected: false},
+		{lang: "#!bash", shebang: "# bash", expected: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.lang+" "+tc.shebang, func(t *testing.T) {
+			language, err := ParseLanguage(tc.lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := language.MatchInterpreter(tc.shebang); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
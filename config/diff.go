@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Diff returns the human readable changes from the old config to the new one, sorted by the field path.
+// It's used to log what changed when the config is reloaded.
+func Diff(oldConfig, newConfig Config) []string {
+	var changes []string
+	diff := func(path string, o, n interface{}, oldOK, newOK bool) {
+		switch {
+		case !oldOK && newOK:
+			changes = append(changes, fmt.Sprintf("%s added: %s", path, toJSON(n)))
+		case oldOK && !newOK:
+			changes = append(changes, fmt.Sprintf("%s removed", path))
+		case oldOK && newOK && !reflect.DeepEqual(o, n):
+			changes = append(changes, fmt.Sprintf("%s changed: %s -> %s", path, toJSON(o), toJSON(n)))
+		}
+	}
+
+	diff("globalDefaultConfig", oldConfig.GlobalDefaultConfig, newConfig.GlobalDefaultConfig, true, true)
+	for _, name := range unionKeys(oldConfig.CustomLinters, newConfig.CustomLinters) {
+		o, oldOK := oldConfig.CustomLinters[name]
+		n, newOK := newConfig.CustomLinters[name]
+		diff(fmt.Sprintf("customLinters[%s]", name), o, n, oldOK, newOK)
+	}
+	for _, name := range unionKeys(oldConfig.Profiles, newConfig.Profiles) {
+		o, oldOK := oldConfig.Profiles[name]
+		n, newOK := newConfig.Profiles[name]
+		diff(fmt.Sprintf("profiles[%s]", name), o, n, oldOK, newOK)
+	}
+	for _, name := range unionKeys(oldConfig.IssueReferences, newConfig.IssueReferences) {
+		o, oldOK := oldConfig.IssueReferences[name]
+		n, newOK := newConfig.IssueReferences[name]
+		diff(fmt.Sprintf("issueReferences[%s]", name), o, n, oldOK, newOK)
+	}
+	for _, orgRepo := range unionKeys(oldConfig.CustomRepos, newConfig.CustomRepos) {
+		o, oldOK := oldConfig.CustomRepos[orgRepo]
+		n, newOK := newConfig.CustomRepos[orgRepo]
+		if !oldOK || !newOK {
+			diff(fmt.Sprintf("customRepos[%s]", orgRepo), o, n, oldOK, newOK)
+			continue
+		}
+
+		// show the changes of each linter rather than the whole repo config
+		for _, name := range unionKeys(o.Linters, n.Linters) {
+			ol, oldOK := o.Linters[name]
+			nl, newOK := n.Linters[name]
+			diff(fmt.Sprintf("customRepos[%s].linters[%s]", orgRepo, name), ol, nl, oldOK, newOK)
+		}
+		o.Linters, n.Linters = nil, nil
+		diff(fmt.Sprintf("customRepos[%s]", orgRepo), o, n, true, true)
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return string(b)
+}
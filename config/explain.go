@@ -0,0 +1,62 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FieldSource is the effective value of a linter config field and the layer which set it.
+type FieldSource struct {
+	// Field is the json name of the field, e.g. "args".
+	Field string
+	// Value is the effective value of the field.
+	Value interface{}
+	// Layer is the name of the last layer which changed the field, see LinterLayer.
+	Layer string
+}
+
+// ExplainLinterConfig explains the effective linter config of the repo and base branch,
+// which is the same as GetLinterConfigForBranch. It returns the fields set by any layer in the order of the struct fields, and the fields never set are omitted.
+func (c Config) ExplainLinterConfig(org, repo, branch, ln string, repoType Platform) []FieldSource {
+	layers := c.LinterLayers(org, repo, branch, ln, repoType)
+
+	var sources []FieldSource
+	t := reflect.TypeOf(Linter{})
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonFieldName(t.Field(i))
+		if name == "" {
+			continue
+		}
+
+		var layer string
+		prev := reflect.ValueOf(Linter{}).Field(i).Interface()
+		for _, l := range layers {
+			cur := reflect.ValueOf(l.Linter).Field(i).Interface()
+			if !reflect.DeepEqual(prev, cur) {
+				layer = l.Name
+			}
+			prev = cur
+		}
+		if layer == "" {
+			continue
+		}
+		sources = append(sources, FieldSource{Field: name, Value: prev, Layer: layer})
+	}
+	return sources
+}
+
+// jsonFieldName returns the json name of the struct field, empty if the field is not configurable.
+func jsonFieldName(f reflect.StructField) string {
+	tag, ok := f.Tag.Lookup("json")
+	if !ok || !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
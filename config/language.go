@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ShebangLanguagePrefix is the prefix of the language matching the interpreter in the shebang line of a file,
+// e.g. "#!bash" matches the files starting with "#!/bin/bash" or "#!/usr/bin/env bash".
+const ShebangLanguagePrefix = "#!"
+
+var ErrInvalidLanguage = errors.New("invalid language")
+
+// LanguageKind is how a language entry of a linter is matched against a file.
+type LanguageKind int
+
+const (
+	// LanguageAny matches any file, i.e. "*".
+	LanguageAny LanguageKind = iota
+	// LanguageExtension matches the file extension, e.g. ".go".
+	LanguageExtension
+	// LanguageFilename matches the file name, e.g. "Dockerfile", or the whole path if it contains "/", e.g. "build/Makefile".
+	LanguageFilename
+	// LanguageGlob matches the file name, or the whole path if it contains "/", by the glob, e.g. "Dockerfile.*" or "**/Jenkinsfile".
+	LanguageGlob
+	// LanguageShebang matches the interpreter in the shebang line of the file, e.g. "#!sh".
+	LanguageShebang
+)
+
+// Language is a parsed language entry of a linter.
+type Language struct {
+	Kind LanguageKind
+	// Value is the extension, file name, glob or interpreter.
+	Value string
+}
+
+// ParseLanguage parses a language entry of a linter, which is one of:
+//   - "*": any file
+//   - ".ext": the file extension, e.g. ".go"
+//   - "name": the exact file name, e.g. "Dockerfile", "go.work" or ".golangci.yml"
+//   - "glob": the doublestar glob of the file name, e.g. "*.mk", or of the path if it contains "/", e.g. "**/Jenkinsfile"
+//   - "#!interpreter": the interpreter in the shebang line, e.g. "#!bash"
+func ParseLanguage(lang string) (Language, error) {
+	switch {
+	case lang == "*":
+		return Language{Kind: LanguageAny, Value: lang}, nil
+	case strings.TrimSpace(lang) != lang || lang == "":
+		return Language{}, fmt.Errorf("%w: %q", ErrInvalidLanguage, lang)
+	case strings.HasPrefix(lang, ShebangLanguagePrefix):
+		interpreter := strings.TrimPrefix(lang, ShebangLanguagePrefix)
+		if interpreter == "" || strings.ContainsAny(interpreter, " \t/") {
+			return Language{}, fmt.Errorf("%w: %q, the shebang must be the name of the interpreter, e.g. #!bash", ErrInvalidLanguage, lang)
+		}
+		return Language{Kind: LanguageShebang, Value: interpreter}, nil
+	case strings.ContainsAny(lang, `*?[{\`):
+		if !doublestar.ValidatePattern(lang) {
+			return Language{}, fmt.Errorf("%w: %q", ErrInvalidLanguage, lang)
+		}
+		return Language{Kind: LanguageGlob, Value: lang}, nil
+	case strings.HasPrefix(lang, ".") && !strings.ContainsAny(lang[1:], "./"):
+		return Language{Kind: LanguageExtension, Value: lang}, nil
+	default:
+		return Language{Kind: LanguageFilename, Value: lang}, nil
+	}
+}
+
+// MatchFile reports whether the file, relative to the repo root, matches the language.
+// The shebang language is never matched by the path, the content of the file needs to be checked.
+func (l Language) MatchFile(file string) bool {
+	name := file
+	if !strings.Contains(l.Value, "/") {
+		name = path.Base(file)
+	}
+	switch l.Kind {
+	case LanguageAny:
+		return true
+	case LanguageExtension:
+		return path.Ext(file) == l.Value
+	case LanguageFilename:
+		return name == l.Value
+	case LanguageGlob:
+		matched, _ := doublestar.Match(l.Value, name)
+		return matched
+	default:
+		return false
+	}
+}
+
+// MatchInterpreter reports whether the shebang line, e.g. "#!/usr/bin/env bash", runs the interpreter of the language.
+// The interpreter with a version suffix matches as well, e.g. "#!python" matches "#!/usr/bin/python3".
+func (l Language) MatchInterpreter(shebang string) bool {
+	if l.Kind != LanguageShebang {
+		return false
+	}
+	interpreter := ShebangInterpreter(shebang)
+	version, ok := strings.CutPrefix(interpreter, l.Value)
+	return ok && strings.Trim(version, "0123456789.") == ""
+}
+
+// ShebangInterpreter returns the name of the interpreter in the shebang line, e.g. "bash" for "#!/usr/bin/env -S bash -e".
+// It returns "" if the line is not a shebang.
+func ShebangInterpreter(line string) string {
+	line, ok := strings.CutPrefix(line, ShebangLanguagePrefix)
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter != "env" {
+		return interpreter
+	}
+	for _, field := range fields[1:] {
+		// skip the options and the environment variables of env
+		if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+			continue
+		}
+		return path.Base(field)
+	}
+	return ""
+}
+
+func validateLanguages(languages []string) error {
+	var errs []error
+	for _, lang := range languages {
+		if _, err := ParseLanguage(lang); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
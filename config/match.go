@@ -0,0 +1,246 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// RegexRepoKeyPrefix is the prefix of the CustomRepos key which is a regular expression matching the whole "org/repo",
+// e.g. "re:^qiniu/.*-service$".
+const RegexRepoKeyPrefix = "re:"
+
+var (
+	ErrInvalidRepoPattern   = errors.New("invalid org/repo pattern")
+	ErrInvalidBranchPattern = errors.New("invalid branch pattern")
+)
+
+// MatchedRepoConfig is an entry of CustomRepos matching the org/repo.
+type MatchedRepoConfig struct {
+	// Key is the key of the entry in CustomRepos, e.g. "qiniu", "qiniu/kodo-*" or "re:^qiniu/.*-service$".
+	Key string
+	RepoConfig
+}
+
+// repoKey is a parsed key of CustomRepos.
+type repoKey struct {
+	key string
+	// rank is the specificity of the key, the greater one takes precedence:
+	// org glob < org < org/repo glob or regex < org/repo.
+	rank int
+	// literal is the length of the literal part of the pattern, the longer one is more specific.
+	literal int
+	re      *regexp.Regexp
+}
+
+func parseRepoKey(key string) (repoKey, error) {
+	if expr, ok := strings.CutPrefix(key, RegexRepoKeyPrefix); ok {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return repoKey{}, fmt.Errorf("%w: %s: %w", ErrInvalidRepoPattern, key, err)
+		}
+		var literal int
+		if unanchored, err := regexp.Compile(strings.TrimPrefix(expr, "^")); err == nil {
+			prefix, _ := unanchored.LiteralPrefix()
+			literal = len(prefix)
+		}
+		return repoKey{key: key, rank: 2, literal: literal, re: re}, nil
+	}
+
+	isRepo := strings.Contains(key, "/")
+	if !strings.ContainsAny(key, `*?[\`) {
+		if isRepo {
+			return repoKey{key: key, rank: 3, literal: len(key)}, nil
+		}
+		return repoKey{key: key, rank: 1, literal: len(key)}, nil
+	}
+	if _, err := path.Match(key, ""); err != nil {
+		return repoKey{}, fmt.Errorf("%w: %s: %w", ErrInvalidRepoPattern, key, err)
+	}
+	literal := len(key) - strings.Count(key, "*") - strings.Count(key, "?")
+	if isRepo {
+		return repoKey{key: key, rank: 2, literal: literal}, nil
+	}
+	return repoKey{key: key, rank: 0, literal: literal}, nil
+}
+
+func (k repoKey) match(org, repo string) bool {
+	orgRepo := org + "/" + repo
+	switch {
+	case k.re != nil:
+		return k.re.MatchString(orgRepo)
+	case k.rank == 1:
+		return k.key == org
+	case k.rank == 3:
+		return k.key == orgRepo
+	case k.rank == 0:
+		ok, _ := path.Match(k.key, org)
+		return ok
+	default:
+		ok, _ := path.Match(k.key, orgRepo)
+		return ok
+	}
+}
+
+// MatchRepoConfigs returns the entries of CustomRepos matching the org/repo, in the order of ascending precedence,
+// so the latter ones override the former ones when merged.
+//
+// The key of CustomRepos can be an org, an org/repo, a glob pattern of them like "qiniu/kodo-*",
+// or a regular expression with the RegexRepoKeyPrefix matching the whole org/repo.
+// The entries are ordered by priority first, then the specificity of the key:
+// org glob < org < org/repo glob or regex < org/repo, and the pattern with the longer literal part is more specific.
+func (c Config) MatchRepoConfigs(org, repo string) []MatchedRepoConfig {
+	var keys []repoKey
+	for key := range c.CustomRepos {
+		k, err := parseRepoKey(key)
+		if err != nil {
+			// already reported by the validation
+			continue
+		}
+		if k.match(org, repo) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := c.CustomRepos[keys[i].key].Priority, c.CustomRepos[keys[j].key].Priority
+		if pi != pj {
+			return pi < pj
+		}
+		if keys[i].rank != keys[j].rank {
+			return keys[i].rank < keys[j].rank
+		}
+		if keys[i].literal != keys[j].literal {
+			return keys[i].literal < keys[j].literal
+		}
+		return keys[i].key < keys[j].key
+	})
+
+	matched := make([]MatchedRepoConfig, 0, len(keys))
+	for _, k := range keys {
+		matched = append(matched, MatchedRepoConfig{Key: k.key, RepoConfig: c.CustomRepos[k.key]})
+	}
+	return matched
+}
+
+// GetRefs returns the refs to clone for the org/repo, which are from the matched entry with the highest precedence
+// specifying refs, including the refs of its profiles, and the key of the entry.
+func (c Config) GetRefs(org, repo string) ([]Refs, string) {
+	matched := c.MatchRepoConfigs(org, repo)
+	for i := len(matched) - 1; i >= 0; i-- {
+		if refs := c.repoRefs(matched[i].RepoConfig); len(refs) > 0 {
+			return refs, matched[i].Key
+		}
+	}
+	return nil, ""
+}
+
+// MatchedBranchConfig is an entry of RepoConfig.Branches matching the branch.
+type MatchedBranchConfig struct {
+	// Key is the key of the entry in Branches, e.g. "main", "release/**" or "re:^v\d+$".
+	Key string
+	BranchConfig
+}
+
+// branchKey is a parsed key of RepoConfig.Branches.
+type branchKey struct {
+	key string
+	// rank is the specificity of the key, the greater one takes precedence: glob or regex < exact.
+	rank int
+	// literal is the length of the literal part of the pattern, the longer one is more specific.
+	literal int
+	re      *regexp.Regexp
+}
+
+func parseBranchKey(key string) (branchKey, error) {
+	if expr, ok := strings.CutPrefix(key, RegexRepoKeyPrefix); ok {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return branchKey{}, fmt.Errorf("%w: %s: %w", ErrInvalidBranchPattern, key, err)
+		}
+		var literal int
+		if unanchored, err := regexp.Compile(strings.TrimPrefix(expr, "^")); err == nil {
+			prefix, _ := unanchored.LiteralPrefix()
+			literal = len(prefix)
+		}
+		return branchKey{key: key, literal: literal, re: re}, nil
+	}
+
+	if !strings.ContainsAny(key, `*?[{\`) {
+		return branchKey{key: key, rank: 1, literal: len(key)}, nil
+	}
+	if !doublestar.ValidatePattern(key) {
+		return branchKey{}, fmt.Errorf("%w: %s", ErrInvalidBranchPattern, key)
+	}
+	return branchKey{key: key, literal: len(key) - strings.Count(key, "*") - strings.Count(key, "?")}, nil
+}
+
+func (k branchKey) match(branch string) bool {
+	switch {
+	case k.re != nil:
+		return k.re.MatchString(branch)
+	case k.rank == 1:
+		return k.key == branch
+	default:
+		ok, _ := doublestar.Match(k.key, branch)
+		return ok
+	}
+}
+
+// MatchBranchConfigs returns the entries of Branches matching the base branch, in the order of ascending precedence.
+// The key can be a branch name, a glob pattern like "release/**", or a regular expression with the RegexRepoKeyPrefix.
+// The exact branch name takes precedence over the patterns, and the pattern with the longer literal part is more specific.
+// Nothing matches the empty branch.
+func (rc RepoConfig) MatchBranchConfigs(branch string) []MatchedBranchConfig {
+	if branch == "" {
+		return nil
+	}
+
+	var keys []branchKey
+	for key := range rc.Branches {
+		k, err := parseBranchKey(key)
+		if err != nil {
+			// already reported by the validation
+			continue
+		}
+		if k.match(branch) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].rank != keys[j].rank {
+			return keys[i].rank < keys[j].rank
+		}
+		if keys[i].literal != keys[j].literal {
+			return keys[i].literal < keys[j].literal
+		}
+		return keys[i].key < keys[j].key
+	})
+
+	matched := make([]MatchedBranchConfig, 0, len(keys))
+	for _, k := range keys {
+		matched = append(matched, MatchedBranchConfig{Key: k.key, BranchConfig: rc.Branches[k.key]})
+	}
+	return matched
+}
+
+func (c *Config) validateRepoKeys() error {
+	var errs []error
+	for _, key := range sortedKeys(c.CustomRepos) {
+		if _, err := parseRepoKey(key); err != nil {
+			errs = append(errs, fmt.Errorf("customRepos[%s]: %w", key, err))
+		}
+		for _, branch := range sortedKeys(c.CustomRepos[key].Branches) {
+			if _, err := parseBranchKey(branch); err != nil {
+				errs = append(errs, fmt.Errorf("customRepos[%s].branches[%s]: %w", key, branch, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
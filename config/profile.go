@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrUnknownProfile     = errors.New("unknown profile")
+	ErrProfileCycle       = errors.New("profile extends itself")
+	ErrInvalidUnsetField  = errors.New("invalid unset field")
+	linterUnsetableFields = unsetableLinterFields()
+)
+
+// Profile is a named bundle of linter settings and refs shared by the org/repo entries, see RepoConfig.Extends.
+type Profile struct {
+	// Extends is the profiles this profile inherits from, applied in order before the profile itself.
+	Extends []string `json:"extends,omitempty"`
+	// Refs are the extra repositories to clone, see RepoConfig.Refs.
+	Refs    []Refs            `json:"refs,omitempty"`
+	Linters map[string]Linter `json:"linters,omitempty"`
+}
+
+// ExpandProfiles returns the profiles to apply in order for the extends, including the inherited ones.
+// The parents are applied before the children, and each profile is applied only once.
+func (c Config) ExpandProfiles(extends []string) []string {
+	var expanded []string
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		profile, ok := c.Profiles[name]
+		if !ok || visited[name] {
+			// unknown profiles and cycles are reported by the validation
+			return
+		}
+		visited[name] = true
+		for _, parent := range profile.Extends {
+			visit(parent)
+		}
+		expanded = append(expanded, name)
+	}
+	for _, name := range extends {
+		visit(name)
+	}
+	return expanded
+}
+
+// repoRefs returns the refs of the entry, which are the refs of its profiles followed by its own refs.
+// The latter ref of the same org/repo replaces the former one.
+func (c Config) repoRefs(rc RepoConfig) []Refs {
+	if len(rc.Extends) == 0 {
+		return rc.Refs
+	}
+
+	var refs []Refs
+	add := func(ref Refs) {
+		for i := range refs {
+			if refs[i].Org == ref.Org && refs[i].Repo == ref.Repo {
+				refs[i] = ref
+				return
+			}
+		}
+		refs = append(refs, ref)
+	}
+	for _, name := range c.ExpandProfiles(rc.Extends) {
+		for _, ref := range c.Profiles[name].Refs {
+			add(ref)
+		}
+	}
+	for _, ref := range rc.Refs {
+		add(ref)
+	}
+	return refs
+}
+
+// unsetLinterFields resets the fields of the linter to zero values, so the defaults are used.
+// The fields are the json names, e.g. "args", "env".
+func unsetLinterFields(l Linter, fields []string) Linter {
+	v := reflect.ValueOf(&l).Elem()
+	for _, field := range fields {
+		if i, ok := linterUnsetableFields[field]; ok {
+			v.Field(i).SetZero()
+		}
+	}
+	return l
+}
+
+// unsetableLinterFields returns the index of the linter fields which can be unset by the json name.
+func unsetableLinterFields() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(Linter{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonFieldName(t.Field(i)); name != "" && name != "unset" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+func validateUnset(fields []string) error {
+	var invalid []string
+	for _, field := range fields {
+		if _, ok := linterUnsetableFields[field]; !ok {
+			invalid = append(invalid, field)
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidUnsetField, strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+func (c *Config) validateProfiles() error {
+	var errs []error
+	checkExtends := func(path string, extends []string) {
+		for _, name := range extends {
+			if _, ok := c.Profiles[name]; !ok {
+				errs = append(errs, fmt.Errorf("%s.extends: %w: %s", path, ErrUnknownProfile, name))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(c.Profiles) {
+		checkExtends(fmt.Sprintf("profiles[%s]", name), c.Profiles[name].Extends)
+		if chain := c.profileCycle(name, nil); chain != nil {
+			errs = append(errs, fmt.Errorf("profiles[%s]: %w: %s", name, ErrProfileCycle, strings.Join(chain, " -> ")))
+		}
+	}
+	for _, orgRepo := range sortedKeys(c.CustomRepos) {
+		checkExtends(fmt.Sprintf("customRepos[%s]", orgRepo), c.CustomRepos[orgRepo].Extends)
+	}
+	return errors.Join(errs...)
+}
+
+// profileCycle returns the chain of the profiles if the profile extends itself, nil if not.
+func (c *Config) profileCycle(name string, chain []string) []string {
+	for i, n := range chain {
+		if n == name {
+			if i == 0 {
+				return append(chain, name)
+			}
+			// the cycle is reported by the profile in it
+			return nil
+		}
+	}
+	chain = append(chain, name)
+	for _, parent := range c.Profiles[name].Extends {
+		if cycle := c.profileCycle(parent, chain); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
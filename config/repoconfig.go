@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// RepoConfigFile is the repo-local config file, which is read from the base branch of the PR/MR.
+// Its content is the same as the entry of CustomRepos, e.g.
+//
+//	linters:
+//	  golangci-lint:
+//	    args: ["run", "--timeout=10m"]
+//	    exclude: ["vendor/**"]
+const RepoConfigFile = ".reviewbot.yaml"
+
+// DefaultRepoConfigAllowedFields is the fields that the repo-local config can set by default.
+// Only the fields which never execute anything are allowed, the fields deciding what and where to execute,
+// like args, command, env and the runners, are left to the admin. Allowing args or command in
+// GlobalConfig.RepoConfigAllowedFields grants the code execution on the runner to anyone who can push to the repo.
+var DefaultRepoConfigAllowedFields = []string{"enable", "include", "exclude", "reportType"}
+
+var (
+	ErrRepoConfigDisabled        = errors.New("repo config is disabled")
+	ErrRepoConfigFieldNotAllowed = errors.New("field is not allowed in repo config")
+	ErrRepoConfigPathNotAllowed  = errors.New("path must be relative and inside the repo")
+	// ErrRepoConfigSecretRef is returned for the secret references in the repo-local config, since they are resolved
+	// from the env and files of reviewbot itself, only the central config can refer to the secrets.
+	ErrRepoConfigSecretRef = errors.New("secret references are not allowed in repo config")
+)
+
+// repoConfigForbiddenFields can never be set by the repo-local config,
+// since the refs are cloned before the repo-local config is read,
+// the repo-local config always takes precedence over the other entries,
+// and it's read from the base branch so it's already specific to the branch.
+var repoConfigForbiddenFields = map[string]bool{"refs": true, "priority": true, "branches": true}
+
+// ParseRepoConfig parses the repo-local config with the same strict schema as the CustomRepos entry,
+// and validates it against the fields allowed by RepoConfigAllowedFields.
+func (c Config) ParseRepoConfig(data []byte) (RepoConfig, error) {
+	var rc RepoConfig
+	allowed := c.GlobalDefaultConfig.RepoConfigAllowedFields
+	if allowed == nil {
+		allowed = DefaultRepoConfigAllowedFields
+	}
+	if len(allowed) == 0 {
+		return rc, ErrRepoConfigDisabled
+	}
+
+	if err := yaml.UnmarshalStrict(data, &rc); err != nil {
+		return rc, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return rc, err
+	}
+	if err := checkRepoConfigFields(raw, allowed); err != nil {
+		return rc, err
+	}
+	if ref := findSecretRef(raw); ref != "" {
+		return rc, fmt.Errorf("%w: %s", ErrRepoConfigSecretRef, ref)
+	}
+
+	for _, name := range rc.Extends {
+		if _, ok := c.Profiles[name]; !ok {
+			return rc, fmt.Errorf("extends: %w: %s", ErrUnknownProfile, name)
+		}
+	}
+	for _, name := range sortedKeys(rc.Linters) {
+		if err := validateLinter(rc.Linters[name]); err != nil {
+			return rc, fmt.Errorf("linters[%s]: %w", name, err)
+		}
+		if err := validateRepoPaths(rc.Linters[name]); err != nil {
+			return rc, fmt.Errorf("linters[%s]: %w", name, err)
+		}
+	}
+	if err := validateGeneratedFilesRules(rc.GeneratedFiles); err != nil {
+		return rc, fmt.Errorf("generatedFiles: %w", err)
+	}
+	if rc.MaxInlineCommentsPerPR < 0 {
+		return rc, fmt.Errorf("maxInlineCommentsPerPR: %w, got %d", ErrInvalidMaxInlineComments, rc.MaxInlineCommentsPerPR)
+	}
+	return rc, nil
+}
+
+// validateRepoPaths checks that the paths set by the repo-local config do not escape the checkout of the repo.
+func validateRepoPaths(l Linter) error {
+	for _, f := range []struct{ name, path string }{{"workDir", l.WorkDir}, {"configPath", l.ConfigPath}} {
+		if f.path == "" {
+			continue
+		}
+		if path.IsAbs(f.path) || filepath.IsAbs(f.path) || slices.Contains(strings.Split(filepath.ToSlash(f.path), "/"), "..") {
+			return fmt.Errorf("%s: %w: %s", f.name, ErrRepoConfigPathNotAllowed, f.path)
+		}
+	}
+	return nil
+}
+
+// findSecretRef returns the first secret reference in the string values of the raw config, "" if not found.
+func findSecretRef(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return secretRefPattern.FindString(v)
+	case []interface{}:
+		for _, item := range v {
+			if ref := findSecretRef(item); ref != "" {
+				return ref
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ref := findSecretRef(v[key]); ref != "" {
+				return ref
+			}
+		}
+	}
+	return ""
+}
+
+// checkRepoConfigFields checks that all fields set in the raw repo config are allowed.
+func checkRepoConfigFields(raw map[string]interface{}, allowed []string) error {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		allowedSet[field] = true
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key != "linters" {
+			if repoConfigForbiddenFields[key] || !allowedSet[key] {
+				return fmt.Errorf("%w: %s", ErrRepoConfigFieldNotAllowed, key)
+			}
+			continue
+		}
+
+		linters, _ := raw[key].(map[string]interface{})
+		for name, l := range linters {
+			fields, _ := l.(map[string]interface{})
+			for field := range fields {
+				if !allowedSet[field] {
+					return fmt.Errorf("%w: linters[%s].%s", ErrRepoConfigFieldNotAllowed, name, field)
+				}
+			}
+			// the fields not allowed to set are not allowed to unset either
+			unset, _ := fields["unset"].([]interface{})
+			for _, field := range unset {
+				if f, _ := field.(string); !allowedSet[f] {
+					return fmt.Errorf("%w: linters[%s].unset: %v", ErrRepoConfigFieldNotAllowed, name, field)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WithRepoConfig returns a copy of the config with the repo-local config of the repo, and the config itself is not modified.
+// The linters of the repo-local config are applied as the last layer, see LinterLayers,
+// so they take precedence over all the central config of the repo, including the branch scoped one.
+// The other settings are merged into the CustomRepos entry of the repo.
+func (c Config) WithRepoConfig(org, repo string, rc RepoConfig) Config {
+	orgRepo := org + "/" + repo
+	merged := c.CustomRepos[orgRepo]
+
+	repoLinters := make(map[string]map[string]Linter, len(c.repoLinters)+1)
+	for k, v := range c.repoLinters {
+		repoLinters[k] = v
+	}
+	linters := make(map[string]Linter, len(rc.Linters))
+	for name, linter := range rc.Linters {
+		linters[name] = linter
+	}
+	repoLinters[orgRepo] = linters
+	c.repoLinters = repoLinters
+
+	generated := GeneratedFiles{
+		Paths:          append(append([]string{}, merged.GeneratedFiles.Paths...), rc.GeneratedFiles.Paths...),
+		HeaderPatterns: make(map[string][]string),
+	}
+	for _, g := range []GeneratedFiles{merged.GeneratedFiles, rc.GeneratedFiles} {
+		for ext, patterns := range g.HeaderPatterns {
+			generated.HeaderPatterns[ext] = append(generated.HeaderPatterns[ext], patterns...)
+		}
+	}
+	merged.GeneratedFiles = generated
+
+	if rc.MaxInlineCommentsPerPR != 0 {
+		merged.MaxInlineCommentsPerPR = rc.MaxInlineCommentsPerPR
+	}
+	if len(rc.Extends) > 0 {
+		merged.Extends = append(append([]string{}, merged.Extends...), rc.Extends...)
+	}
+	// the repo-local settings of the entry, e.g. maxInlineCommentsPerPR, must not be overridden by the other matched entries
+	for _, matched := range c.MatchRepoConfigs(org, repo) {
+		if matched.Priority > merged.Priority {
+			merged.Priority = matched.Priority
+		}
+	}
+
+	customRepos := make(map[string]RepoConfig, len(c.CustomRepos)+1)
+	for k, v := range c.CustomRepos {
+		customRepos[k] = v
+	}
+	customRepos[orgRepo] = merged
+	c.CustomRepos = customRepos
+	return c
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// schemaEnums is the allowed values of the enum types in the config.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(ReportType("")): {
+		string(GitHubCheckRuns), string(GitHubPRReview), string(GitHubMixType),
+		string(GitLabComment), string(GitLabCommentAndDiscussion), string(Quiet),
+	},
+	reflect.TypeOf(OutputFormat("")): {
+		string(OutputFormatText), string(OutputFormatSarif), string(OutputFormatCheckstyle), string(OutputFormatJSONLines),
+	},
+	reflect.TypeOf(Severity("")): {
+		string(SeverityError), string(SeverityWarning), string(SeverityInfo), string(SeveritySuggest),
+	},
+	reflect.TypeOf(DiffMode("")): {
+		string(DiffModeAdded), string(DiffModeHunk), string(DiffModeContext), string(DiffModeFile),
+	},
+	reflect.TypeOf(Scope("")): {
+		string(ScopeDiff), string(ScopeFiles), string(ScopeRepo),
+	},
+}
+
+// JSONSchema returns the JSON Schema of the config file, which can be used by editors for completion and validation.
+// It's generated from the Config struct, so it's always in sync with the code.
+func JSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "reviewbot config"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// RepoConfigJSONSchema returns the JSON Schema of the repo-local config file, see RepoConfigFile.
+func RepoConfigJSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(RepoConfig{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "reviewbot repo config"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+	if t == reflect.TypeOf(Duration(0)) {
+		return map[string]interface{}{
+			"type":        []string{"string", "number"},
+			"description": `duration like "10m" or "90s", or the number of seconds`,
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		addStructProperties(t, properties)
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// addStructProperties adds the configurable fields of the struct, the fields of the embedded struct are inlined.
+func addStructProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if _, ok := f.Tag.Lookup("json"); !ok {
+				addStructProperties(f.Type, properties)
+				continue
+			}
+		}
+		name := jsonFieldName(f)
+		if name == "" {
+			continue
+		}
+		properties[name] = typeSchema(f.Type)
+	}
+}
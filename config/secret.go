@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The sources of the secret references in Linter.Env, e.g.
+// * GITHUB_TOKEN=${env:GITHUB_TOKEN}, the env of reviewbot itself.
+// * GOPRIVATE_TOKEN=${file:/etc/reviewbot/secrets/token}, the content of the file, with the trailing newline trimmed.
+// * NPM_TOKEN=${k8s:npm-secret/token}, the key of the Kubernetes secret in the namespace of the job,
+// only supported by the kubernetes runner and must be the whole value.
+const (
+	SecretSourceEnv  = "env"
+	SecretSourceFile = "file"
+	SecretSourceK8s  = "k8s"
+)
+
+var (
+	ErrInvalidSecretRef    = errors.New("invalid secret reference")
+	ErrSecretRefNotAllowed = errors.New("secret references are only allowed in env, refer to the env like $NAME instead")
+)
+
+// secretRefPattern matches the secret references, but not the shell parameter expansions like ${env:-default}.
+var secretRefPattern = regexp.MustCompile(`\$\{(env|file|k8s):([^-=+?}][^}]*)\}`)
+
+// SecretRef is a secret reference like ${env:NAME} in the env of the linter.
+type SecretRef struct {
+	// Ref is the whole reference, e.g. "${env:NAME}".
+	Ref string
+	// Source is where the secret comes from, one of env, file and k8s.
+	Source string
+	// Name is the env name, the file path, or the Kubernetes secret name.
+	Name string
+	// Key is the key of the Kubernetes secret.
+	Key string
+}
+
+// ParseSecretRefs parses the secret references in the value.
+func ParseSecretRefs(value string) ([]SecretRef, error) {
+	var refs []SecretRef
+	for _, m := range secretRefPattern.FindAllStringSubmatch(value, -1) {
+		ref := SecretRef{Ref: m[0], Source: m[1], Name: strings.TrimSpace(m[2])}
+		if ref.Source == SecretSourceK8s {
+			name, key, ok := strings.Cut(ref.Name, "/")
+			if !ok || name == "" || key == "" {
+				return nil, fmt.Errorf("%w: %s, must be ${k8s:secret-name/key}", ErrInvalidSecretRef, ref.Ref)
+			}
+			ref.Name, ref.Key = name, key
+		}
+		if ref.Name == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSecretRef, ref.Ref)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// validateSecretRefs validates the secret references of the linter, which are only allowed in the env values.
+func validateSecretRefs(l Linter) error {
+	var errs []error
+	for _, env := range l.Env {
+		name, value, _ := strings.Cut(env, "=")
+		refs, err := ParseSecretRefs(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", name, err))
+			continue
+		}
+		for _, ref := range refs {
+			if ref.Source == SecretSourceK8s && ref.Ref != value {
+				errs = append(errs, fmt.Errorf("env %s: %w: %s must be the whole value", name, ErrInvalidSecretRef, ref.Ref))
+			}
+		}
+	}
+	for _, s := range append(append([]string{}, l.Command...), l.Args...) {
+		if secretRefPattern.MatchString(s) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrSecretRefNotAllowed, secretRefPattern.FindString(s)))
+		}
+	}
+	return errors.Join(errs...)
+}
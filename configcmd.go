@@ -0,0 +1,208 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
+)
+
+var (
+	errConfigCommandUsage = errors.New("usage: reviewbot config validate|explain|schema [flags]")
+	errInvalidConfig      = errors.New("invalid config")
+	errInvalidRepoName    = errors.New("repo must be in the form of org/repo")
+)
+
+// runConfigCommand runs the `reviewbot config` subcommands, which help to check the config without starting the server.
+func runConfigCommand(args []string) error {
+	if len(args) == 0 {
+		return errConfigCommandUsage
+	}
+	switch args[0] {
+	case "validate":
+		return runConfigValidate(os.Stdout, args[1:])
+	case "explain":
+		return runConfigExplain(os.Stdout, args[1:])
+	case "schema":
+		return runConfigSchema(os.Stdout, args[1:])
+	default:
+		return errConfigCommandUsage
+	}
+}
+
+// runConfigValidate runs all the checks of the config and reports every error.
+func runConfigValidate(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	configFile := fs.String("config", "", "config file to validate")
+	repos := fs.String("repos", "", "comma separated org/repo to show the matched customRepos entries of")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configFile == "" && fs.NArg() > 0 {
+		*configFile = fs.Arg(0)
+	}
+	if *configFile == "" {
+		return fmt.Errorf("%w: missing config file", errConfigCommandUsage)
+	}
+
+	var errs []error
+	cfg, err := config.NewConfig(*configFile)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if err := lint.ValidateFilters(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		var count int
+		for _, line := range strings.Split(err.Error(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(w, "error: %s\n", line)
+				count++
+			}
+		}
+		return fmt.Errorf("%w: %d error(s) found in %s", errInvalidConfig, count, *configFile)
+	}
+	fmt.Fprintf(w, "%s is valid\n", *configFile)
+
+	for _, orgRepo := range strings.Split(*repos, ",") {
+		if orgRepo = strings.TrimSpace(orgRepo); orgRepo == "" {
+			continue
+		}
+		org, repo, ok := strings.Cut(orgRepo, "/")
+		if !ok || org == "" || repo == "" {
+			return fmt.Errorf("%w: %s", errInvalidRepoName, orgRepo)
+		}
+		fmt.Fprintf(w, "%s matches customRepos: %s\n", orgRepo, matchedRepoKeys(cfg, org, repo))
+	}
+	return nil
+}
+
+// matchedRepoKeys returns the keys of the customRepos entries matching the repo, in the order of ascending precedence.
+func matchedRepoKeys(cfg config.Config, org, repo string) string {
+	matched := cfg.MatchRepoConfigs(org, repo)
+	if len(matched) == 0 {
+		return "<none>"
+	}
+	keys := make([]string, 0, len(matched))
+	for _, m := range matched {
+		keys = append(keys, m.Key)
+	}
+	return strings.Join(keys, " < ")
+}
+
+// runConfigExplain prints the effective linter config of the repo and the layer which set each field.
+func runConfigExplain(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("config explain", flag.ExitOnError)
+	configFile := fs.String("config", "", "config file")
+	platform := fs.String("platform", "github", "platform of the repo, github or gitlab")
+	branch := fs.String("branch", "", "base branch of the PR/MR, to apply the branch specific config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("%w: explain [-config file] [-platform github|gitlab] [-branch branch] org/repo [linter]", errConfigCommandUsage)
+	}
+	org, repo, ok := strings.Cut(fs.Arg(0), "/")
+	if !ok || org == "" || repo == "" {
+		return fmt.Errorf("%w: %s", errInvalidRepoName, fs.Arg(0))
+	}
+
+	var repoType config.Platform
+	switch strings.ToLower(*platform) {
+	case "github":
+		repoType = config.GitHub
+	case "gitlab":
+		repoType = config.GitLab
+	default:
+		return fmt.Errorf("%w: unknown platform %s", errConfigCommandUsage, *platform)
+	}
+
+	var cfg config.Config
+	if *configFile != "" {
+		var err error
+		cfg, err = config.NewConfig(*configFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+	}
+
+	var names []string
+	if fs.NArg() == 2 {
+		names = []string{fs.Arg(1)}
+	} else {
+		seen := make(map[string]bool)
+		for name := range lint.TotalPullRequestHandlers() {
+			seen[name] = true
+		}
+		for name := range cfg.CustomLinters {
+			seen[name] = true
+		}
+		for name := range seen {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	fmt.Fprintf(w, "# %s/%s matches customRepos: %s\n\n", org, repo, matchedRepoKeys(cfg, org, repo))
+	for i, name := range names {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if *branch != "" {
+			fmt.Fprintf(w, "# %s on %s/%s@%s\n", name, org, repo, *branch)
+		} else {
+			fmt.Fprintf(w, "# %s on %s/%s\n", name, org, repo)
+		}
+		for _, source := range cfg.ExplainLinterConfig(org, repo, *branch, name, repoType) {
+			value, err := json.Marshal(source.Value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s: %s  # %s\n", source.Field, value, source.Layer)
+		}
+	}
+	return nil
+}
+
+// runConfigSchema prints the JSON Schema of the config file.
+func runConfigSchema(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("config schema", flag.ExitOnError)
+	repoConfig := fs.Bool("repo-config", false, "print the schema of the repo-local "+config.RepoConfigFile+" instead")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	schema, err := config.JSONSchema()
+	if *repoConfig {
+		schema, err = config.RepoConfigJSONSchema()
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(schema))
+	return err
+}
@@ -20,10 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
-	"github.com/google/go-github/v57/github"
 	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/reviewbot/internal/cache"
 	"github.com/qiniu/reviewbot/internal/llm"
@@ -53,7 +51,7 @@ type Agent struct {
 	Provider Provider
 	// LinterConfig is the linter configuration.
 	LinterConfig config.Linter
-	// RepoDir is the main repo directory.
+	// RepoDir is the checkout directory of the main repo, where the source files are read from.
 	RepoDir string
 	// GenLogKey generates the log key.
 	GenLogKey func() string
@@ -61,8 +59,23 @@ type Agent struct {
 	GenLogViewURL func() string
 	// IssueReferences is the compiled issue references config for the linter.
 	IssueReferences []config.CompiledIssueReference
+	// IssueContentGetter fetches the content of the issue references.
+	// Optional, if nil, the issues are fetched anonymously.
+	IssueContentGetter IssueContentGetter
 	// ModelClient is the LLM model client.
 	ModelClient llms.Model
+	// Baseline is the accepted findings of the repo, which will not be reported.
+	// Optional, nil means no baseline.
+	Baseline *Baseline
+	// GeneratedFileDetector decides whether a file is generated, lint errors on generated files are ignored.
+	// Optional, if nil, the default detector of the repo is used.
+	GeneratedFileDetector GeneratedFileDetector
+	// Aggregator collects the results of all linters of the PR/MR to merge duplicates before reporting.
+	// Optional, if nil, the results are reported immediately.
+	Aggregator *Aggregator
+	// InlineCommentsBudget is the max inline comments allocated to the linter from the budget of the PR/MR.
+	// It's set by the Aggregator, optional, nil means no budget across linters.
+	InlineCommentsBudget *int
 }
 
 // getMsgFormat returns the message format based on report type.
@@ -80,28 +93,22 @@ func (a *Agent) getIssueContent(ctx context.Context, ref config.CompiledIssueRef
 	log := util.FromContext(ctx)
 
 	// Try cache first
-	if content, ok := issueCache.Get(ref.URL); ok && !issueCache.IsExpired(ref.URL) {
+	key := ref.ContentKey()
+	if content, ok := issueCache.Get(key); ok && !issueCache.IsExpired(key) {
 		return content, nil
 	}
 
-	if ref.IssueNumber == 0 {
-		return "", ErrIssueNumberIsZero
+	getter := a.IssueContentGetter
+	if getter == nil {
+		getter = NewIssueContentGetter(nil, nil)
 	}
-
-	// Fetch from GitHub
-	issue, resp, err := github.NewClient(http.DefaultClient).Issues.Get(ctx, "qiniu", "reviewbot", ref.IssueNumber)
+	content, err := getter.GetIssueContent(ctx, ref)
 	if err != nil {
-		log.Errorf("failed to fetch issue content: %s", err)
+		log.Errorf("failed to fetch issue content of %s: %v", key, err)
 		return "", err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Errorf("failed to fetch issue content, resp: %v", resp)
-		return "", ErrFailedToFetchIssueContent
-	}
-
-	content := issue.GetBody()
-	issueCache.Set(ref.URL, content)
+	issueCache.Set(key, content)
 	return content, nil
 }
 
@@ -112,8 +119,20 @@ func (a *Agent) processOutput(ctx context.Context, output LinterOutput, ref conf
 	}
 
 	newOutput := output
-	newOutput.TypedMessage = fmt.Sprintf(msgFormat, output.Message, ref.URL)
+	if ref.Severity != "" {
+		newOutput.Severity = ref.Severity
+	}
 
+	// the local knowledge base without url has no link to refer to, so its content is always added
+	if ref.URL == "" {
+		newOutput.TypedMessage = output.Message
+		if content, err := a.getIssueContent(ctx, ref); err == nil {
+			newOutput.TypedMessage += fmt.Sprintf(ReferenceFooter, content)
+		}
+		return newOutput, true
+	}
+
+	newOutput.TypedMessage = fmt.Sprintf(msgFormat, output.Message, ref.URL)
 	// Add issue content for PR review formats
 	if a.LinterConfig.ReportType == config.GitHubPRReview || a.LinterConfig.ReportType == config.GitHubMixType {
 		if content, err := a.getIssueContent(ctx, ref); err == nil {
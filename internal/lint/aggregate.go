@@ -0,0 +1,181 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/util"
+)
+
+// Aggregator collects the lint results of all linters running on a PR/MR,
+// and merges the duplicates reported by different linters before reporting,
+// e.g. golangci-lint with staticcheck enabled and the standalone staticcheck.
+// It's safe for concurrent use.
+type Aggregator struct {
+	mu      sync.Mutex
+	reports []pendingReport
+	// maxInlineComments is the max inline comments posted by all linters, 0 means no limit.
+	maxInlineComments int
+}
+
+type pendingReport struct {
+	agent   Agent
+	results map[string][]LinterOutput
+}
+
+// NewAggregator creates an aggregator for a PR/MR.
+// maxInlineComments is the budget of inline comments across linters, 0 means no limit.
+func NewAggregator(maxInlineComments int) *Aggregator {
+	return &Aggregator{maxInlineComments: maxInlineComments}
+}
+
+// Add adds the filtered lint results of the linter, which will be reported when flushing.
+func (g *Aggregator) Add(a Agent, lintResults map[string][]LinterOutput) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.reports = append(g.reports, pendingReport{agent: a, results: lintResults})
+}
+
+// Flush merges the duplicates across linters, allocates the inline comments budget and reports the results of each linter.
+// It should be called after all linters are finished.
+func (g *Aggregator) Flush(ctx context.Context) error {
+	log := util.FromContext(ctx)
+	g.mu.Lock()
+	reports := g.reports
+	g.reports = nil
+	g.mu.Unlock()
+
+	mergeDuplicates(reports)
+	allocateInlineComments(reports, g.maxInlineComments)
+
+	var errs []error
+	for _, r := range reports {
+		if err := r.agent.Provider.Report(ctx, r.agent, r.results); err != nil {
+			log.Errorf("[%s] failed to report: %v", r.agent.LinterConfig.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", r.agent.LinterConfig.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mergeDuplicates merges the outputs at the same location with the same normalized message across linters.
+// The output is kept by the first linter in name order, and the other linters are recorded in its Linters.
+// The linters which don't report inline, such as quiet or repo scope, are not merged,
+// otherwise the issue may be hidden from the PR/MR.
+func mergeDuplicates(reports []pendingReport) {
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].agent.LinterConfig.Name < reports[j].agent.LinterConfig.Name
+	})
+
+	type location struct {
+		report int
+		file   string
+		index  int
+	}
+	seen := make(map[string]location)
+	for i := range reports {
+		linter := reports[i].agent.LinterConfig
+		if linter.ReportType == config.Quiet || linter.Scope == config.ScopeRepo {
+			continue
+		}
+
+		files := make([]string, 0, len(reports[i].results))
+		for file := range reports[i].results {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+
+		merged := make(map[string][]LinterOutput, len(reports[i].results))
+		for _, file := range files {
+			for _, output := range reports[i].results[file] {
+				key := dedupKey(file, output)
+				loc, ok := seen[key]
+				if !ok || loc.report == i {
+					merged[file] = append(merged[file], output)
+					if !ok {
+						seen[key] = location{report: i, file: file, index: len(merged[file]) - 1}
+					}
+					continue
+				}
+
+				primary := &reports[loc.report].results[loc.file][loc.index]
+				if !slices.Contains(primary.Linters, linter.Name) {
+					primary.Linters = append(primary.Linters, linter.Name)
+				}
+				// keep the most severe one, so that the merged issue is not underestimated
+				if severityRank(SeverityOf(output)) > severityRank(SeverityOf(*primary)) {
+					primary.Severity = output.Severity
+				}
+			}
+		}
+		reports[i].results = merged
+	}
+}
+
+var (
+	// trailingTagRex matches the trailing tag of a message, like "(staticcheck)", "(SA4006)", "[SC2086]".
+	trailingTagRex = regexp.MustCompile(`\s*[(\[][\w\-./]+[)\]]\s*$`)
+	// leadingRuleRex matches the leading rule ID of a message, like "SA4006: ".
+	leadingRuleRex = regexp.MustCompile(`^[A-Za-z]+-?[0-9]+:\s*`)
+)
+
+// dedupKey returns the key to identify the duplicate outputs across linters.
+func dedupKey(file string, o LinterOutput) string {
+	msg := strings.TrimSpace(o.Message)
+	for {
+		trimmed := trailingTagRex.ReplaceAllString(msg, "")
+		if trimmed == msg {
+			break
+		}
+		msg = trimmed
+	}
+	msg = leadingRuleRex.ReplaceAllString(msg, "")
+	msg = strings.NewReplacer("`", "", "'", "", `"`, "").Replace(msg)
+	msg = strings.ToLower(strings.Join(strings.Fields(msg), " "))
+	return fmt.Sprintf("%s:%d:%s", file, o.Line, msg)
+}
+
+// severityRank returns the rank of the severity, the higher the more severe.
+func severityRank(severity config.Severity) int {
+	switch severity {
+	case config.SeverityError:
+		return 3
+	case config.SeverityWarning:
+		return 2
+	case config.SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// alsoReportedBy returns the note of other linters which reported the same issue, empty if none.
+func alsoReportedBy(o LinterOutput) string {
+	if len(o.Linters) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\n<sub>also reported by: %s</sub>", strings.Join(o.Linters, ", "))
+}
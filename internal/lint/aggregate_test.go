@@ -0,0 +1,124 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+)
+
+func TestAggregatorFlush(t *testing.T) {
+	provider := NewLocalProvider("qiniu", "reviewbot", nil)
+	newAgent := func(name string, reportType config.ReportType) Agent {
+		return Agent{
+			LinterConfig: config.Linter{Name: name, ReportType: reportType},
+			Provider:     provider,
+		}
+	}
+
+	g := NewAggregator(0)
+	g.Add(newAgent("staticcheck", config.GitHubMixType), map[string][]LinterOutput{
+		"a.go": {
+			{File: "a.go", Line: 10, Message: "this value of err is never used (SA4006)", Severity: config.SeverityError},
+			{File: "a.go", Line: 20, Message: "should omit comparison to bool constant (S1002)"},
+		},
+	})
+	g.Add(newAgent("golangci-lint", config.GitHubMixType), map[string][]LinterOutput{
+		"a.go": {
+			{File: "a.go", Line: 10, Message: "SA4006: this value of `err` is never used (staticcheck)"},
+			{File: "a.go", Line: 30, Message: "Error return value is not checked (errcheck)"},
+		},
+	})
+	g.Add(newAgent("quiet-linter", config.Quiet), map[string][]LinterOutput{
+		"a.go": {
+			{File: "a.go", Line: 10, Message: "this value of err is never used"},
+		},
+	})
+
+	if err := g.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]map[string][]LinterOutput{
+		"golangci-lint": {
+			"a.go": {
+				{File: "a.go", Line: 10, Message: "SA4006: this value of `err` is never used (staticcheck)", Severity: config.SeverityError, Linters: []string{"staticcheck"}},
+				{File: "a.go", Line: 30, Message: "Error return value is not checked (errcheck)"},
+			},
+		},
+		"staticcheck": {
+			"a.go": {
+				{File: "a.go", Line: 20, Message: "should omit comparison to bool constant (S1002)"},
+			},
+		},
+		"quiet-linter": {
+			"a.go": {
+				{File: "a.go", Line: 10, Message: "this value of err is never used"},
+			},
+		},
+	}
+	if got := provider.Results(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, got)
+	}
+
+	// flushed results should not be reported again
+	provider = NewLocalProvider("qiniu", "reviewbot", nil)
+	if err := g.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := provider.Results(); len(got) != 0 {
+		t.Errorf("expected no results after flushed, got %v", got)
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	tcs := []struct {
+		name  string
+		a, b  LinterOutput
+		equal bool
+	}{
+		{
+			name:  "rule prefix and linter tag",
+			a:     LinterOutput{Line: 1, Message: "SA4006: this value of `x` is never used (staticcheck)"},
+			b:     LinterOutput{Line: 1, Message: "this value of x is never used (SA4006)"},
+			equal: true,
+		},
+		{
+			name:  "different line",
+			a:     LinterOutput{Line: 1, Message: "unused variable"},
+			b:     LinterOutput{Line: 2, Message: "unused variable"},
+			equal: false,
+		},
+		{
+			name:  "different message",
+			a:     LinterOutput{Line: 1, Message: "unused variable x"},
+			b:     LinterOutput{Line: 1, Message: "unused variable y"},
+			equal: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := dedupKey("a.go", tc.a) == dedupKey("a.go", tc.b); got != tc.equal {
+				t.Errorf("expected equal %v, got %v: %q vs %q", tc.equal, got, dedupKey("a.go", tc.a), dedupKey("a.go", tc.b))
+			}
+		})
+	}
+}
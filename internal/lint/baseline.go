@@ -0,0 +1,243 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/qiniu/x/xlog"
+)
+
+// BaselineFile is the baseline file name under the root of the repository.
+const BaselineFile = ".reviewbot-baseline.json"
+
+const baselineVersion = 1
+
+// Baseline records the accepted findings of a repository.
+// It is used to suppress the pre-existing findings when adopting reviewbot on legacy repositories,
+// so that only the new findings are reported even if a changed hunk touches old code.
+type Baseline struct {
+	Version  int               `json:"version"`
+	Findings []BaselineFinding `json:"findings"`
+}
+
+// BaselineFinding is an accepted finding in the baseline.
+type BaselineFinding struct {
+	Linter string `json:"linter"`
+	File   string `json:"file"`
+	Rule   string `json:"rule,omitempty"`
+	// Message is only for human reading, the Fingerprint is used for matching.
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// LoadBaseline loads the baseline from the given path.
+// nil is returned if the baseline file does not exist.
+func LoadBaseline(path string) (*Baseline, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	b, err := ParseBaseline(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// ParseBaseline parses the content of the baseline file, e.g. read from the base branch.
+// nil is returned if the content is nil, i.e. the baseline file does not exist.
+func ParseBaseline(content []byte) (*Baseline, error) {
+	if content == nil {
+		return nil, nil
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(content, &b); err != nil {
+		return nil, fmt.Errorf("invalid baseline file: %w", err)
+	}
+	if b.Version > baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d", b.Version)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to the given path.
+func (b *Baseline) Save(path string) error {
+	b.Version = baselineVersion
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.Linter != y.Linter {
+			return x.Linter < y.Linter
+		}
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		return x.Fingerprint < y.Fingerprint
+	})
+	if b.Findings == nil {
+		b.Findings = []BaselineFinding{}
+	}
+
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0o644)
+}
+
+// Update replaces the findings of the linter with the given lint results.
+// The findings of other linters are kept.
+func (b *Baseline) Update(repoDir, linterName string, lintResults map[string][]LinterOutput) {
+	findings := make([]BaselineFinding, 0, len(b.Findings))
+	for _, f := range b.Findings {
+		if f.Linter != linterName {
+			findings = append(findings, f)
+		}
+	}
+
+	sources := newSourceFiles(repoDir)
+	for file, outputs := range lintResults {
+		for _, o := range outputs {
+			findings = append(findings, BaselineFinding{
+				Linter:      linterName,
+				File:        file,
+				Rule:        o.Rule,
+				Message:     o.Message,
+				Fingerprint: Fingerprint(linterName, o, sources.line(file, o.Line)),
+			})
+		}
+	}
+	b.Findings = findings
+}
+
+// Fingerprint returns the fingerprint of the linter output, which is used to match the output against the baseline.
+// The line number is not part of the fingerprint, instead, the content of the source line is used,
+// so that the fingerprint keeps stable when the code above is changed.
+func Fingerprint(linterName string, o LinterOutput, sourceLine string) string {
+	h := sha256.New()
+	for _, part := range []string{linterName, o.Rule, normalizeMessage(o.Message), o.File, strings.TrimSpace(sourceLine)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+var (
+	numberRex     = regexp.MustCompile(`\d+`)
+	whitespaceRex = regexp.MustCompile(`\s+`)
+)
+
+// normalizeMessage removes the volatile parts of the message, like numbers and redundant whitespaces.
+// e.g. "line is 121 characters" and "line is 125 characters" are treated as the same message.
+func normalizeMessage(msg string) string {
+	msg = numberRex.ReplaceAllString(msg, "0")
+	return strings.TrimSpace(whitespaceRex.ReplaceAllString(msg, " "))
+}
+
+// filterByBaseline filters out the lint errors that are recorded in the baseline.
+// Each finding in the baseline suppresses one lint error at most,
+// so that the new lint errors with the same fingerprint are still reported.
+func filterByBaseline(log *xlog.Logger, a Agent, lintResults map[string][]LinterOutput) map[string][]LinterOutput {
+	if a.Baseline == nil || len(lintResults) == 0 {
+		return lintResults
+	}
+
+	linterName := a.LinterConfig.Name
+	remaining := make(map[string]int)
+	for _, f := range a.Baseline.Findings {
+		if f.Linter == linterName {
+			remaining[f.Fingerprint]++
+		}
+	}
+	if len(remaining) == 0 {
+		return lintResults
+	}
+
+	var suppressed int
+	sources := newSourceFiles(a.RepoDir)
+	results := make(map[string][]LinterOutput, len(lintResults))
+	for file, outputs := range lintResults {
+		for _, o := range outputs {
+			fp := Fingerprint(linterName, o, sources.line(file, o.Line))
+			if remaining[fp] > 0 {
+				remaining[fp]--
+				suppressed++
+				continue
+			}
+			results[file] = append(results[file], o)
+		}
+	}
+
+	if suppressed > 0 {
+		log.Infof("[%s] %d lint errors are suppressed by the baseline", linterName, suppressed)
+	}
+	return results
+}
+
+// sourceFiles reads the source lines of the files under the root directory, with cache.
+type sourceFiles struct {
+	root  string
+	lines map[string][]string
+}
+
+func newSourceFiles(root string) *sourceFiles {
+	return &sourceFiles{root: root, lines: make(map[string][]string)}
+}
+
+// line returns the content of the line (1-based) in the file, empty if not found.
+func (s *sourceFiles) line(file string, line int) string {
+	lines, ok := s.lines[file]
+	if !ok {
+		lines = readLines(filepath.Join(s.root, file))
+		s.lines[file] = lines
+	}
+	if line <= 0 || line > len(lines) {
+		return ""
+	}
+	return lines[line-1]
+}
+
+func readLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
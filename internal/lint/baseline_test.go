@@ -0,0 +1,153 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/xlog"
+)
+
+func TestFilterByBaseline(t *testing.T) {
+	// the source code when the baseline is generated
+	oldSource := "package a\n\nfunc f() {\n\tpanic(1)\n}\n"
+	// the source code of the PR, one line is inserted above the legacy code and a new panic is added
+	newSource := "package a\n\nimport \"fmt\"\n\nfunc f() {\n\tpanic(1)\n}\n\nfunc g() {\n\tpanic(1)\n}\n"
+
+	baselineResults := map[string][]LinterOutput{
+		"a.go": {
+			{File: "a.go", Line: 4, Column: 2, Message: "do not panic in line 4"},
+		},
+	}
+
+	tcs := []struct {
+		name     string
+		linter   string
+		input    map[string][]LinterOutput
+		expected map[string][]LinterOutput
+	}{
+		{
+			name:   "legacy finding moved is suppressed, new finding is reported",
+			linter: "fake",
+			input: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 6, Column: 2, Message: "do not panic in line 6"},
+					{File: "a.go", Line: 10, Column: 2, Message: "do not panic in line 10"},
+				},
+			},
+			expected: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 10, Column: 2, Message: "do not panic in line 10"},
+				},
+			},
+		},
+		{
+			name:   "findings of other linters are not suppressed",
+			linter: "other",
+			input: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 6, Column: 2, Message: "do not panic in line 6"},
+				},
+			},
+			expected: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 6, Column: 2, Message: "do not panic in line 6"},
+				},
+			},
+		},
+		{
+			name:   "finding with different rule is not suppressed",
+			linter: "fake",
+			input: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 6, Column: 2, Message: "do not panic in line 6", Rule: "R001"},
+				},
+			},
+			expected: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 6, Column: 2, Message: "do not panic in line 6", Rule: "R001"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(repoDir, "a.go"), []byte(oldSource), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			baselinePath := filepath.Join(repoDir, BaselineFile)
+			b := &Baseline{}
+			b.Update(repoDir, "fake", baselineResults)
+			if err := b.Save(baselinePath); err != nil {
+				t.Fatalf("failed to save baseline: %v", err)
+			}
+
+			if err := os.WriteFile(filepath.Join(repoDir, "a.go"), []byte(newSource), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadBaseline(baselinePath)
+			if err != nil {
+				t.Fatalf("failed to load baseline: %v", err)
+			}
+
+			a := Agent{
+				LinterConfig: config.Linter{Name: tc.linter},
+				RepoDir:      repoDir,
+				Baseline:     loaded,
+			}
+			got := filterByBaseline(xlog.New("test"), a, tc.input)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadBaselineNotExist(t *testing.T) {
+	b, err := LoadBaseline(filepath.Join(t.TempDir(), BaselineFile))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b != nil {
+		t.Errorf("expected nil baseline, got %v", b)
+	}
+}
+
+func TestBaselineUpdate(t *testing.T) {
+	b := &Baseline{
+		Findings: []BaselineFinding{
+			{Linter: "fake", File: "a.go", Message: "old", Fingerprint: "1"},
+			{Linter: "other", File: "a.go", Message: "kept", Fingerprint: "2"},
+		},
+	}
+	b.Update(t.TempDir(), "fake", map[string][]LinterOutput{
+		"b.go": {{File: "b.go", Line: 1, Message: "new"}},
+	})
+
+	if len(b.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %v", b.Findings)
+	}
+	if b.Findings[0].Message != "kept" || b.Findings[1].Message != "new" {
+		t.Errorf("unexpected findings: %v", b.Findings)
+	}
+}
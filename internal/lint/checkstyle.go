@@ -0,0 +1,141 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/qiniu/x/xlog"
+)
+
+// The Checkstyle XML report, which is also emitted by eslint, ktlint, hadolint, swiftlint and so on.
+// see https://checkstyle.org/
+type checkstyleReport struct {
+	Files []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// CheckstyleParse parses the Checkstyle XML output of linters.
+// Multiple reports are allowed, e.g. one for each file under $ARTIFACT.
+func CheckstyleParse(log *xlog.Logger, output []byte) (map[string][]LinterOutput, []string) {
+	results := make(map[string][]LinterOutput)
+	docs, unexpected := splitCheckstyleReports(string(output))
+	for _, doc := range docs {
+		var report checkstyleReport
+		if err := xml.Unmarshal([]byte(doc), &report); err != nil {
+			log.Warnf("failed to unmarshal checkstyle report: %v", err)
+			unexpected = append(unexpected, doc)
+			continue
+		}
+
+		for _, file := range report.Files {
+			for _, e := range file.Errors {
+				// ignore severity means the rule is disabled
+				if e.Severity == "ignore" {
+					continue
+				}
+				o := LinterOutput{
+					File:     file.Name,
+					Line:     e.Line,
+					Column:   e.Column,
+					Message:  strings.TrimSpace(e.Message),
+					Severity: ParseSeverity(e.Severity),
+					Rule:     checkstyleRule(e.Source),
+				}
+				if o.Line <= 0 {
+					// file level issue, e.g. missing license header
+					o.Line = 1
+				}
+				if o.Rule != "" && !strings.Contains(o.Message, o.Rule) {
+					o.Message = fmt.Sprintf("%s (%s)", o.Message, o.Rule)
+				}
+				results[o.File] = append(results[o.File], o)
+			}
+		}
+	}
+
+	return results, unexpected
+}
+
+// checkstyleRule returns the short rule name of the source,
+// e.g. com.puppycrawl.tools.checkstyle.checks.naming.MemberNameCheck => MemberNameCheck,
+// eslint.rules.no-unused-vars => no-unused-vars.
+func checkstyleRule(source string) string {
+	source = strings.TrimSpace(source)
+	if idx := strings.LastIndex(source, "."); idx != -1 {
+		return source[idx+1:]
+	}
+	return source
+}
+
+// splitCheckstyleReports finds all the <checkstyle> elements in the output,
+// the other non-empty lines except the xml declarations are returned as unexpected.
+func splitCheckstyleReports(output string) (docs []string, unexpected []string) {
+	const (
+		startTag = "<checkstyle"
+		endTag   = "</checkstyle>"
+	)
+
+	for len(output) > 0 {
+		start := strings.Index(output, startTag)
+		if start == -1 {
+			return docs, append(unexpected, checkstyleUnexpectedLines(output)...)
+		}
+		unexpected = append(unexpected, checkstyleUnexpectedLines(output[:start])...)
+		output = output[start:]
+
+		// empty report, e.g. <checkstyle version="4.3"/>
+		openEnd := strings.Index(output, ">")
+		if openEnd != -1 && output[openEnd-1] == '/' {
+			output = output[openEnd+1:]
+			continue
+		}
+
+		end := strings.Index(output, endTag)
+		if end == -1 {
+			return docs, append(unexpected, checkstyleUnexpectedLines(output)...)
+		}
+		docs = append(docs, output[:end+len(endTag)])
+		output = output[end+len(endTag):]
+	}
+	return docs, unexpected
+}
+
+func checkstyleUnexpectedLines(data string) []string {
+	var lines []string
+	for _, line := range unexpectedLines([]byte(data)) {
+		if strings.HasPrefix(line, "<?xml") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
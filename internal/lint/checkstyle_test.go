@@ -0,0 +1,101 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/xlog"
+)
+
+func TestCheckstyleParse(t *testing.T) {
+	tcs := []struct {
+		name       string
+		input      string
+		expected   map[string][]LinterOutput
+		unexpected []string
+	}{
+		{
+			name: "checkstyle report",
+			input: `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="10.17.0">
+<file name="src/Foo.java">
+<error line="10" column="5" severity="warning" message="Name &apos;Foo_bar&apos; must match pattern." source="com.puppycrawl.tools.checkstyle.checks.naming.MemberNameCheck"/>
+<error line="12" severity="error" message="Missing a Javadoc comment." source="com.puppycrawl.tools.checkstyle.checks.javadoc.MissingJavadocMethodCheck"/>
+<error line="13" severity="ignore" message="ignored" source="com.puppycrawl.tools.checkstyle.checks.whitespace.FileTabCharacterCheck"/>
+</file>
+<file name="src/Bar.java">
+</file>
+</checkstyle>`,
+			expected: map[string][]LinterOutput{
+				"src/Foo.java": {
+					{
+						File:     "src/Foo.java",
+						Line:     10,
+						Column:   5,
+						Message:  "Name 'Foo_bar' must match pattern. (MemberNameCheck)",
+						Severity: config.SeverityWarning,
+						Rule:     "MemberNameCheck",
+					},
+					{
+						File:     "src/Foo.java",
+						Line:     12,
+						Message:  "Missing a Javadoc comment. (MissingJavadocMethodCheck)",
+						Severity: config.SeverityError,
+						Rule:     "MissingJavadocMethodCheck",
+					},
+				},
+			},
+		},
+		{
+			name: "multiple reports from artifacts",
+			input: `---artifacts-xxx/eslint.xml---
+<?xml version="1.0" encoding="utf-8"?><checkstyle version="4.3"><file name="/src/web/app.js"><error line="3" column="7" severity="error" message="&apos;x&apos; is assigned a value but never used." source="eslint.rules.no-unused-vars" /></file></checkstyle>
+---artifacts-xxx/hadolint.xml---
+<?xml version='1.0' encoding='UTF-8'?><checkstyle version='4.3'/>
+unknown output
+`,
+			expected: map[string][]LinterOutput{
+				"/src/web/app.js": {
+					{
+						File:     "/src/web/app.js",
+						Line:     3,
+						Column:   7,
+						Message:  "'x' is assigned a value but never used. (no-unused-vars)",
+						Severity: config.SeverityError,
+						Rule:     "no-unused-vars",
+					},
+				},
+			},
+			unexpected: []string{"unknown output"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, unexpected := CheckstyleParse(xlog.New("ut"), []byte(tc.input))
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+			if !reflect.DeepEqual(unexpected, tc.unexpected) {
+				t.Errorf("expected unexpected %v, got %v", tc.unexpected, unexpected)
+			}
+		})
+	}
+}
@@ -17,40 +17,128 @@
 package lint
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/x/log"
 	"github.com/qiniu/x/xlog"
 )
 
+// FilterFunc filters the lint results of the agent, the file paths of results are relative to the repo root.
+type FilterFunc func(log *xlog.Logger, a Agent, results map[string][]LinterOutput) (map[string][]LinterOutput, error)
+
+// builtin filters.
+const (
+	// FilterPaths filters out the results out of the include/exclude scope of the linter.
+	FilterPaths = "paths"
+	// FilterPRChanged filters out the results not related to the changes of the PR.
+	FilterPRChanged = "pr-changed"
+	// FilterGenerated filters out the results on generated files.
+	FilterGenerated = "generated"
+	// FilterIgnoreDirectives filters out the results suppressed by reviewbot:ignore directives.
+	FilterIgnoreDirectives = "ignore-directives"
+	// FilterBaseline filters out the results recorded in the baseline of the repo.
+	FilterBaseline = "baseline"
+)
+
+var (
+	filters       = map[string]FilterFunc{}
+	linterFilters = map[string][]string{}
+)
+
+var ErrFilterNotFound = errors.New("filter not found")
+
+func init() {
+	RegisterFilter(FilterPaths, func(_ *xlog.Logger, a Agent, results map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+		return filterByPaths(a.LinterConfig, results), nil
+	})
+	RegisterFilter(FilterPRChanged, func(_ *xlog.Logger, a Agent, results map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+		if a.LinterConfig.Scope == config.ScopeRepo {
+			// all results in the repository are reported
+			return results, nil
+		}
+		return filterByPRChanged(a.Provider, DiffOptionsOf(a.LinterConfig), results), nil
+	})
+	RegisterFilter(FilterGenerated, func(_ *xlog.Logger, a Agent, results map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+		return filterByAutoGenerated(a, results)
+	})
+	RegisterFilter(FilterIgnoreDirectives, func(log *xlog.Logger, a Agent, results map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+		return filterByIgnoreDirectives(log, a, results), nil
+	})
+	RegisterFilter(FilterBaseline, func(log *xlog.Logger, a Agent, results map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+		return filterByBaseline(log, a, results), nil
+	})
+}
+
+// RegisterFilter registers a filter which can be referred by name in the linter config.
+func RegisterFilter(name string, fn FilterFunc) {
+	filters[name] = fn
+}
+
+// RegisterLinterFilters registers the filters applied by default on the results of the linter,
+// which run after the builtin paths, pr-changed and generated filters.
+func RegisterLinterFilters(linterName string, filterNames ...string) {
+	linterFilters[linterName] = append(linterFilters[linterName], filterNames...)
+}
+
+// ValidateFilters checks that all filters referred in every linter layer of the config are registered.
+func ValidateFilters(c config.Config) error {
+	return c.WalkLinters(func(l config.Linter) error {
+		var errs []error
+		for _, name := range l.Filters {
+			if _, ok := filters[name]; !ok {
+				errs = append(errs, fmt.Errorf("%w: %s", ErrFilterNotFound, name))
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// filterNames returns the names of filters applied on the results of the agent in order.
+func filterNames(a Agent) []string {
+	if len(a.LinterConfig.Filters) > 0 {
+		return a.LinterConfig.Filters
+	}
+	names := []string{FilterPaths, FilterPRChanged, FilterGenerated}
+	names = append(names, linterFilters[a.LinterConfig.Name]...)
+	return append(names, FilterIgnoreDirectives, FilterBaseline)
+}
+
 // Filters filters the lint errors.
+// The file paths are always cleaned first, then the filters configured for the linter are applied in order.
 func Filters(log *xlog.Logger, a Agent, linterResults map[string][]LinterOutput) (map[string][]LinterOutput, error) {
-	linterResults = cleanLintResults(a.RepoDir, linterResults)
-	results := filterByPRChanged(a.Provider, linterResults)
-	results, err := filterByAutoGenerated(a, results)
-	if err != nil {
-		return nil, err
+	results := cleanLintResults(a.RepoDir, linterResults)
+	for _, name := range filterNames(a) {
+		fn, ok := filters[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrFilterNotFound, name)
+		}
+		var err error
+		results, err = fn(log, a, results)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", name, err)
+		}
 	}
-
-	results = filterBySA5008(results)
 	return results, nil
 }
 
 // LinterRelated checks if the linter is related to the PR.
-// Each linter has a list of languages that it supports and the file extensions are used to determine
-// whether the linter is related to the PR.
+// Each linter has a list of languages that it supports, which are matched against the extensions, names, paths
+// and shebang lines of the changed files to determine whether the linter is related to the PR.
+// The files out of the include/exclude scope of the linter are not considered.
 func LinterRelated(linterName string, a Agent) bool {
-	exts := make(map[string]bool)
-	for _, file := range a.Provider.GetFiles(nil) {
-		ext := filepath.Ext(file)
-		if ext == "" {
-			continue
-		}
-		exts[ext] = true
-	}
-	return languageRelated(linterName, exts)
+	return languageRelated(linterName, Languages(linterName), a.RepoDir, a.Provider.GetFiles(a.LinterConfig.PathMatched))
+}
+
+// LanguagesRelated is like LinterRelated, but checks the given languages rather than the registered ones,
+// e.g. the languages of the custom linter in the config snapshot of the PR.
+func LanguagesRelated(languages []string, a Agent) bool {
+	return languageRelated(a.LinterConfig.Name, languages, a.RepoDir, a.Provider.GetFiles(a.LinterConfig.PathMatched))
 }
 
 // cleanLintResults cleans the file path in lint results.
@@ -64,12 +152,28 @@ func cleanLintResults(workdir string, lintResults map[string][]LinterOutput) map
 	return cleanedResults
 }
 
+// filterByPaths filters out the lint errors of files out of the include/exclude scope of the linter.
+func filterByPaths(linterConfig config.Linter, lintResults map[string][]LinterOutput) map[string][]LinterOutput {
+	if len(linterConfig.Include) == 0 && len(linterConfig.Exclude) == 0 {
+		return lintResults
+	}
+	results := make(map[string][]LinterOutput, len(lintResults))
+	for file, outputs := range lintResults {
+		if !linterConfig.PathMatched(file) {
+			log.Debugf("ignore %s since it's out of the scope of %s", file, linterConfig.Name)
+			continue
+		}
+		results[file] = outputs
+	}
+	return results
+}
+
 // filterByPRChanged filters out the lint errors that are not related to the PR.
-func filterByPRChanged(provider Provider, outputs map[string][]LinterOutput) map[string][]LinterOutput {
+func filterByPRChanged(provider Provider, opts DiffOptions, outputs map[string][]LinterOutput) map[string][]LinterOutput {
 	result := make(map[string][]LinterOutput)
 	for file, lintFileErrs := range outputs {
 		for _, lintErr := range lintFileErrs {
-			if provider.IsRelated(file, lintErr.Line, lintErr.StartLine) {
+			if provider.IsRelated(file, lintErr.Line, lintErr.StartLine, opts) {
 				result[file] = append(result[file], lintErr)
 			}
 		}
@@ -79,17 +183,21 @@ func filterByPRChanged(provider Provider, outputs map[string][]LinterOutput) map
 
 // filterByAutoGenerated filters out the auto-generated files.
 func filterByAutoGenerated(a Agent, linterResults map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+	detector := a.GeneratedFileDetector
+	if detector == nil {
+		detector = NewGeneratedFileDetector(a.RepoDir, config.GeneratedFiles{})
+	}
+
 	var filesToIgnore []string
 	for file := range linterResults {
-		absPath := filepath.Join(a.LinterConfig.WorkDir, file)
-		if isGenerated, err := isGeneratedFile(absPath); err != nil {
-			log.Errorf("failed to check if file is generated: %v", err)
-			continue
-		} else {
-			if isGenerated {
-				log.Infof("ignore generated file: %s", file)
-				filesToIgnore = append(filesToIgnore, file)
-			}
+		head, err := readFileHead(filepath.Join(a.RepoDir, file))
+		if err != nil {
+			// still check it since it may be generated by path
+			log.Debugf("failed to read the head of file %s: %v", file, err)
+		}
+		if detector.IsGenerated(file, head) {
+			log.Infof("ignore generated file: %s", file)
+			filesToIgnore = append(filesToIgnore, file)
 		}
 	}
 
@@ -100,75 +208,272 @@ func filterByAutoGenerated(a Agent, linterResults map[string][]LinterOutput) (ma
 	return linterResults, nil
 }
 
-// special handling for staticcheck SA5008 (unknown JSON option error)
-// Background:
-//   - https://github.com/qiniu/reviewbot/issues/24
-var tagRex = regexp.MustCompile(`unknown JSON option "(.*)" \(SA5008\)`)
+// ignoreDirective is the reviewbot-level directive to suppress lint errors for any linter,
+// which is written in a comment on the reported line or the line above, like:
+//
+//	// reviewbot:ignore <linter|all>[ <rule>][ --] <reason>
+//	# reviewbot:ignore shellcheck SC2086 the word splitting is expected here
+//	// reviewbot:ignore all -- the panic is expected here
+const ignoreDirective = "reviewbot:ignore"
+
+// ignoreReasonSeparator separates the reason from the linter of the ignore directive without rule.
+const ignoreReasonSeparator = "--"
+
+// commentPrefixes is the comment syntax of languages by file extension or file name.
+var commentPrefixes = map[string][]string{
+	".go": {"//", "/*"}, ".mod": {"//"}, ".c": {"//", "/*"}, ".h": {"//", "/*"}, ".cc": {"//", "/*"}, ".cpp": {"//", "/*"},
+	".hpp": {"//", "/*"}, ".java": {"//", "/*"}, ".kt": {"//", "/*"}, ".scala": {"//", "/*"}, ".js": {"//", "/*"},
+	".jsx": {"//", "/*"}, ".ts": {"//", "/*"}, ".tsx": {"//", "/*"}, ".rs": {"//", "/*"}, ".swift": {"//", "/*"},
+	".cs": {"//", "/*"}, ".proto": {"//", "/*"}, ".dart": {"//", "/*"}, ".php": {"//", "#", "/*"},
+	".sh": {"#"}, ".bash": {"#"}, ".zsh": {"#"}, ".py": {"#"}, ".rb": {"#"}, ".pl": {"#"}, ".r": {"#"},
+	".yaml": {"#"}, ".yml": {"#"}, ".toml": {"#"}, ".mk": {"#"}, ".cmake": {"#"}, ".conf": {"#"},
+	".lua": {"--"}, ".sql": {"--"}, ".hs": {"--"},
+	".md": {"<!--"}, ".html": {"<!--"}, ".xml": {"<!--"}, ".vue": {"<!--", "//"},
+	".css": {"/*"}, ".scss": {"/*", "//"}, ".less": {"/*", "//"}, ".ini": {";", "#"},
+	"Dockerfile": {"#"}, "Makefile": {"#"}, "CMakeLists.txt": {"#"},
+}
+
+// fallbackCommentPrefixes is used for files whose language is unknown.
+var fallbackCommentPrefixes = []string{"//", "#", "--", "/*", "<!--", ";"}
 
-func filterBySA5008(results map[string][]LinterOutput) map[string][]LinterOutput {
-	finalResults := make(map[string][]LinterOutput)
+func commentPrefixesOf(file string) []string {
+	if prefixes, ok := commentPrefixes[filepath.Base(file)]; ok {
+		return prefixes
+	}
+	if prefixes, ok := commentPrefixes[strings.ToLower(filepath.Ext(file))]; ok {
+		return prefixes
+	}
+	return fallbackCommentPrefixes
+}
 
-	for file, linterResults := range results {
-		var lintersCopy []LinterOutput
-		for _, linter := range linterResults {
-			matches := tagRex.FindStringSubmatch(linter.Message)
-			if len(matches) == 2 && isGoZeroCustomTag(matches[1]) {
-				log.Warnf("ignore this error: %v", linter.Message)
+// parseIgnoreDirective finds the ignore directive in the comment of the line.
+// It returns the fields after the directive, ok is false if there is no directive.
+func parseIgnoreDirective(line string, prefixes []string) (fields []string, ok bool) {
+	for _, prefix := range prefixes {
+		rest := line
+		for {
+			idx := strings.Index(rest, prefix)
+			if idx < 0 {
+				break
+			}
+			rest = rest[idx+len(prefix):]
+			comment := strings.TrimSpace(rest)
+			if !strings.HasPrefix(comment, ignoreDirective) {
 				continue
 			}
-			lintersCopy = append(lintersCopy, linter)
+			comment = strings.TrimPrefix(comment, ignoreDirective)
+			// directive like reviewbot:ignored is not ours
+			if comment != "" && comment[0] != ' ' && comment[0] != '\t' {
+				continue
+			}
+			comment = strings.TrimSpace(comment)
+			comment = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(comment, "-->"), "*/"))
+			return strings.Fields(comment), true
 		}
-		if len(lintersCopy) > 0 {
-			finalResults[file] = lintersCopy
+	}
+	return nil, false
+}
+
+// matchIgnoreDirective checks whether the directive applies to the lint error of the linter.
+// The field after the linter is the rule, the directive for another rule doesn't apply to the lint error.
+// The reason is written after the rule, or after the separator if without rule. The reason is returned if matched.
+func matchIgnoreDirective(fields []string, linterName string, o LinterOutput) (reason string, matched bool) {
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	var linterMatched bool
+	for _, name := range strings.Split(fields[0], ",") {
+		if name == "all" || name == linterName {
+			linterMatched = true
+			break
 		}
 	}
+	if !linterMatched {
+		return "", false
+	}
 
-	return finalResults
+	rest := fields[1:]
+	if len(rest) > 0 && rest[0] != ignoreReasonSeparator {
+		if !isRuleOf(rest[0], o) {
+			// the directive is for another rule
+			return "", false
+		}
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && rest[0] == ignoreReasonSeparator {
+		rest = rest[1:]
+	}
+	return strings.Join(rest, " "), true
 }
 
-// isGoZeroCustomTag checks if the tag is a go-zero custom tag.
-// refer: https://go-zero.dev/en/docs/tutorials/go-zero/configuration/overview#tag-checksum-rule
-const (
-	goZeroDefaultOption  = "default"
-	goZeroEnvOption      = "env"
-	goZeroInheritOption  = "inherit"
-	goZeroOptionalOption = "optional"
-	goZeroOptionsOption  = "options"
-	goZeroRangeOption    = "range"
-)
+// isRuleOf checks whether the rule is the rule of the lint error.
+// Besides the Rule field, the trailing tag of the message like (errcheck) or [SC2086] is also considered.
+func isRuleOf(rule string, o LinterOutput) bool {
+	if o.Rule != "" && strings.EqualFold(rule, o.Rule) {
+		return true
+	}
+	msg := strings.TrimSpace(o.Message)
+	return strings.HasSuffix(msg, "("+rule+")") || strings.HasSuffix(msg, "["+rule+"]")
+}
+
+// filterByIgnoreDirectives filters out the lint errors suppressed by the reviewbot:ignore directives.
+// The directive without reason still works, but a new lint error is reported on it to ask for the reason.
+func filterByIgnoreDirectives(log *xlog.Logger, a Agent, lintResults map[string][]LinterOutput) map[string][]LinterOutput {
+	if len(lintResults) == 0 {
+		return lintResults
+	}
 
-// FIXME(CarlJi): this function is a temporary solution for go-zero custom tag, see [#24](https://github.com/qiniu/reviewbot/issues/24)
-// expect to remove this function after staticcheck supports go-zero custom tag.
-func isGoZeroCustomTag(jsonOption string) bool {
-	var found bool
-	switch {
-	case strings.HasPrefix(jsonOption, goZeroDefaultOption):
-		found = true
-	case strings.HasPrefix(jsonOption, goZeroEnvOption):
-		found = true
-	case strings.HasPrefix(jsonOption, goZeroInheritOption):
-		found = true
-	case strings.HasPrefix(jsonOption, goZeroOptionalOption):
-		found = true
-	case strings.HasPrefix(jsonOption, goZeroOptionsOption):
-		found = true
-	case strings.HasPrefix(jsonOption, goZeroRangeOption):
-		found = true
+	linterName := a.LinterConfig.Name
+	sources := newSourceFiles(a.RepoDir)
+	results := make(map[string][]LinterOutput, len(lintResults))
+	var missingReasons []LinterOutput
+	reported := make(map[int]bool)
+	for file, outputs := range lintResults {
+		prefixes := commentPrefixesOf(file)
+		clear(reported)
+		for _, o := range outputs {
+			directiveLine, reason, suppressed := findIgnoreDirective(sources, file, prefixes, linterName, o)
+			if !suppressed {
+				results[file] = append(results[file], o)
+				continue
+			}
+
+			log.Infof("[%s] ignore %s:%d by directive at line %d: %s", linterName, file, o.Line, directiveLine, o.Message)
+			if reason != "" || reported[directiveLine] {
+				continue
+			}
+			reported[directiveLine] = true
+			if a.Provider != nil && a.LinterConfig.Scope != config.ScopeRepo && !a.Provider.IsRelated(file, directiveLine, 0, DiffOptionsOf(a.LinterConfig)) {
+				continue
+			}
+			missingReasons = append(missingReasons, LinterOutput{
+				File:     file,
+				Line:     directiveLine,
+				Message:  fmt.Sprintf("%s directive should have a reason, e.g. `%s %s -- false positive since ...`", ignoreDirective, ignoreDirective, linterName),
+				Severity: config.SeverityWarning,
+				Rule:     "reviewbot-ignore-reason",
+			})
+		}
 	}
 
-	if found {
-		log.Warnf("this tag %v seems belongs to go-zero, ignore it temporary, see [#24](https://github.com/qiniu/reviewbot/issues/24) for more information", jsonOption)
+	for _, o := range missingReasons {
+		results[o.File] = append(results[o.File], o)
 	}
+	return results
+}
 
-	return found
+// findIgnoreDirective finds the directive on the reported line or the line above which suppresses the lint error.
+func findIgnoreDirective(sources *sourceFiles, file string, prefixes []string, linterName string, o LinterOutput) (directiveLine int, reason string, found bool) {
+	candidates := []int{o.Line, o.Line - 1}
+	if o.StartLine > 0 && o.StartLine != o.Line {
+		candidates = append(candidates, o.StartLine, o.StartLine-1)
+	}
+	for _, line := range candidates {
+		fields, ok := parseIgnoreDirective(sources.line(file, line), prefixes)
+		if !ok {
+			continue
+		}
+		if reason, matched := matchIgnoreDirective(fields, linterName, o); matched {
+			return line, reason, true
+		}
+	}
+	return 0, "", false
 }
 
-func languageRelated(linterName string, exts map[string]bool) bool {
-	langs := Languages(linterName)
-	for _, language := range langs {
-		if language == "*" || exts[language] {
+func languageRelated(linterName string, languages []string, repoDir string, files []string) bool {
+	m := newLanguageMatcher(linterName, languages)
+	if m.any {
+		return true
+	}
+	for _, file := range files {
+		if m.match(repoDir, file) {
 			return true
 		}
 	}
 	return false
 }
+
+// LanguageFiles returns the changed files matching the languages of the linter, e.g. the shell scripts for shellcheck.
+// The files out of the include/exclude scope of the linter are not considered.
+func LanguageFiles(linterName string, a Agent) []string {
+	m := newLanguageMatcher(linterName, Languages(linterName))
+	var files []string
+	for _, file := range a.Provider.GetFiles(a.LinterConfig.PathMatched) {
+		if m.any || m.match(a.RepoDir, file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// languageMatcher matches the files by the languages of a linter.
+type languageMatcher struct {
+	any      bool
+	paths    []config.Language
+	shebangs []config.Language
+}
+
+func newLanguageMatcher(linterName string, languages []string) languageMatcher {
+	var m languageMatcher
+	for _, lang := range languages {
+		language, err := config.ParseLanguage(lang)
+		if err != nil {
+			log.Warnf("ignore the language of %s: %v", linterName, err)
+			continue
+		}
+		switch language.Kind {
+		case config.LanguageAny:
+			m.any = true
+		case config.LanguageShebang:
+			m.shebangs = append(m.shebangs, language)
+		default:
+			m.paths = append(m.paths, language)
+		}
+	}
+	return m
+}
+
+// match reports whether the file matches any of the languages.
+// The shebang line is only checked when the file is not matched by its path, and the repo is checked out.
+func (m languageMatcher) match(repoDir string, file string) bool {
+	for _, language := range m.paths {
+		if language.MatchFile(file) {
+			return true
+		}
+	}
+	if len(m.shebangs) == 0 || repoDir == "" {
+		return false
+	}
+	line := readShebang(filepath.Join(repoDir, file))
+	if line == "" {
+		return false
+	}
+	for _, language := range m.shebangs {
+		if language.MatchInterpreter(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxShebangLength is the max length of the shebang line to read.
+const maxShebangLength = 256
+
+// readShebang returns the shebang line of the file, or "" if the file does not start with a shebang.
+func readShebang(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	buf := make([]byte, maxShebangLength)
+	n, _ := io.ReadFull(f, buf)
+	line, _, _ := strings.Cut(string(buf[:n]), "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasPrefix(line, config.ShebangLanguagePrefix) {
+		return ""
+	}
+	return line
+}
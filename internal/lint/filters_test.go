@@ -19,9 +19,16 @@ package lint
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/go-github/v57/github"
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/xlog"
 )
 
 func TestFilters(t *testing.T) {
@@ -64,30 +71,6 @@ func TestFilters(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
-		{
-			name: "filterByAutoGenerated",
-			a: []*github.CommitFile{
-				{
-					Filename: github.String(".testdata/auto.go"),
-					Patch:    github.String(`@@ -1,7 +1,7 @@`),
-				},
-				{
-					Filename: github.String(".testdata/xxx_2.go"),
-					Patch:    github.String(`@@ -1,7 +1,7 @@`),
-				},
-			},
-			input: map[string][]LinterOutput{
-				".testdata/auto.go": {
-					{
-						Line:    4,
-						Column:  16,
-						Message: `unknown JSON option "default=abc" (SA5008)`,
-					},
-				},
-			},
-			expected:    map[string][]LinterOutput{},
-			expectedErr: nil,
-		},
 		{
 			name: "normalCase",
 			a: []*github.CommitFile{
@@ -136,45 +119,6 @@ func TestFilters(t *testing.T) {
 			expected:    map[string][]LinterOutput{},
 			expectedErr: nil,
 		},
-		{
-			name: "filterSA5008",
-			a: []*github.CommitFile{
-				{
-					Filename: github.String(".testdata/xxx_1.go"),
-					Patch:    github.String(`@@ -1,7 +1,8 @@`),
-				},
-				{
-					Filename: github.String(".testdata/xxx_2.go"),
-					Patch:    github.String(`@@ -1,7 +1,8 @@`),
-				},
-			},
-			input: map[string][]LinterOutput{
-				".testdata/xxx_1.go": {
-					{
-						Line:    4,
-						Column:  16,
-						Message: `normal error`,
-					},
-				},
-				".testdata/xxx_2.go": {
-					{
-						Line:    4,
-						Column:  16,
-						Message: `unknown JSON option "default=abc" (SA5008)`,
-					},
-				},
-			},
-			expected: map[string][]LinterOutput{
-				".testdata/xxx_1.go": {
-					{
-						Line:    4,
-						Column:  16,
-						Message: `normal error`,
-					},
-				},
-			},
-			expectedErr: nil,
-		},
 	}
 
 	for _, tc := range tcs {
@@ -212,12 +156,291 @@ func TestFilters(t *testing.T) {
 	}
 }
 
+func TestFiltersPipeline(t *testing.T) {
+	var called []string
+	record := func(name string) FilterFunc {
+		return func(_ *xlog.Logger, _ Agent, results map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+			called = append(called, name)
+			return results, nil
+		}
+	}
+	RegisterFilter("test-first", record("test-first"))
+	RegisterFilter("test-second", record("test-second"))
+	RegisterFilter("test-drop-all", func(_ *xlog.Logger, _ Agent, _ map[string][]LinterOutput) (map[string][]LinterOutput, error) {
+		called = append(called, "test-drop-all")
+		return map[string][]LinterOutput{}, nil
+	})
+	RegisterLinterFilters("test-linter", "test-second")
+
+	p, err := NewGithubProvider(context.TODO(), nil, github.PullRequestEvent{}, WithPullRequestChangedFiles([]*github.CommitFile{
+		{
+			Filename: github.String("a.go"),
+			Patch:    github.String(`@@ -1,7 +1,7 @@`),
+		},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create github provider: %v", err)
+	}
+
+	tcs := []struct {
+		name          string
+		linterName    string
+		filters       []string
+		expectedCalls []string
+		expectedLen   int
+		expectedErr   error
+	}{
+		{
+			name:          "default filters with linter filters",
+			linterName:    "test-linter",
+			expectedCalls: []string{"test-second"},
+			expectedLen:   1,
+		},
+		{
+			name:          "configured filters in order",
+			linterName:    "test-linter",
+			filters:       []string{"test-second", FilterPRChanged, "test-first"},
+			expectedCalls: []string{"test-second", "test-first"},
+			expectedLen:   1,
+		},
+		{
+			name:          "configured filters drop all",
+			filters:       []string{"test-drop-all", "test-first"},
+			expectedCalls: []string{"test-drop-all", "test-first"},
+			expectedLen:   0,
+		},
+		{
+			name:          "unknown filter",
+			filters:       []string{"test-first", "not-exist"},
+			expectedCalls: []string{"test-first"},
+			expectedErr:   ErrFilterNotFound,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			called = nil
+			a := Agent{
+				Provider:     p,
+				RepoDir:      "/repo",
+				LinterConfig: config.Linter{Name: tc.linterName, Filters: tc.filters},
+			}
+			input := map[string][]LinterOutput{
+				"/repo/a.go": {{File: "/repo/a.go", Line: 2, Message: "err not used"}},
+			}
+			results, err := Filters(nil, a, input)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(called, tc.expectedCalls) {
+				t.Errorf("expected calls %v, got %v", tc.expectedCalls, called)
+			}
+			if err == nil && len(results["a.go"]) != tc.expectedLen {
+				t.Errorf("expected %d results of a.go, got %v", tc.expectedLen, results)
+			}
+		})
+	}
+}
+
+func TestFilterByIgnoreDirectives(t *testing.T) {
+	sources := map[string]string{
+		"a.go": `package a
+
+func f() {
+	// reviewbot:ignore fake -- the panic is expected here
+	panic(1)
+	panic(2) // reviewbot:ignore all
+	// reviewbot:ignore fake R002 only R002 is ignored
+	panic(3)
+	// reviewbot:ignore other -- not for fake
+	panic(4)
+	/* reviewbot:ignore fake,other errcheck block comment */
+	panic(5)
+	// reviewbot:ignore fake unusedresult the result is not needed
+	panic(6)
+	// reviewbot:ignore fake UnusedLocalVariable
+	panic(7)
+}
+`,
+		"a.sh": `#!/bin/sh
+echo $1 # reviewbot:ignore fake SC2086 the word splitting is expected
+
+echo $2 // reviewbot:ignore fake -- not a shell comment
+`,
+		"a.lua": `-- reviewbot:ignore fake -- legacy global
+x = 1
+`,
+	}
+
+	tcs := []struct {
+		name     string
+		input    map[string][]LinterOutput
+		expected map[string][]LinterOutput
+	}{
+		{
+			name: "directive on the line above with reason",
+			input: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 5, Message: "do not panic"}},
+			},
+			expected: map[string][]LinterOutput{},
+		},
+		{
+			name: "directive on the same line without reason",
+			input: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 6, Message: "do not panic"}},
+			},
+			expected: map[string][]LinterOutput{
+				"a.go": {
+					{
+						File:     "a.go",
+						Line:     6,
+						Message:  "reviewbot:ignore directive should have a reason, e.g. `reviewbot:ignore fake -- false positive since ...`",
+						Severity: config.SeverityWarning,
+						Rule:     "reviewbot-ignore-reason",
+					},
+				},
+			},
+		},
+		{
+			name: "directive for specific rule",
+			input: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 8, Message: "do not panic", Rule: "R001"},
+					{File: "a.go", Line: 8, Message: "do not panic again", Rule: "R002"},
+				},
+			},
+			expected: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 8, Message: "do not panic", Rule: "R001"}},
+			},
+		},
+		{
+			name: "directive for other linter",
+			input: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 10, Message: "do not panic"}},
+			},
+			expected: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 10, Message: "do not panic"}},
+			},
+		},
+		{
+			name: "block comment with rule in message tag",
+			input: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 12, Message: "error is not checked (errcheck)"}},
+			},
+			expected: map[string][]LinterOutput{},
+		},
+		{
+			name: "directive for other named rules",
+			input: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 14, Message: "printf: non-constant format string (govet)"},
+					{File: "a.go", Line: 16, Message: "unused variable", Rule: "S1000"},
+				},
+			},
+			expected: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 14, Message: "printf: non-constant format string (govet)"},
+					{File: "a.go", Line: 16, Message: "unused variable", Rule: "S1000"},
+				},
+			},
+		},
+		{
+			name: "comment syntax of shell",
+			input: map[string][]LinterOutput{
+				"a.sh": {
+					{File: "a.sh", Line: 2, Message: "warning: Double quote to prevent globbing and word splitting. [SC2086]"},
+					{File: "a.sh", Line: 4, Message: "warning: Double quote to prevent globbing and word splitting. [SC2086]"},
+				},
+			},
+			expected: map[string][]LinterOutput{
+				"a.sh": {{File: "a.sh", Line: 4, Message: "warning: Double quote to prevent globbing and word splitting. [SC2086]"}},
+			},
+		},
+		{
+			name: "comment syntax of lua",
+			input: map[string][]LinterOutput{
+				"a.lua": {{File: "a.lua", Line: 2, Message: "setting non-standard global variable x"}},
+			},
+			expected: map[string][]LinterOutput{},
+		},
+	}
+
+	repoDir := t.TempDir()
+	for file, content := range sources {
+		if err := os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Agent{
+				LinterConfig: config.Linter{Name: "fake"},
+				RepoDir:      repoDir,
+			}
+			got := filterByIgnoreDirectives(xlog.New("test"), a, tc.input)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected: %v, got: %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilterByPaths(t *testing.T) {
+	input := map[string][]LinterOutput{
+		"main.go":                  {{File: "main.go", Line: 1, Message: "a"}},
+		"pkg/a/a.go":               {{File: "pkg/a/a.go", Line: 1, Message: "b"}},
+		"pkg/a/testdata/t.go":      {{File: "pkg/a/testdata/t.go", Line: 1, Message: "c"}},
+		"vendor/github.com/x/y.go": {{File: "vendor/github.com/x/y.go", Line: 1, Message: "d"}},
+	}
+
+	tcs := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "no patterns",
+			expected: []string{"main.go", "pkg/a/a.go", "pkg/a/testdata/t.go", "vendor/github.com/x/y.go"},
+		},
+		{
+			name:     "exclude only",
+			exclude:  []string{"vendor/**", "**/testdata/**"},
+			expected: []string{"main.go", "pkg/a/a.go"},
+		},
+		{
+			name:     "include and exclude",
+			include:  []string{"pkg/**/*.go"},
+			exclude:  []string{"**/testdata/**"},
+			expected: []string{"pkg/a/a.go"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := filterByPaths(config.Linter{Include: tc.include, Exclude: tc.exclude}, input)
+			var files []string
+			for file := range got {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, files)
+			}
+		})
+	}
+}
+
 func TestLinterRelated(t *testing.T) {
 	tcs := []struct {
 		name     string
 		linter   string
 		a        []*github.CommitFile
 		langs    []string
+		include  []string
+		exclude  []string
+		contents map[string]string
 		expected bool
 	}{
 		{
@@ -267,6 +490,149 @@ func TestLinterRelated(t *testing.T) {
 			langs:    []string{".c", ".go"},
 			expected: true,
 		},
+		{
+			name:   "not related since excluded",
+			linter: "golangci-lint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("vendor/github.com/x/y.go"),
+				},
+				{
+					Filename: github.String("pkg/testdata/z.go"),
+				},
+			},
+			langs:    []string{".go"},
+			exclude:  []string{"vendor/**", "**/testdata/**"},
+			expected: false,
+		},
+		{
+			name:   "related since included",
+			linter: "golangci-lint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("cmd/main.go"),
+				},
+				{
+					Filename: github.String("pkg/a/a.go"),
+				},
+			},
+			langs:    []string{".go"},
+			include:  []string{"pkg/**"},
+			expected: true,
+		},
+		{
+			name:   "not related since not included",
+			linter: "golangci-lint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("cmd/main.go"),
+				},
+			},
+			langs:    []string{".go"},
+			include:  []string{"pkg/**"},
+			expected: false,
+		},
+		{
+			name:   "related by file name",
+			linter: "hadolint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("build/Dockerfile"),
+				},
+			},
+			langs:    []string{"Dockerfile"},
+			expected: true,
+		},
+		{
+			name:   "not related by file name",
+			linter: "hadolint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("build/Dockerfile.md"),
+				},
+			},
+			langs:    []string{"Dockerfile"},
+			expected: false,
+		},
+		{
+			name:   "related by glob",
+			linter: "hadolint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("build/Dockerfile.release"),
+				},
+			},
+			langs:    []string{"Dockerfile", "Dockerfile.*"},
+			expected: true,
+		},
+		{
+			name:   "related by path glob",
+			linter: "jenkinsfile-lint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("ci/deploy/Jenkinsfile"),
+				},
+			},
+			langs:    []string{"ci/**/Jenkinsfile"},
+			expected: true,
+		},
+		{
+			name:   "not related by path glob",
+			linter: "jenkinsfile-lint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("docs/Jenkinsfile"),
+				},
+			},
+			langs:    []string{"ci/**/Jenkinsfile"},
+			expected: false,
+		},
+		{
+			name:   "related by shebang",
+			linter: "shellcheck",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("README"),
+				},
+				{
+					Filename: github.String("hack/build"),
+				},
+			},
+			langs: []string{".sh", "#!bash"},
+			contents: map[string]string{
+				"README":     "# title\n",
+				"hack/build": "#!/usr/bin/env bash\nset -e\n",
+			},
+			expected: true,
+		},
+		{
+			name:   "related by shebang with version",
+			linter: "pylint",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("tools/gen"),
+				},
+			},
+			langs: []string{"#!python"},
+			contents: map[string]string{
+				"tools/gen": "#!/usr/bin/python3\nprint(1)\n",
+			},
+			expected: true,
+		},
+		{
+			name:   "not related by shebang",
+			linter: "shellcheck",
+			a: []*github.CommitFile{
+				{
+					Filename: github.String("tools/gen"),
+				},
+			},
+			langs: []string{"#!sh", "#!bash"},
+			contents: map[string]string{
+				"tools/gen": "#!/usr/bin/env python3\nprint(1)\n",
+			},
+			expected: false,
+		},
 	}
 
 	for _, tc := range tcs {
@@ -276,8 +642,19 @@ func TestLinterRelated(t *testing.T) {
 			if err != nil {
 				t.Errorf("failed to create github provider: %v", err)
 			}
+			repoDir := t.TempDir()
+			for file, content := range tc.contents {
+				if err := os.MkdirAll(filepath.Join(repoDir, filepath.Dir(file)), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(repoDir, file), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
 			actual := LinterRelated(tc.linter, Agent{
-				Provider: p,
+				RepoDir:      repoDir,
+				Provider:     p,
+				LinterConfig: config.Linter{Include: tc.include, Exclude: tc.exclude},
 			})
 			if actual != tc.expected {
 				t.Errorf("expected %v, got %v, linter: %v, PR: %v", tc.expected, actual, tc.linter, tc.a)
@@ -285,3 +662,42 @@ func TestLinterRelated(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateFilters(t *testing.T) {
+	c := config.Config{
+		CustomLinters: map[string]config.CustomLinter{
+			"semgrep": {Linter: config.Linter{Filters: []string{FilterPaths, "pr-chnaged"}}},
+		},
+		Profiles: map[string]config.Profile{
+			"go": {Linters: map[string]config.Linter{"golangci-lint": {Filters: []string{"baselin"}}}},
+		},
+		CustomRepos: map[string]config.RepoConfig{
+			"qiniu/kodo": {
+				Linters: map[string]config.Linter{"golangci-lint": {Filters: []string{FilterBaseline}}},
+				Branches: map[string]config.BranchConfig{
+					"release/**": {Linters: map[string]config.Linter{"golangci-lint": {Filters: []string{"generatd", "ignore"}}}},
+				},
+			},
+		},
+	}
+
+	err := ValidateFilters(c)
+	if !errors.Is(err, ErrFilterNotFound) {
+		t.Fatalf("expected %v, got %v", ErrFilterNotFound, err)
+	}
+	want := strings.Join([]string{
+		"customLinters[semgrep]: filter not found: pr-chnaged",
+		"profiles[go].linters[golangci-lint]: filter not found: baselin",
+		"customRepos[qiniu/kodo].branches[release/**].linters[golangci-lint]: filter not found: generatd\nfilter not found: ignore",
+	}, "\n")
+	if err.Error() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, err)
+	}
+
+	c.CustomRepos["qiniu/kodo"].Branches["release/**"].Linters["golangci-lint"] = config.Linter{Filters: []string{FilterGenerated}}
+	delete(c.Profiles, "go")
+	delete(c.CustomLinters, "semgrep")
+	if err := ValidateFilters(c); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
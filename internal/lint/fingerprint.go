@@ -0,0 +1,89 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// fingerprintContextLines is the number of lines above and below the reported line used to compute the fingerprint.
+	fingerprintContextLines = 1
+	// retiredMarker marks the comment whose issue is no longer reported, which is kept to preserve the discussion.
+	retiredMarker = "<!--reviewbot:retired-->"
+	// retiredNote is appended to the retired comment to inform the users.
+	retiredNote = "\n\n> ✅ This issue is not reported in the latest commit anymore."
+)
+
+// fingerprintMarkerRex matches the hidden fingerprint marker in the comment body.
+var fingerprintMarkerRex = regexp.MustCompile(`<!--reviewbot:fp:([0-9a-f]+)-->`)
+
+// fingerprintMarker returns the hidden marker embedded in the comment to identify the issue across pushes.
+func fingerprintMarker(fingerprint string) string {
+	if fingerprint == "" {
+		return ""
+	}
+	return "\n<!--reviewbot:fp:" + fingerprint + "-->"
+}
+
+// parseFingerprintMarker returns the fingerprint in the comment body, empty if not found.
+func parseFingerprintMarker(body string) string {
+	matches := fingerprintMarkerRex.FindStringSubmatch(body)
+	if len(matches) != 2 {
+		return ""
+	}
+	return matches[1]
+}
+
+// isRetired reports whether the comment is retired.
+func isRetired(body string) bool {
+	return strings.Contains(body, retiredMarker)
+}
+
+// retiredBody returns the body of the comment after retired.
+func retiredBody(body string) string {
+	return body + retiredNote + "\n" + retiredMarker
+}
+
+// lineDistance returns the distance between the lines, to pick the nearest comment of the same fingerprint.
+func lineDistance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// setFingerprints sets the fingerprint of each output, which is content based so that it's stable
+// when the lines are shifted by the changes elsewhere.
+// It's computed from the linter, rule, normalized message, file and the surrounding source lines.
+func setFingerprints(a Agent, lintResults map[string][]LinterOutput) {
+	src := newSourceFiles(a.RepoDir)
+	for file, outputs := range lintResults {
+		for i := range outputs {
+			o := &outputs[i]
+			if o.File == "" {
+				o.File = file
+			}
+			var lines []string
+			for l := o.Line - fingerprintContextLines; l <= o.Line+fingerprintContextLines; l++ {
+				lines = append(lines, strings.TrimSpace(src.line(file, l)))
+			}
+			o.Fingerprint = Fingerprint(a.LinterConfig.Name, *o, strings.Join(lines, "\n"))
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/qiniu/reviewbot/config"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func TestSetFingerprints(t *testing.T) {
+	before := t.TempDir()
+	after := t.TempDir()
+	src := "package main\n\nfunc main() {\n\tfmt.Println(1)\n}\n"
+	if err := os.WriteFile(filepath.Join(before, "a.go"), []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// the lines are shifted by the changes above
+	if err := os.WriteFile(filepath.Join(after, "a.go"), []byte("// Package main.\n// Another line.\n"+src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprintOf := func(dir string, line int, msg string) string {
+		a := Agent{RepoDir: dir, LinterConfig: config.Linter{Name: "golangci-lint"}}
+		results := map[string][]LinterOutput{"a.go": {{Line: line, Message: msg}}}
+		setFingerprints(a, results)
+		if results["a.go"][0].File != "a.go" {
+			t.Errorf("expected file a.go, got %s", results["a.go"][0].File)
+		}
+		return results["a.go"][0].Fingerprint
+	}
+
+	fp := fingerprintOf(before, 4, "undefined: fmt")
+	if fp == "" {
+		t.Fatal("expected fingerprint, got empty")
+	}
+	if got := fingerprintOf(after, 6, "undefined: fmt"); got != fp {
+		t.Errorf("expected stable fingerprint %s after lines shifted, got %s", fp, got)
+	}
+	if got := fingerprintOf(before, 3, "undefined: fmt"); got == fp {
+		t.Errorf("expected different fingerprint for other line, got same %s", got)
+	}
+	if got := fingerprintOf(before, 4, "unused: fmt"); got == fp {
+		t.Errorf("expected different fingerprint for other message, got same %s", got)
+	}
+}
+
+func TestFingerprintMarker(t *testing.T) {
+	tcs := []struct {
+		body string
+		want string
+	}{
+		{body: "[golangci-lint] msg" + fingerprintMarker("0123abcd"), want: "0123abcd"},
+		{body: "[golangci-lint] msg", want: ""},
+		{body: "[golangci-lint] msg" + fingerprintMarker(""), want: ""},
+		{body: "[golangci-lint] msg <!--reviewbot:fp:xyz-->", want: ""},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.body, func(t *testing.T) {
+			if got := parseFingerprintMarker(tc.body); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	body := retiredBody("[golangci-lint] msg" + fingerprintMarker("0123abcd"))
+	if !isRetired(body) || parseFingerprintMarker(body) != "0123abcd" {
+		t.Errorf("expected retired comment to keep the fingerprint, got %q", body)
+	}
+}
+
+func TestFilterLinterOutputs(t *testing.T) {
+	comment := func(id int64, path string, line int, body string) *github.PullRequestComment {
+		return &github.PullRequestComment{ID: github.Int64(id), Path: github.String(path), Line: github.Int(line), Body: github.String(body)}
+	}
+	outputs := map[string][]LinterOutput{
+		"a.go": {
+			{File: "a.go", Line: 1, Message: "kept", Fingerprint: "aa"},
+			{File: "a.go", Line: 5, Message: "moved", Fingerprint: "bb"},
+			{File: "a.go", Line: 7, Message: "legacy"},
+			{File: "a.go", Line: 9, Message: "new", Fingerprint: "cc"},
+			{File: "a.go", Line: 20, Message: "dup", Fingerprint: "abab"},
+			{File: "a.go", Line: 30, Message: "dup", Fingerprint: "abab"},
+		},
+	}
+	comments := []*github.PullRequestComment{
+		comment(1, "a.go", 1, "[lint] kept"+fingerprintMarker("aa")),
+		comment(2, "a.go", 3, "[lint] moved"+fingerprintMarker("bb")),
+		comment(3, "a.go", 7, "[lint] legacy"),
+		comment(4, "a.go", 11, "[lint] fixed"+fingerprintMarker("dd")),
+		comment(5, "a.go", 12, "[lint] discussed"+fingerprintMarker("ee")),
+		comment(6, "a.go", 13, retiredBody("[lint] retired"+fingerprintMarker("ff"))),
+		comment(7, "a.go", 31, "[lint] dup"+fingerprintMarker("abab")),
+		comment(8, "a.go", 100, "[lint] dup"+fingerprintMarker("abab")),
+		comment(9, "a.go", 21, "[lint] dup"+fingerprintMarker("abab")),
+	}
+
+	toAdds, toDeletes, toRetires := filterLinterOutputs(outputs, comments, map[int64]bool{5: true, 6: true})
+
+	var added []string
+	for _, o := range toAdds["a.go"] {
+		added = append(added, o.Message)
+	}
+	// the comment of the moved output is kept to preserve the discussion on it
+	if want := []string{"new"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("expected added %v, got %v", want, added)
+	}
+	var deleted []int64
+	for _, c := range toDeletes {
+		deleted = append(deleted, c.GetID())
+	}
+	// the nearest comments of the same fingerprint are kept
+	if want := []int64{4, 8}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("expected deleted %v, got %v", want, deleted)
+	}
+	if len(toRetires) != 1 || toRetires[0].GetID() != 5 {
+		t.Errorf("expected retired [5], got %v", toRetires)
+	}
+}
+
+func TestFilterMergeRequestDiscussions(t *testing.T) {
+	discussion := func(id string, path string, line int, body string, resolved bool, replies int) *gitlab.Discussion {
+		d := &gitlab.Discussion{ID: id, Notes: []*gitlab.Note{{
+			Type:     gitlab.DiffNote,
+			Body:     body,
+			Resolved: resolved,
+			Position: &gitlab.NotePosition{NewPath: path, NewLine: line},
+		}}}
+		for i := 0; i < replies; i++ {
+			d.Notes = append(d.Notes, &gitlab.Note{Body: "reply"})
+		}
+		return d
+	}
+	outputs := map[string][]LinterOutput{
+		"a.go": {
+			{File: "a.go", Line: 1, Message: "kept", Fingerprint: "aa"},
+			{File: "a.go", Line: 5, Message: "moved", Fingerprint: "bb"},
+			{File: "a.go", Line: 9, Message: "new", Fingerprint: "cc"},
+			{File: "a.go", Line: 15, Message: "resolved", Fingerprint: "ff"},
+		},
+	}
+	dlist := []*gitlab.Discussion{
+		discussion("kept", "a.go", 1, "[lint] kept"+fingerprintMarker("aa"), false, 0),
+		discussion("moved", "a.go", 3, "[lint] moved"+fingerprintMarker("bb"), false, 0),
+		discussion("legacy", "a.go", 7, "[lint] legacy", false, 0),
+		discussion("fixed", "a.go", 11, "[lint] fixed"+fingerprintMarker("dd"), false, 0),
+		discussion("discussed", "a.go", 12, "[lint] discussed"+fingerprintMarker("ee"), false, 1),
+		discussion("resolved", "a.go", 13, "[lint] resolved"+fingerprintMarker("ff"), true, 1),
+		discussion("other", "a.go", 14, "[other] msg"+fingerprintMarker("gg"), false, 0),
+	}
+
+	toAdds, toDeletes, toResolves := filterMergeRequestDiscussions(outputs, dlist, "[lint]")
+
+	var added []string
+	for _, o := range toAdds["a.go"] {
+		added = append(added, o.Message)
+	}
+	// the moved and the resolved ones are not posted again
+	if want := []string{"new"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("expected added %v, got %v", want, added)
+	}
+	if len(toDeletes) != 2 || toDeletes[0].ID != "legacy" || toDeletes[1].ID != "fixed" {
+		t.Errorf("expected deleted [legacy fixed], got %v", toDeletes)
+	}
+	if len(toResolves) != 1 || toResolves[0].ID != "discussed" {
+		t.Errorf("expected resolved [discussed], got %v", toResolves)
+	}
+}
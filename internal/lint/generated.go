@@ -0,0 +1,239 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/log"
+)
+
+const (
+	// generatedHeadBytes is the max size of the file head to read when detecting generated files.
+	generatedHeadBytes = 16 * 1024
+	// generatedHeadLines is the max number of lines in the head to match the header patterns.
+	generatedHeadLines = 40
+)
+
+// GeneratedFileDetector decides whether a file is generated.
+type GeneratedFileDetector interface {
+	// IsGenerated reports whether the file is generated.
+	// file is the path relative to the repo root, head is the beginning of the file content, which may be nil if the file is not readable.
+	IsGenerated(file string, head []byte) bool
+}
+
+// goGeneratedRex is the official convention of generated Go files.
+// see https://pkg.go.dev/cmd/go#hdr-Generate_Go_files_by_processing_source
+var goGeneratedRex = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// goGeneratedDetector detects generated Go files by the official comment before the package clause.
+type goGeneratedDetector struct{}
+
+func (goGeneratedDetector) IsGenerated(file string, head []byte) bool {
+	if filepath.Ext(file) != ".go" {
+		return false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(head))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+		if goGeneratedRex.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultHeaderPatterns are the common headers of generated files in languages other than Go.
+// Only comment lines are matched, so that the files merely mention these words in code are not skipped.
+var defaultHeaderPatterns = map[string][]*regexp.Regexp{
+	"*": {
+		regexp.MustCompile(`^\s*(//|#|--|/?\*+|<!--)\s*@generated\b`),
+		regexp.MustCompile(`(?i)^\s*(//|#|--|/?\*+|<!--)\s*(code generated|generated by|autogenerated by|auto-generated by|this file (is|was) (automatically |auto-)?generated)\b`),
+		regexp.MustCompile(`^\s*(//|#|--|/?\*+|<!--)\s*DO NOT EDIT\b`),
+	},
+}
+
+// headerDetector detects generated files by the patterns of lines in the file head, keyed by file extension.
+type headerDetector struct {
+	patterns map[string][]*regexp.Regexp
+	// skipGo skips Go files since they are handled by goGeneratedDetector.
+	skipGo bool
+}
+
+func (h headerDetector) IsGenerated(file string, head []byte) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	if h.skipGo && ext == ".go" {
+		return false
+	}
+	patterns := append(append([]*regexp.Regexp{}, h.patterns["*"]...), h.patterns[ext]...)
+	if len(patterns) == 0 {
+		return false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(head))
+	for i := 0; i < generatedHeadLines && scanner.Scan(); i++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		for _, pattern := range patterns {
+			if pattern.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pathDetector detects generated files by path globs.
+type pathDetector struct {
+	patterns []string
+}
+
+func (p pathDetector) IsGenerated(file string, _ []byte) bool {
+	for _, pattern := range p.patterns {
+		if matched, _ := doublestar.Match(pattern, file); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// gitAttributesDetector detects generated files by the linguist-generated attribute in .gitattributes of the repo root.
+// see https://github.com/github-linguist/linguist/blob/master/docs/overrides.md#generated-code
+type gitAttributesDetector struct {
+	rules []gitAttributesRule
+}
+
+type gitAttributesRule struct {
+	pattern   string
+	generated bool
+}
+
+// newGitAttributesDetector parses the .gitattributes under the repo root, nil is returned if no related rules.
+func newGitAttributesDetector(repoDir string) *gitAttributesDetector {
+	content, err := os.ReadFile(filepath.Join(repoDir, ".gitattributes"))
+	if err != nil {
+		return nil
+	}
+
+	var rules []gitAttributesRule
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attr := range fields[1:] {
+			var generated bool
+			switch attr {
+			case "linguist-generated", "linguist-generated=true":
+				generated = true
+			case "-linguist-generated", "!linguist-generated", "linguist-generated=false":
+				generated = false
+			default:
+				continue
+			}
+			rules = append(rules, gitAttributesRule{pattern: gitAttributesPattern(fields[0]), generated: generated})
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &gitAttributesDetector{rules: rules}
+}
+
+// gitAttributesPattern converts the gitattributes pattern to the doublestar pattern.
+// The pattern without slash matches the file name in any directory, otherwise it's relative to the repo root.
+func gitAttributesPattern(pattern string) string {
+	if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		return "**/" + pattern
+	}
+	return strings.TrimPrefix(pattern, "/")
+}
+
+func (g *gitAttributesDetector) IsGenerated(file string, _ []byte) bool {
+	var generated bool
+	// the last matched rule wins
+	for _, rule := range g.rules {
+		if matched, _ := doublestar.Match(rule.pattern, file); matched {
+			generated = rule.generated
+		}
+	}
+	return generated
+}
+
+// generatedFileDetectors combines multiple detectors, a file is generated if any detector says so.
+type generatedFileDetectors []GeneratedFileDetector
+
+func (d generatedFileDetectors) IsGenerated(file string, head []byte) bool {
+	for _, detector := range d {
+		if detector.IsGenerated(file, head) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewGeneratedFileDetector creates the detector for the repo.
+// It detects generated files by the official Go comment, the common headers, .gitattributes of the repo,
+// and the extra path globs and header patterns in rules.
+// The invalid patterns in rules are ignored, which should be validated when loading the config.
+func NewGeneratedFileDetector(repoDir string, rules config.GeneratedFiles) GeneratedFileDetector {
+	detectors := generatedFileDetectors{
+		goGeneratedDetector{},
+		headerDetector{patterns: defaultHeaderPatterns, skipGo: true},
+	}
+	if g := newGitAttributesDetector(repoDir); g != nil {
+		detectors = append(detectors, g)
+	}
+	if len(rules.Paths) > 0 {
+		detectors = append(detectors, pathDetector{patterns: rules.Paths})
+	}
+	if len(rules.HeaderPatterns) > 0 {
+		patterns := make(map[string][]*regexp.Regexp, len(rules.HeaderPatterns))
+		for ext, exprs := range rules.HeaderPatterns {
+			for _, expr := range exprs {
+				rex, err := regexp.Compile(expr)
+				if err != nil {
+					log.Errorf("invalid header pattern of generated files: %s, ignore it: %v", expr, err)
+					continue
+				}
+				patterns[strings.ToLower(ext)] = append(patterns[strings.ToLower(ext)], rex)
+			}
+		}
+		detectors = append(detectors, headerDetector{patterns: patterns})
+	}
+	return detectors
+}
+
+// readFileHead reads the beginning of the file.
+func readFileHead(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, generatedHeadBytes))
+}
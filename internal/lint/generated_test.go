@@ -0,0 +1,175 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+)
+
+func TestGeneratedFileDetector(t *testing.T) {
+	repoDir := t.TempDir()
+	gitattributes := "# generated code\n" +
+		"*.pb.ts linguist-generated\n" +
+		"/docs/api/** linguist-generated=true\n" +
+		"docs/api/manual.md -linguist-generated\n"
+	if err := os.WriteFile(filepath.Join(repoDir, ".gitattributes"), []byte(gitattributes), 0o600); err != nil {
+		t.Fatalf("failed to write .gitattributes: %v", err)
+	}
+
+	detector := NewGeneratedFileDetector(repoDir, config.GeneratedFiles{
+		Paths: []string{"api/gen/**"},
+		HeaderPatterns: map[string][]string{
+			".ts": {`^// swagger-codegen v\d+`},
+		},
+	})
+
+	tcs := []struct {
+		name     string
+		file     string
+		head     string
+		expected bool
+	}{
+		{
+			name:     "go generated header before package",
+			file:     "a.go",
+			head:     "// Code generated by protoc-gen-go. DO NOT EDIT.\n// source: a.proto\n\npackage a\n",
+			expected: true,
+		},
+		{
+			name:     "go generated header after build tag",
+			file:     "a.go",
+			head:     "//go:build linux\n\n// Code generated by stringer; DO NOT EDIT.\n\npackage a\n",
+			expected: true,
+		},
+		{
+			name:     "go generated header after package",
+			file:     "a.go",
+			head:     "package a\n\n// Code generated by hand. DO NOT EDIT.\n",
+			expected: false,
+		},
+		{
+			name:     "go file mentions generated in body",
+			file:     "a.go",
+			head:     "package a\n\nconst header = \"Code generated by reviewbot. DO NOT EDIT.\"\n",
+			expected: false,
+		},
+		{
+			name:     "go file not matching the official convention",
+			file:     "a.go",
+			head:     "// Code generated by hand, DO NOT EDIT\npackage a\n",
+			expected: false,
+		},
+		{
+			name:     "ts generated by protobuf",
+			file:     "web/a.ts",
+			head:     "// @generated by protoc-gen-es v1.0.0\n// @generated from file a.proto\n",
+			expected: true,
+		},
+		{
+			name:     "python generated header",
+			file:     "a_pb2.py",
+			head:     "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n",
+			expected: true,
+		},
+		{
+			name:     "python mentions generated in code",
+			file:     "a.py",
+			head:     "msg = 'Code generated by reviewbot'\n",
+			expected: false,
+		},
+		{
+			name:     "gitattributes pattern without slash",
+			file:     "web/proto/a.pb.ts",
+			head:     "export const a = 1;\n",
+			expected: true,
+		},
+		{
+			name:     "gitattributes pattern relative to root",
+			file:     "docs/api/index.md",
+			expected: true,
+		},
+		{
+			name:     "gitattributes later rule unsets",
+			file:     "docs/api/manual.md",
+			expected: false,
+		},
+		{
+			name:     "configured path glob",
+			file:     "api/gen/v1/client.go",
+			head:     "package v1\n",
+			expected: true,
+		},
+		{
+			name:     "configured header pattern",
+			file:     "web/client.ts",
+			head:     "/* eslint-disable */\n// swagger-codegen v2\n",
+			expected: true,
+		},
+		{
+			name:     "configured header pattern of other extension",
+			file:     "web/client.js",
+			head:     "// swagger-codegen v2\n",
+			expected: false,
+		},
+		{
+			name:     "normal file",
+			file:     "pkg/a.go",
+			head:     "package a\n",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detector.IsGenerated(tc.file, []byte(tc.head)); got != tc.expected {
+				t.Errorf("IsGenerated(%s) = %v, want %v", tc.file, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFilterByAutoGenerated(t *testing.T) {
+	repoDir := t.TempDir()
+	files := map[string]string{
+		"a.go":          "// Code generated by mockgen. DO NOT EDIT.\n\npackage a\n",
+		"b.go":          "package b\n\n// DO NOT EDIT the following constants.\n",
+		"gen/client.go": "package gen\n",
+	}
+	for file, content := range files {
+		path := filepath.Join(repoDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	a := Agent{
+		RepoDir:               repoDir,
+		GeneratedFileDetector: NewGeneratedFileDetector(repoDir, config.GeneratedFiles{Paths: []string{"gen/**"}}),
+	}
+	results := map[string][]LinterOutput{
+		"a.go":          {{File: "a.go", Line: 3, Message: "a"}},
+		"b.go":          {{File: "b.go", Line: 3, Message: "b"}},
+		"gen/client.go": {{File: "gen/client.go", Line: 1, Message: "c"}},
+		"missing.go":    {{File: "missing.go", Line: 1, Message: "d"}},
+	}
+
+	got, err := filterByAutoGenerated(a, results)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, file := range []string{"a.go", "gen/client.go"} {
+		if _, ok := got[file]; ok {
+			t.Errorf("expected %s to be filtered", file)
+		}
+	}
+	for _, file := range []string{"b.go", "missing.go"} {
+		if _, ok := got[file]; !ok {
+			t.Errorf("expected %s to be kept", file)
+		}
+	}
+}
@@ -17,17 +17,28 @@
 package lint
 
 import (
+	"bufio"
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
+
+	"github.com/qiniu/reviewbot/config"
 )
 
+// defaultDiffContext is the default number of context lines when the diff mode is context.
+const defaultDiffContext = 3
+
 type HunkChecker interface {
 	InHunk(file string, line int) bool
 }
 
+// FileHunkChecker checks whether the lines are changed in the PR/MR.
 type FileHunkChecker struct {
 	Hunks map[string][]Hunk
+	// Diffs is the parsed diff of each file, which is used to check the lines precisely.
+	// Optional, if the file is not in Diffs, only Hunks is used.
+	Diffs map[string]*FileDiff
 }
 
 type Hunk struct {
@@ -35,6 +46,41 @@ type Hunk struct {
 	EndLine   int
 }
 
+// FileDiff is the parsed unified diff of a file, the line numbers are of the new file.
+type FileDiff struct {
+	// Hunks are the line ranges covered by the diff hunks, including the context lines.
+	Hunks []Hunk
+	// AddedLines are the added or modified lines.
+	AddedLines map[int]bool
+	// Binary is true if the file is binary, which has no line level changes.
+	Binary bool
+}
+
+// DiffOptions decides which lines of the changes are considered related.
+type DiffOptions struct {
+	Mode config.DiffMode
+	// Context is the number of lines to extend the hunks on both sides, only used in context mode.
+	Context int
+}
+
+// DiffOptionsOf returns the diff options of the linter, with defaults applied.
+func DiffOptionsOf(linter config.Linter) DiffOptions {
+	opts := DiffOptions{
+		Mode:    linter.DiffMode,
+		Context: linter.DiffContext,
+	}
+	if linter.Scope == config.ScopeFiles || linter.Scope == config.ScopeRepo {
+		opts.Mode = config.DiffModeFile
+	}
+	if opts.Mode == "" {
+		opts.Mode = config.DiffModeAdded
+	}
+	if opts.Mode == config.DiffModeContext && opts.Context == 0 {
+		opts.Context = defaultDiffContext
+	}
+	return opts
+}
+
 // NewFileHunkChecker creates a new FileHunkChecker with given hunks map.
 func NewFileHunkChecker(hunks map[string][]Hunk) *FileHunkChecker {
 	return &FileHunkChecker{
@@ -42,16 +88,82 @@ func NewFileHunkChecker(hunks map[string][]Hunk) *FileHunkChecker {
 	}
 }
 
+// NewFileDiffChecker creates a new FileHunkChecker with given diffs map.
+func NewFileDiffChecker(diffs map[string]*FileDiff) *FileHunkChecker {
+	hunks := make(map[string][]Hunk, len(diffs))
+	for file, diff := range diffs {
+		hunks[file] = diff.Hunks
+	}
+	return &FileHunkChecker{
+		Hunks: hunks,
+		Diffs: diffs,
+	}
+}
+
 func (c *FileHunkChecker) InHunk(file string, line, startLine int) bool {
 	if hunks, ok := c.Hunks[file]; ok {
-		for _, hunk := range hunks {
-			if startLine != 0 {
-				if startLine >= hunk.StartLine && line <= hunk.EndLine {
-					return true
-				}
-			} else if line >= hunk.StartLine && line <= hunk.EndLine {
+		return inHunks(hunks, line, startLine, 0)
+	}
+	return false
+}
+
+// IsRelated checks whether the lines are related to the changes of the file according to the diff options.
+// startLine is 0 if the issue is on a single line.
+func (c *FileHunkChecker) IsRelated(file string, line, startLine int, opts DiffOptions) bool {
+	diff, ok := c.Diffs[file]
+	if !ok {
+		// no precise diff, fall back to the hunks
+		if opts.Mode == config.DiffModeFile {
+			_, ok := c.Hunks[file]
+			return ok
+		}
+		if opts.Mode == config.DiffModeContext {
+			return inHunks(c.Hunks[file], line, startLine, opts.Context)
+		}
+		return c.InHunk(file, line, startLine)
+	}
+	return diff.IsRelated(line, startLine, opts)
+}
+
+// IsRelated checks whether the lines are related to the changes according to the diff options.
+// For a multi-line issue, the lines must be in the same hunk, and at least one of them should be added in added mode.
+func (d *FileDiff) IsRelated(line, startLine int, opts DiffOptions) bool {
+	if d.Binary {
+		return false
+	}
+	switch opts.Mode {
+	case config.DiffModeFile:
+		return true
+	case config.DiffModeHunk:
+		return inHunks(d.Hunks, line, startLine, 0)
+	case config.DiffModeContext:
+		return inHunks(d.Hunks, line, startLine, opts.Context)
+	default:
+		if !inHunks(d.Hunks, line, startLine, 0) {
+			return false
+		}
+		if startLine == 0 || startLine > line {
+			return d.AddedLines[line]
+		}
+		for l := startLine; l <= line; l++ {
+			if d.AddedLines[l] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// inHunks checks whether the lines are in one of the hunks, which are extended by context lines on both sides.
+func inHunks(hunks []Hunk, line, startLine, context int) bool {
+	for _, hunk := range hunks {
+		start, end := hunk.StartLine-context, hunk.EndLine+context
+		if startLine != 0 {
+			if startLine >= start && line <= end {
 				return true
 			}
+		} else if line >= start && line <= end {
+			return true
 		}
 	}
 	return false
@@ -59,30 +171,126 @@ func (c *FileHunkChecker) InHunk(file string, line, startLine int) bool {
 
 // ParsePatch parses a unified diff patch string and returns hunks.
 func ParsePatch(patch string) ([]Hunk, error) {
-	hunks := make([]Hunk, 0)
+	diff, err := ParseFileDiff(patch)
+	if err != nil {
+		return nil, err
+	}
+	return diff.Hunks, nil
+}
+
+// ParseFileDiff parses the unified diff of a single file.
+// The file headers like `diff --git`, `---`, `+++` and `rename from` are skipped if present.
+// NOTE: if the body of a hunk is missing, all lines of the hunk are considered added,
+// since the diff may be truncated by the git provider.
+func ParseFileDiff(patch string) (*FileDiff, error) {
+	diff := &FileDiff{
+		AddedLines: make(map[int]bool),
+	}
+
+	var (
+		// the current line number in the new file
+		newLine int
+		// the remaining lines of the current hunk in the old and new file
+		oldRemaining, newRemaining int
+		// the current hunk, nil if no hunk body seen yet
+		pending *Hunk
+	)
+	// flush marks the lines of the hunk as added if its body is missing.
+	flush := func() {
+		if pending != nil {
+			for l := pending.StartLine; l <= pending.EndLine; l++ {
+				diff.AddedLines[l] = true
+			}
+			pending = nil
+		}
+	}
 
-	groups := patchRegex.FindAllStringSubmatch(patch, -1)
-	for _, group := range groups {
-		if len(group) != 5 {
-			return nil, fmt.Errorf("invalid patch: %s", patch)
+	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if oldRemaining > 0 || newRemaining > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				pending = nil
+				diff.AddedLines[newLine] = true
+				newLine++
+				newRemaining--
+				continue
+			case strings.HasPrefix(text, "-"):
+				pending = nil
+				oldRemaining--
+				continue
+			case strings.HasPrefix(text, " "), text == "":
+				// an empty line is a context line whose leading space is trimmed by some tools
+				pending = nil
+				newLine++
+				oldRemaining--
+				newRemaining--
+				continue
+			case strings.HasPrefix(text, "\\"):
+				// \ No newline at end of file
+				continue
+			}
 		}
-		hunkStartLine, err := strconv.Atoi(group[3])
-		if err != nil {
-			return nil, fmt.Errorf("invalid patch: %s, hunkStartLine: %s", patch, group[3])
+
+		if strings.HasPrefix(text, "@@") {
+			flush()
+			headers := patchRegex.FindAllStringSubmatch(text, -1)
+			if len(headers) == 0 {
+				return nil, fmt.Errorf("invalid patch: %s", text)
+			}
+			for _, header := range headers {
+				hunk, oldLines, newLines, err := parseHunkHeader(header)
+				if err != nil {
+					return nil, fmt.Errorf("invalid patch: %s, %w", text, err)
+				}
+				flush()
+				diff.Hunks = append(diff.Hunks, hunk)
+				if newLines > 0 {
+					pending = &diff.Hunks[len(diff.Hunks)-1]
+				}
+				newLine, oldRemaining, newRemaining = hunk.StartLine, oldLines, newLines
+			}
+			continue
 		}
 
-		hunkLength, err := strconv.Atoi(group[4])
-		if err != nil {
-			return nil, fmt.Errorf("invalid patch: %s, hunkLength: %s", patch, group[4])
+		if strings.HasPrefix(text, "Binary files ") || text == "GIT binary patch" {
+			diff.Binary = true
 		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
 
-		hunks = append(hunks, Hunk{
-			StartLine: hunkStartLine,
-			EndLine:   hunkStartLine + hunkLength - 1,
-		})
+	return diff, nil
+}
+
+// parseHunkHeader parses the hunk header like `@@ -1,7 +1,8 @@`, the count is 1 if omitted.
+func parseHunkHeader(group []string) (hunk Hunk, oldLines, newLines int, err error) {
+	count := func(s string) (int, error) {
+		if s == "" {
+			return 1, nil
+		}
+		return strconv.Atoi(s)
+	}
+
+	if oldLines, err = count(group[2]); err != nil {
+		return hunk, 0, 0, fmt.Errorf("old lines: %s", group[2])
+	}
+	hunkStartLine, err := strconv.Atoi(group[3])
+	if err != nil {
+		return hunk, 0, 0, fmt.Errorf("hunkStartLine: %s", group[3])
+	}
+	if newLines, err = count(group[4]); err != nil {
+		return hunk, 0, 0, fmt.Errorf("hunkLength: %s", group[4])
 	}
 
-	return hunks, nil
+	return Hunk{
+		StartLine: hunkStartLine,
+		EndLine:   hunkStartLine + newLines - 1,
+	}, oldLines, newLines, nil
 }
 
-var patchRegex = regexp.MustCompile(`@@ \-(\d+),(\d+) \+(\d+),(\d+) @@`)
+var patchRegex = regexp.MustCompile(`@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
@@ -17,13 +17,168 @@
 package lint
 
 import (
-	"fmt"
+	"reflect"
+	"sort"
 	"testing"
+
+	"github.com/qiniu/reviewbot/config"
 )
 
 func TestParsePatch(t *testing.T) {
 	patch := "@@ -132,7 +132,7 @@ module Test @@ -1000,7 +1000,7 @@ module Test"
-	fmt.Println(ParsePatch(patch))
+	hunks, err := ParsePatch(patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Hunk{{StartLine: 132, EndLine: 138}, {StartLine: 1000, EndLine: 1006}}
+	if !reflect.DeepEqual(hunks, expected) {
+		t.Errorf("expected %v, got %v", expected, hunks)
+	}
+}
+
+func TestParseFileDiff(t *testing.T) {
+	tcs := []struct {
+		name          string
+		patch         string
+		expectedHunks []Hunk
+		expectedAdded []int
+		binary        bool
+	}{
+		{
+			name: "added and context lines",
+			patch: `@@ -10,4 +10,5 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	fmt.Println(a)
+ 	fmt.Println(b)`,
+			expectedHunks: []Hunk{{StartLine: 10, EndLine: 14}},
+			expectedAdded: []int{11, 12},
+		},
+		{
+			name: "single line hunks without count",
+			patch: `@@ -1 +1 @@
+-package a
++package b
+@@ -20,0 +21 @@ func a() {
++	return
+\ No newline at end of file`,
+			expectedHunks: []Hunk{{StartLine: 1, EndLine: 1}, {StartLine: 21, EndLine: 21}},
+			expectedAdded: []int{1, 21},
+		},
+		{
+			name: "deleted lines only",
+			patch: `@@ -5,2 +4,0 @@
+-a
+-b`,
+			expectedHunks: []Hunk{{StartLine: 4, EndLine: 3}},
+		},
+		{
+			name: "with file headers of renamed file",
+			patch: `diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+--- a/old.go
++++ b/new.go
+@@ -1,2 +1,2 @@
+--- a comment
++-- another comment
+ end`,
+			expectedHunks: []Hunk{{StartLine: 1, EndLine: 2}},
+			expectedAdded: []int{1},
+		},
+		{
+			name:          "binary file",
+			patch:         "Binary files a/logo.png and b/logo.png differ",
+			expectedAdded: []int{},
+			binary:        true,
+		},
+		{
+			name:          "hunk without body",
+			patch:         "@@ -1,7 +1,3 @@",
+			expectedHunks: []Hunk{{StartLine: 1, EndLine: 3}},
+			expectedAdded: []int{1, 2, 3},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			diff, err := ParseFileDiff(tc.patch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(diff.Hunks, tc.expectedHunks) {
+				t.Errorf("expected hunks %v, got %v", tc.expectedHunks, diff.Hunks)
+			}
+			var added []int
+			for line := range diff.AddedLines {
+				added = append(added, line)
+			}
+			sort.Ints(added)
+			if len(added) != len(tc.expectedAdded) || (len(added) > 0 && !reflect.DeepEqual(added, tc.expectedAdded)) {
+				t.Errorf("expected added lines %v, got %v", tc.expectedAdded, added)
+			}
+			if diff.Binary != tc.binary {
+				t.Errorf("expected binary %v, got %v", tc.binary, diff.Binary)
+			}
+		})
+	}
+}
+
+func TestFileDiffIsRelated(t *testing.T) {
+	diff, err := ParseFileDiff(`@@ -10,4 +10,5 @@
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	fmt.Println(a)
+ 	fmt.Println(b)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tcs := []struct {
+		name      string
+		opts      DiffOptions
+		startLine int
+		line      int
+		expected  bool
+	}{
+		{"added mode, added line", DiffOptions{Mode: config.DiffModeAdded}, 0, 11, true},
+		{"added mode, context line", DiffOptions{Mode: config.DiffModeAdded}, 0, 13, false},
+		{"added mode, range covers added line", DiffOptions{Mode: config.DiffModeAdded}, 10, 13, true},
+		{"added mode, range of context lines", DiffOptions{Mode: config.DiffModeAdded}, 13, 14, false},
+		{"added mode, range out of hunk", DiffOptions{Mode: config.DiffModeAdded}, 8, 11, false},
+		{"hunk mode, context line", DiffOptions{Mode: config.DiffModeHunk}, 0, 13, true},
+		{"hunk mode, out of hunk", DiffOptions{Mode: config.DiffModeHunk}, 0, 15, false},
+		{"context mode, near hunk", DiffOptions{Mode: config.DiffModeContext, Context: 3}, 0, 17, true},
+		{"context mode, far from hunk", DiffOptions{Mode: config.DiffModeContext, Context: 3}, 0, 18, false},
+		{"file mode", DiffOptions{Mode: config.DiffModeFile}, 0, 100, true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := diff.IsRelated(tc.line, tc.startLine, tc.opts); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	binary := &FileDiff{Binary: true}
+	if binary.IsRelated(1, 0, DiffOptions{Mode: config.DiffModeFile}) {
+		t.Errorf("expected binary file not related")
+	}
+}
+
+func TestDiffOptionsOf(t *testing.T) {
+	if got := DiffOptionsOf(config.Linter{}); got.Mode != config.DiffModeAdded {
+		t.Errorf("expected default mode added, got %v", got.Mode)
+	}
+	if got := DiffOptionsOf(config.Linter{DiffMode: config.DiffModeContext}); got.Context != defaultDiffContext {
+		t.Errorf("expected default context %d, got %d", defaultDiffContext, got.Context)
+	}
 }
 
 func TestInHunk(t *testing.T) {
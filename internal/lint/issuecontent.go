@@ -0,0 +1,122 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/qiniu/reviewbot/config"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// IssueContentGetter fetches the content of the issue referenced by the issue reference.
+type IssueContentGetter interface {
+	GetIssueContent(ctx context.Context, ref config.CompiledIssueReference) (string, error)
+}
+
+// issueContentGetter fetches the issue content from GitHub, GitLab or the local knowledge base.
+type issueContentGetter struct {
+	githubClient *github.Client
+	gitlabClient *gitlab.Client
+}
+
+// NewIssueContentGetter creates an IssueContentGetter with the clients of the platforms,
+// so the issues of private repositories can be fetched with the credentials of the installation.
+// Both clients are optional, the issues are fetched anonymously if the client is nil,
+// or the GitLab client is not for the host of the issue.
+func NewIssueContentGetter(githubClient *github.Client, gitlabClient *gitlab.Client) IssueContentGetter {
+	return &issueContentGetter{
+		githubClient: githubClient,
+		gitlabClient: gitlabClient,
+	}
+}
+
+func (g *issueContentGetter) GetIssueContent(ctx context.Context, ref config.CompiledIssueReference) (string, error) {
+	if ref.File != "" {
+		content, err := os.ReadFile(ref.File)
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}
+
+	if ref.IssueNumber == 0 {
+		return "", ErrIssueNumberIsZero
+	}
+
+	switch ref.Platform {
+	case config.GitLab:
+		return g.getGitLabIssueContent(ctx, ref)
+	default:
+		return g.getGitHubIssueContent(ctx, ref)
+	}
+}
+
+func (g *issueContentGetter) getGitHubIssueContent(ctx context.Context, ref config.CompiledIssueReference) (string, error) {
+	client := g.githubClient
+	if client == nil {
+		client = github.NewClient(http.DefaultClient)
+	}
+
+	owner, repo, ok := strings.Cut(ref.Project, "/")
+	if !ok {
+		return "", fmt.Errorf("%w: invalid project %s", ErrFailedToFetchIssueContent, ref.Project)
+	}
+	issue, resp, err := client.Issues.Get(ctx, owner, repo, ref.IssueNumber)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: unexpected status %d", ErrFailedToFetchIssueContent, resp.StatusCode)
+	}
+	return issue.GetBody(), nil
+}
+
+func (g *issueContentGetter) getGitLabIssueContent(ctx context.Context, ref config.CompiledIssueReference) (string, error) {
+	client := g.gitlabClient
+	if client == nil || !sameHost(client.BaseURL(), ref.Host) {
+		var err error
+		client, err = gitlab.NewClient("", gitlab.WithBaseURL(ref.Host))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	issue, resp, err := client.Issues.GetIssue(ref.Project, ref.IssueNumber, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: unexpected status %d", ErrFailedToFetchIssueContent, resp.StatusCode)
+	}
+	return issue.Description, nil
+}
+
+// sameHost reports whether the base url of the client is for the host, e.g. "https://gitlab.com".
+func sameHost(baseURL *url.URL, host string) bool {
+	u, err := url.Parse(host)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(baseURL.Host, u.Host)
+}
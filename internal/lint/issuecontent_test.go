@@ -0,0 +1,124 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/qiniu/reviewbot/config"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+func TestGetIssueContent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/myorg/rules/issues/7", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"number":7,"body":"github issue body"}`))
+	})
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fsub%2Fproject/issues/12" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":1012,"iid":12,"description":"gitlab issue body"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	githubClient := github.NewClient(nil)
+	githubClient.BaseURL, _ = url.Parse(server.URL + "/")
+	gitlabClient, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "ST1003.md")
+	if err := os.WriteFile(file, []byte("local rule explanation"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name    string
+		ref     config.CompiledIssueReference
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "github issue",
+			ref:  config.CompiledIssueReference{Platform: config.GitHub, Host: "https://github.com", Project: "myorg/rules", IssueNumber: 7},
+			want: "github issue body",
+		},
+		{
+			name: "gitlab issue",
+			ref:  config.CompiledIssueReference{Platform: config.GitLab, Host: server.URL, Project: "group/sub/project", IssueNumber: 12},
+			want: "gitlab issue body",
+		},
+		{
+			name: "local file",
+			ref:  config.CompiledIssueReference{File: file},
+			want: "local rule explanation",
+		},
+		{
+			name:    "zero issue number",
+			ref:     config.CompiledIssueReference{Platform: config.GitHub, Project: "myorg/rules"},
+			wantErr: true,
+		},
+	}
+
+	getter := NewIssueContentGetter(githubClient, gitlabClient)
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := getter.GetIssueContent(context.Background(), tc.ref)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEnrichWithLocalIssueReferences(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "containedctx.md")
+	if err := os.WriteFile(file, []byte("do not store context in struct"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	agent := &Agent{
+		LinterConfig: config.Linter{ReportType: config.GitHubCheckRuns},
+		IssueReferences: []config.CompiledIssueReference{
+			{Pattern: regexp.MustCompile(`containedctx`), File: file},
+		},
+	}
+	results := agent.EnrichWithIssueReferences(context.Background(), map[string][]LinterOutput{
+		"a.go": {{File: "a.go", Line: 1, Message: "found a struct that contains a context.Context field (containedctx)"}},
+	})
+
+	want := "found a struct that contains a context.Context field (containedctx)" + fmt.Sprintf(ReferenceFooter, "do not store context in struct")
+	if got := results["a.go"][0].TypedMessage; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/xlog"
+)
+
+// JSONLine is the reviewbot-native output format, one JSON object per line, such as:
+//
+//	{"file":"pkg/a.go","line":12,"column":3,"message":"xxx","severity":"error","rule":"R001"}
+//	{"file":"pkg/b.go","startLine":3,"line":5,"message":"xxx","suggestion":"replacement of line 3 to 5"}
+//
+// Only file, line and message are required.
+// It is recommended for custom linters which are written by yourself.
+type JSONLine struct {
+	// File is the file path relative to the repository root.
+	File string `json:"file"`
+	// Line is the line number of the issue, it's the last line if the issue spans multiple lines.
+	Line int `json:"line"`
+	// Column is the column number of the issue, optional.
+	Column int `json:"column,omitempty"`
+	// StartLine is the first line of the issue if the issue spans multiple lines, optional.
+	StartLine int `json:"startLine,omitempty"`
+	// Message is the message of the issue.
+	Message string `json:"message"`
+	// Severity is one of error, warning, info and suggestion, optional, default is warning.
+	Severity config.Severity `json:"severity,omitempty"`
+	// Rule is the rule ID of the issue, optional.
+	Rule string `json:"rule,omitempty"`
+	// Suggestion is the replacement of the lines between StartLine and Line, optional.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+var errInvalidJSONLine = errors.New("file, line and message are required")
+
+// JSONLinesParse parses the reviewbot-native JSON lines output, see JSONLine.
+func JSONLinesParse(log *xlog.Logger, output []byte) (map[string][]LinterOutput, []string) {
+	lineParser := func(line string) (*LinterOutput, error) {
+		line = strings.TrimSpace(line)
+		if line == "" || isArtifactHeader(line) {
+			return nil, nil
+		}
+
+		var l JSONLine
+		if err := json.Unmarshal([]byte(line), &l); err != nil {
+			return nil, err
+		}
+		if l.File == "" || l.Line <= 0 || l.Message == "" {
+			return nil, errInvalidJSONLine
+		}
+
+		severity := l.Severity
+		if !severity.IsValid() {
+			severity = ParseSeverity(string(l.Severity))
+		}
+		o := &LinterOutput{
+			File:       l.File,
+			Line:       l.Line,
+			Column:     l.Column,
+			Message:    l.Message,
+			Severity:   severity,
+			Rule:       l.Rule,
+			Suggestion: l.Suggestion,
+		}
+		if l.StartLine > 0 && l.StartLine < l.Line {
+			o.StartLine = l.StartLine
+		}
+		return o, nil
+	}
+	return Parse(log, output, lineParser)
+}
@@ -0,0 +1,76 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/xlog"
+)
+
+func TestJSONLinesParse(t *testing.T) {
+	input := `---artifacts-xxx/lint.jsonl---
+{"file":"pkg/a.go","line":12,"column":3,"message":"do not use panic","severity":"error","rule":"R001"}
+{"file":"pkg/b.go","startLine":3,"line":5,"message":"simplify the loop","suggestion":"for range s {\n}"}
+{"file":"pkg/c.go","line":1,"message":"unknown level","severity":"note"}
+{"file":"pkg/d.go","message":"line is missing"}
+not a json line
+`
+	expected := map[string][]LinterOutput{
+		"pkg/a.go": {
+			{
+				File:     "pkg/a.go",
+				Line:     12,
+				Column:   3,
+				Message:  "do not use panic",
+				Severity: config.SeverityError,
+				Rule:     "R001",
+			},
+		},
+		"pkg/b.go": {
+			{
+				File:       "pkg/b.go",
+				Line:       5,
+				StartLine:  3,
+				Message:    "simplify the loop",
+				Suggestion: "for range s {\n}",
+			},
+		},
+		"pkg/c.go": {
+			{
+				File:     "pkg/c.go",
+				Line:     1,
+				Message:  "unknown level",
+				Severity: config.SeverityInfo,
+			},
+		},
+	}
+	expectedUnexpected := []string{
+		`{"file":"pkg/d.go","message":"line is missing"}`,
+		"not a json line",
+	}
+
+	got, unexpected := JSONLinesParse(xlog.New("ut"), []byte(input))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if !reflect.DeepEqual(unexpected, expectedUnexpected) {
+		t.Errorf("expected unexpected %v, got %v", expectedUnexpected, unexpected)
+	}
+}
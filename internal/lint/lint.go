@@ -21,12 +21,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/reviewbot/internal/metric"
 	"github.com/qiniu/reviewbot/internal/util"
 	"github.com/qiniu/x/log"
@@ -34,6 +35,8 @@ import (
 )
 
 var (
+	// registryMu guards the handlers and languages registered by the linters and the commands.
+	registryMu          sync.RWMutex
 	pullRequestHandlers = map[string]PullRequestHandlerFunc{}
 	linterLanguages     = map[string][]string{}
 )
@@ -43,16 +46,22 @@ type PullRequestHandlerFunc func(context.Context, Agent) error
 
 // RegisterPullRequestHandler registers a PullRequestHandlerFunc for the given linter name.
 func RegisterPullRequestHandler(name string, handler PullRequestHandlerFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
 	pullRequestHandlers[name] = handler
 }
 
 // RegisterLinterLanguages registers the languages supported by the linter.
 func RegisterLinterLanguages(name string, languages []string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
 	linterLanguages[name] = languages
 }
 
 // PullRequestHandler returns a PullRequestHandlerFunc for the given linter name.
 func PullRequestHandler(name string) PullRequestHandlerFunc {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
 	if handler, ok := pullRequestHandlers[name]; ok {
 		return handler
 	}
@@ -61,6 +70,8 @@ func PullRequestHandler(name string) PullRequestHandlerFunc {
 
 // TotalPullRequestHandlers returns all registered PullRequestHandlerFunc.
 func TotalPullRequestHandlers() map[string]PullRequestHandlerFunc {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
 	handlers := make(map[string]PullRequestHandlerFunc, len(pullRequestHandlers))
 	for name, handler := range pullRequestHandlers {
 		handlers[name] = handler
@@ -71,6 +82,8 @@ func TotalPullRequestHandlers() map[string]PullRequestHandlerFunc {
 
 // LinterLanguages returns the languages supported by the linter.
 func Languages(linterName string) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
 	return linterLanguages[linterName]
 }
 
@@ -95,6 +108,17 @@ type LinterOutput struct {
 	StartLine int
 	// TypedMessage is the typed message
 	TypedMessage string
+	// Severity is the severity of the output, empty means warning.
+	Severity config.Severity
+	// Rule is the rule ID of the output reported by the linter, optional.
+	Rule string
+	// Suggestion is the suggested replacement of the lines between StartLine and Line, optional.
+	// It will be presented as a suggestion which can be applied directly on the PR/MR.
+	Suggestion string
+	// Linters are the other linters which reported the same issue, set when merging duplicates across linters.
+	Linters []string
+	// Fingerprint identifies the issue across pushes, which is embedded in the comments as a hidden marker.
+	Fingerprint string
 }
 
 const CommentFooter = `
@@ -116,6 +140,9 @@ type LinterParser func(*xlog.Logger, []byte) (map[string][]LinterOutput, []strin
 func GeneralHandler(ctx context.Context, log *xlog.Logger, a Agent, execRun func(ctx context.Context, a Agent) ([]byte, error), linterParser func(*xlog.Logger, []byte) (map[string][]LinterOutput, []string)) error {
 	linterName := a.LinterConfig.Name
 	output, err := execRun(ctx, a)
+	if errors.Is(err, ErrLinterTimeout) {
+		return ReportTimeout(ctx, a)
+	}
 	if err != nil {
 		// NOTE(CarlJi): the error is *ExitError, it seems to have little information and needs to be handled in a better way.
 		log.Warnf("%s run with exit code: %v, mark and continue", linterName, err)
@@ -137,22 +164,43 @@ func GeneralHandler(ctx context.Context, log *xlog.Logger, a Agent, execRun func
 	return Report(ctx, a, lintResults)
 }
 
+// ErrLinterTimeout is returned by ExecRun if the linter doesn't finish within the configured timeout.
+var ErrLinterTimeout = errors.New("linter execution timed out")
+
 // ExecRun executes a command.
+// If the linter has a timeout configured, the runner will be cancelled once the timeout is exceeded,
+// and the partial output will be returned together with ErrLinterTimeout.
 func ExecRun(ctx context.Context, a Agent) ([]byte, error) {
 	eventGuid := util.FromContext(ctx).ReqId
 	start := time.Now()
-	reader, err := a.Runner.Run(ctx, &a.LinterConfig)
+
+	runCtx := ctx
+	timeout := a.LinterConfig.Timeout.Duration()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	reader, err := a.Runner.Run(runCtx, &a.LinterConfig)
+	// only the deadline of the linter itself is treated as timeout, the cancellation of the parent context is not.
+	timedOut := timeout > 0 && errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
 	if err != nil {
 		log.Warnf("failed to run linter: %v, mark and continue", err)
 	}
-	if reader == nil {
+	if reader == nil && !timedOut {
 		return nil, fmt.Errorf("runner returned nil reader with error: %w", err)
 	}
-	defer reader.Close()
 
-	output, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read linter output: %w", err)
+	var output []byte
+	if reader != nil {
+		defer reader.Close()
+		output, err = io.ReadAll(reader)
+		if err != nil && !timedOut {
+			return nil, fmt.Errorf("failed to read linter output: %w", err)
+		}
+		// the secrets in the env must not be leaked to the logs or the comments
+		output = []byte(a.Runner.Redact(string(output)))
 	}
 
 	end := time.Now()
@@ -160,6 +208,10 @@ func ExecRun(ctx context.Context, a Agent) ([]byte, error) {
 		start.Format(time.RFC3339), eventGuid, a.Runner.GetFinalScript()))
 	toLog = append(toLog, []byte(fmt.Sprintf("[%s][%s] output:\n%s\n",
 		end.Format(time.RFC3339), eventGuid, string(output)))...)
+	if timedOut {
+		toLog = append(toLog, []byte(fmt.Sprintf("[%s][%s] linter timed out after %v and was killed, the output above may be incomplete\n",
+			end.Format(time.RFC3339), eventGuid, timeout))...)
+	}
 	err = a.Storage.Write(ctx, a.GenLogKey(), toLog)
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
@@ -167,9 +219,34 @@ func ExecRun(ctx context.Context, a Agent) ([]byte, error) {
 		}
 	}
 
+	if timedOut {
+		return output, fmt.Errorf("%w after %v", ErrLinterTimeout, timeout)
+	}
+
 	return output, nil
 }
 
+// ReportTimeout reports that the linter was killed because of timeout.
+// The partial outputs are not reported since they may be incomplete and misleading.
+func ReportTimeout(ctx context.Context, a Agent) error {
+	log := util.FromContext(ctx)
+	log.Errorf("[%s] timed out after %v on PR %d (%s/%s)", a.LinterConfig.Name, a.LinterConfig.Timeout,
+		a.Provider.GetCodeReviewInfo().Number, a.Provider.GetCodeReviewInfo().Org, a.Provider.GetCodeReviewInfo().Repo)
+	return a.Provider.ReportTimeout(ctx, a, a.LinterConfig.Timeout.Duration())
+}
+
+// timeoutCommentMarker marks the comment reporting the timed out run, which is updated by the later runs.
+const timeoutCommentMarker = "\n<!--reviewbot:timeout-->"
+
+// timeoutMessage returns the message shown to users when the linter is timed out.
+func timeoutMessage(linterName string, timeout time.Duration, logURL string) string {
+	msg := fmt.Sprintf("⏱️ %s timed out after %v and was killed, so no lint results are reported for this run.", linterName, timeout)
+	if logURL != "" {
+		msg += fmt.Sprintf(" This is [the detailed log](%s).", logURL)
+	}
+	return msg + "\n\nPlease increase the `timeout` of the linter or click the `Re-run` button to try again."
+}
+
 // GeneralParse parses the output of a linter command.
 func GeneralParse(log *xlog.Logger, output []byte) (map[string][]LinterOutput, []string) {
 	return Parse(log, output, GeneralLineParser)
@@ -199,6 +276,12 @@ func Report(ctx context.Context, a Agent, lintResults map[string][]LinterOutput)
 		metric.IncIssueCounter(orgRepo, linterName, a.Provider.GetCodeReviewInfo().URL, a.Provider.GetCodeReviewInfo().HeadSHA, float64(countLinterErrors(lintResults)))
 	}
 
+	// report later with the other linters, so that the duplicates across linters can be merged
+	if a.Aggregator != nil {
+		a.Aggregator.Add(a, lintResults)
+		return nil
+	}
+
 	return a.Provider.Report(ctx, a, lintResults)
 }
 
@@ -377,17 +460,23 @@ func GeneralLineParser(line string) (*LinterOutput, error) {
 
 func GeneralLinterHandler(ctx context.Context, a Agent) error {
 	log := util.FromContext(ctx)
-	return GeneralHandler(ctx, log, a, ExecRun, GeneralParse)
+	return GeneralHandler(ctx, log, a, ExecRun, ParserFor(a.LinterConfig.OutputFormat))
 }
 
-func isGeneratedFile(file string) (bool, error) {
-	data, err := os.ReadFile(file)
-	if err != nil {
-		return false, err
-	}
+// outputParsers are the parsers of the well-known output formats.
+var outputParsers = map[config.OutputFormat]LinterParser{
+	config.OutputFormatText:       GeneralParse,
+	config.OutputFormatSarif:      SarifParse,
+	config.OutputFormatCheckstyle: CheckstyleParse,
+	config.OutputFormatJSONLines:  JSONLinesParse,
+}
 
-	dataStr := string(data)
-	return strings.Contains(dataStr, "Code generated by") || strings.Contains(dataStr, "DO NOT EDIT"), nil
+// ParserFor returns the parser of the output format, GeneralParse is used if the format is unknown.
+func ParserFor(format config.OutputFormat) LinterParser {
+	if parser, ok := outputParsers[format]; ok {
+		return parser
+	}
+	return GeneralParse
 }
 
 func IsEmpty(args ...string) bool {
@@ -20,9 +20,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/reviewbot/internal/runner"
@@ -224,10 +225,24 @@ func TestExecRun(t *testing.T) {
 			output: []byte("file2:6:7:message\nfile3:6:7:message\n"),
 			err:    nil,
 		},
+		{
+			id: "case3 - timed out",
+			input: Agent{
+				LinterConfig: config.Linter{
+					Enable:     &tp,
+					Command:    []string{"/bin/bash", "-c", "--"},
+					Args:       []string{"echo file:line:column:message; sleep 30"},
+					ReportType: config.Quiet,
+					Timeout:    config.Duration(500 * time.Millisecond),
+				},
+			},
+			output: []byte("file:line:column:message\n"),
+			err:    ErrLinterTimeout,
+		},
 	}
 	for _, tc := range tcs {
 		t.Run(tc.id, func(t *testing.T) {
-			storage, err := storage.NewLocalStorage(os.TempDir())
+			storage, err := storage.NewLocalStorage(t.TempDir())
 			if err != nil {
 				t.Errorf("failed to create local storage: %v", err)
 			}
@@ -244,6 +259,14 @@ func TestExecRun(t *testing.T) {
 			if string(output) != string(tc.output) {
 				t.Errorf("expected: %v, got: %v", string(tc.output), string(output))
 			}
+
+			log, err := storage.Read(ctx, "test")
+			if err != nil {
+				t.Errorf("failed to read log: %v", err)
+			}
+			if timedOut := strings.Contains(string(log), "timed out after"); timedOut != (tc.err != nil) {
+				t.Errorf("expected timeout recorded in log: %v, got log: %s", tc.err != nil, log)
+			}
 		})
 	}
 }
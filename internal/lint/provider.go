@@ -11,13 +11,16 @@ import (
 // It is responsible for interacting with the git provider when reviewing the PR/MR.
 type Provider interface {
 	// IsRelated returns whether the issue is related to the file changed.
-	// file/line/startLine represent the issue location.
-	IsRelated(file string, line int, startLine int) bool
+	// file/line/startLine represent the issue location, opts decides which lines of the changes are considered.
+	IsRelated(file string, line int, startLine int, opts DiffOptions) bool
 	// HandleComments handles the comments for the linter.
 	// Base on the linter outputs, the provider will create or update or delete the comments for the PR/MR.
 	HandleComments(ctx context.Context, outputs map[string][]LinterOutput) error
 	// Report reports the lint results to the provider.
 	Report(ctx context.Context, agent Agent, lintResults map[string][]LinterOutput) error
+	// ReportTimeout reports that the linter was killed since it didn't finish within the timeout.
+	// It should be distinguishable from a normal report, so that users won't think the linter passed.
+	ReportTimeout(ctx context.Context, agent Agent, timeout time.Duration) error
 	// GetFiles returns the files that match the given predicate in the PR/MR.
 	// if predicate is nil, it returns all the files except removed files in the PR/MR.
 	// NOTE(CarlJi): this is a simplified definition since only the file path is returned.
@@ -38,7 +38,9 @@ var (
 	ErrAfterTry                = errors.New("failed after 5 retries")
 	ErrDeleteComment           = errors.New("delete comment failed")
 	ErrCreateComment           = errors.New("create comment failed")
+	ErrEditComment             = errors.New("edit comment failed")
 	ErrCreateCheckRun          = errors.New("create check run failed")
+	ErrCreateIssue             = errors.New("create or update issue failed")
 	ErrListComments            = errors.New("list comments failed")
 	ErrListCommits             = errors.New("list commits failed")
 	ErrUnexpectedTransportType = errors.New("unexpected transport type")
@@ -167,6 +169,9 @@ func constructPullRequestComments(linterOutputs map[string][]LinterOutput, linte
 				message = fmt.Sprintf("%s %s",
 					linterName, output.Message)
 			}
+			message += alsoReportedBy(output)
+			message += githubSuggestion(output)
+			message += fingerprintMarker(output.Fingerprint)
 
 			if output.StartLine != 0 {
 				comments = append(comments, &github.PullRequestComment{
@@ -192,36 +197,71 @@ func constructPullRequestComments(linterOutputs map[string][]LinterOutput, linte
 	return comments
 }
 
-// filterPullRequestComments filters out the comments that are already posted by the bot.
-func filterLinterOutputs(outputs map[string][]LinterOutput, comments []*github.PullRequestComment) (toAdds map[string][]LinterOutput, toDeletes []*github.PullRequestComment) {
+// githubSuggestion returns the suggested change block of the output, empty if no suggestion.
+// see https://docs.github.com/en/pull-requests/collaborating-with-pull-requests/reviewing-changes-in-pull-requests/commenting-on-a-pull-request#adding-line-comments-to-a-pull-request
+func githubSuggestion(output LinterOutput) string {
+	if output.Suggestion == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\n```suggestion\n%s\n```", output.Suggestion)
+}
+
+// filterLinterOutputs reconciles the linter outputs with the comments already posted by the bot.
+// A comment is kept if it has the same fingerprint as the output, even if the line of the output is shifted by the new commits,
+// so that the discussion on it is not lost. The nearest one is kept if more comments have the same fingerprint.
+// The outputs without comments of the same fingerprint are posted as new comments.
+// The comments whose issues are not reported anymore are deleted, or retired if someone replied to keep the discussion.
+// NOTE: the comments without fingerprint are posted by the old versions, which are matched by path, line and message.
+func filterLinterOutputs(outputs map[string][]LinterOutput, comments []*github.PullRequestComment, replied map[int64]bool) (toAdds map[string][]LinterOutput, toDeletes, toRetires []*github.PullRequestComment) {
 	toAdds = make(map[string][]LinterOutput)
 
 	validComments := make(map[int64]struct{})
 	for file, lintFileErrs := range outputs {
 		for _, lintErr := range lintFileErrs {
-			var found bool
+			var matched *github.PullRequestComment
 			for _, comment := range comments {
-				if comment.GetPath() == file && comment.GetLine() == lintErr.Line && strings.Contains(comment.GetBody(), lintErr.Message) {
-					found = true
-					validComments[comment.GetID()] = struct{}{}
-					break
+				if _, ok := validComments[comment.GetID()]; ok || isRetired(comment.GetBody()) || comment.GetPath() != file {
+					continue
+				}
+				fingerprint := parseFingerprintMarker(comment.GetBody())
+				if fingerprint != "" && fingerprint == lintErr.Fingerprint &&
+					(matched == nil || lineDistance(comment.GetLine(), lintErr.Line) < lineDistance(matched.GetLine(), lintErr.Line)) {
+					matched = comment
+				}
+			}
+			if matched == nil {
+				for _, comment := range comments {
+					if _, ok := validComments[comment.GetID()]; ok || isRetired(comment.GetBody()) || comment.GetPath() != file || comment.GetLine() != lintErr.Line {
+						continue
+					}
+					if parseFingerprintMarker(comment.GetBody()) == "" && strings.Contains(comment.GetBody(), lintErr.Message) {
+						matched = comment
+						break
+					}
 				}
 			}
 
 			// if the linter err is not found, add it to the toAdds
-			if !found {
+			if matched == nil {
 				toAdds[file] = append(toAdds[file], lintErr)
+				continue
 			}
+			validComments[matched.GetID()] = struct{}{}
 		}
 	}
 
 	// filter out the comments that are not in the linter outputs
 	for _, comment := range comments {
-		if _, ok := validComments[comment.GetID()]; !ok {
-			toDeletes = append(toDeletes, comment)
+		if _, ok := validComments[comment.GetID()]; ok || isRetired(comment.GetBody()) {
+			continue
 		}
+		if replied[comment.GetID()] {
+			toRetires = append(toRetires, comment)
+			continue
+		}
+		toDeletes = append(toDeletes, comment)
 	}
-	return toAdds, toDeletes
+	return toAdds, toDeletes, toRetires
 }
 
 const Reference = "If you have any questions about this comment, feel free to [raise an issue here](https://github.com/qiniu/reviewbot)."
@@ -234,15 +274,31 @@ func toGithubCheckRunAnnotations(linterOutputs map[string][]LinterOutput) []*git
 				Path:            github.String(file),
 				StartLine:       github.Int(output.Line),
 				EndLine:         github.Int(output.Line),
-				AnnotationLevel: github.String("warning"),
+				AnnotationLevel: github.String(githubAnnotationLevel(SeverityOf(output))),
 				Message:         github.String(output.Message),
 			}
+			if len(output.Linters) > 0 {
+				annotation.Message = github.String(fmt.Sprintf("%s (also reported by: %s)", output.Message, strings.Join(output.Linters, ", ")))
+			}
 			annotations = append(annotations, annotation)
 		}
 	}
 	return annotations
 }
 
+// githubAnnotationLevel converts the severity to the annotation level of GitHub check run.
+// see https://docs.github.com/en/rest/checks/runs#create-a-check-run
+func githubAnnotationLevel(severity config.Severity) string {
+	switch severity {
+	case config.SeverityError:
+		return "failure"
+	case config.SeverityInfo, config.SeveritySuggest:
+		return "notice"
+	default:
+		return "warning"
+	}
+}
+
 // make sure the GithubProvider implements the Provider interface.
 var _ Provider = (*GithubProvider)(nil)
 
@@ -329,6 +385,10 @@ func (g *GithubProvider) Report(ctx context.Context, a Agent, lintResults map[st
 	num := a.Provider.GetCodeReviewInfo().Number
 	orgRepo := fmt.Sprintf("%s/%s", org, repo)
 
+	if a.LinterConfig.Scope == config.ScopeRepo {
+		return g.reportRepoScope(ctx, a, lintResults)
+	}
+
 	switch a.LinterConfig.ReportType {
 	case config.GitHubCheckRuns:
 		check := newBaseCheckRun(a, lintResults)
@@ -343,6 +403,11 @@ func (g *GithubProvider) Report(ctx context.Context, a Agent, lintResults map[st
 
 		metric.NotifyWebhookByText(ConstructGotchaMsg(linterName, a.Provider.GetCodeReviewInfo().URL, ch.GetHTMLURL(), lintResults))
 	case config.GitHubPRReview:
+		if err := g.deleteTimeoutComments(ctx, org, repo, num, linterName); err != nil {
+			log.Errorf("failed to delete timed out comments: %v", err)
+			return err
+		}
+		lintResults = selectTopLintResults(lintResults, a.inlineCommentsLimit())
 		lintResults = a.EnrichWithLLM(ctx, lintResults)
 		comments, err := g.ProcessComments(ctx, a, lintResults)
 		if err != nil {
@@ -373,10 +438,10 @@ func (g *GithubProvider) Report(ctx context.Context, a Agent, lintResults map[st
 		}
 		log.Infof("[%s] create check run success, HTML_URL: %v", linterName, ch.GetHTMLURL())
 
-		// report top 10 lint results to pull request review comments at most
-		top10LintResults := listTop10LintResults(lintResults)
-		top10LintResults = a.EnrichWithLLM(ctx, top10LintResults)
-		comments, err := g.ProcessComments(ctx, a, top10LintResults)
+		// report the top lint results to pull request review comments
+		topLintResults := selectTopLintResults(lintResults, a.inlineCommentsLimit())
+		topLintResults = a.EnrichWithLLM(ctx, topLintResults)
+		comments, err := g.ProcessComments(ctx, a, topLintResults)
 		if err != nil {
 			log.Errorf("failed to process need to add comments: %v", err)
 			return err
@@ -403,47 +468,189 @@ func (g *GithubProvider) Report(ctx context.Context, a Agent, lintResults map[st
 	return nil
 }
 
-// lists the top 10 lint results.
-func listTop10LintResults(lintResults map[string][]LinterOutput) map[string][]LinterOutput {
-	seenMsgs := make(map[string]struct{})
-	seenLinterOutput := make(map[string]struct{})
-	result := make(map[string][]LinterOutput)
-	count := 0
+// reportRepoScope reports all lint results in the repository.
+// The results are not related to the changes, so they are written to the check run summary,
+// or an issue if check run is not available, rather than the review comments.
+func (g *GithubProvider) reportRepoScope(ctx context.Context, a Agent, lintResults map[string][]LinterOutput) error {
+	log := util.FromContext(ctx)
+	linterName := a.LinterConfig.Name
+	org := a.Provider.GetCodeReviewInfo().Org
+	repo := a.Provider.GetCodeReviewInfo().Repo
 
-	// Filter out different outputs according to output.Message, when the results are greater than or equal to 10, return it directly.
-	for file, outputs := range lintResults {
-		for _, output := range outputs {
-			if count >= 10 {
-				return result
+	switch a.LinterConfig.ReportType {
+	case config.Quiet:
+		return nil
+	case config.GitHubPRReview:
+		// github_pr_review is usually used by the authentication which doesn't support check run
+		title := repoScopeIssueTitle(linterName, org, repo)
+		issue, err := g.upsertIssue(ctx, org, repo, title, repoScopeReport(a, lintResults, maxIssueBodyLen), len(lintResults) == 0)
+		if err != nil {
+			log.Errorf("failed to report repo scope results to issue: %v", err)
+			return err
+		}
+		if issue != nil {
+			log.Infof("[%s] report repo scope results to issue: %v", linterName, issue.GetHTMLURL())
+		}
+	default:
+		check := newRepoCheckRun(a, lintResults)
+		ch, err := g.CreateCheckRun(ctx, org, repo, check)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Errorf("failed to create github checks: %v", err)
 			}
-			if _, exists := seenMsgs[output.Message]; !exists {
-				seenMsgs[output.Message] = struct{}{}
-				seenLinterOutput[fmt.Sprintf("%s:%d", output.File, output.Line)] = struct{}{}
-				result[file] = append(result[file], output)
-				count++
+			return err
+		}
+		log.Infof("[%s] create repo scope check run success, HTML_URL: %v", linterName, ch.GetHTMLURL())
+	}
+	return nil
+}
+
+// upsertIssue updates the body of the open issue with the given title, or creates one if not found.
+// If closed is true, the existing issue is closed and no issue is created.
+func (g *GithubProvider) upsertIssue(ctx context.Context, owner, repo, title, body string, closed bool) (*github.Issue, error) {
+	existing, err := g.findOpenIssue(ctx, owner, repo, title)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		if closed {
+			return nil, nil
+		}
+		issue, resp, err := g.GithubClient.Issues.Create(ctx, owner, repo, &github.IssueRequest{
+			Title: github.String(title),
+			Body:  github.String(body),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusCreated {
+			return nil, fmt.Errorf("%w: %v", ErrCreateIssue, resp.Status)
+		}
+		return issue, nil
+	}
+
+	req := &github.IssueRequest{Body: github.String(body)}
+	if closed {
+		req.State = github.String("closed")
+	}
+	issue, resp, err := g.GithubClient.Issues.Edit(ctx, owner, repo, existing.GetNumber(), req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %v", ErrCreateIssue, resp.Status)
+	}
+	return issue, nil
+}
+
+// findOpenIssue finds the open issue with the given title, nil if not found.
+func (g *GithubProvider) findOpenIssue(ctx context.Context, owner, repo, title string) (*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State: "open",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		issues, resp, err := g.GithubClient.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if !issue.IsPullRequest() && issue.GetTitle() == title {
+				return issue, nil
 			}
 		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
 	}
+}
 
-	// After the above processing, if the results are less than 10, then add the output according to the file and line.
-	for file, outputs := range lintResults {
-		for _, output := range outputs {
-			if count >= 10 {
-				return result
+func (g *GithubProvider) ReportTimeout(ctx context.Context, a Agent, timeout time.Duration) error {
+	log := util.FromContext(ctx)
+	linterName := a.LinterConfig.Name
+	org := a.Provider.GetCodeReviewInfo().Org
+	repo := a.Provider.GetCodeReviewInfo().Repo
+	num := a.Provider.GetCodeReviewInfo().Number
+
+	switch a.LinterConfig.ReportType {
+	case config.GitHubCheckRuns, config.GitHubMixType:
+		ch, err := g.CreateCheckRun(ctx, org, repo, newTimeoutCheckRun(a, timeout))
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Errorf("failed to create github checks: %v", err)
 			}
-			key := fmt.Sprintf("%s:%d", output.File, output.Line)
-			if _, exists := seenLinterOutput[key]; !exists {
-				result[file] = append(result[file], output)
-				count++
+			return err
+		}
+		log.Infof("[%s] create timed out check run success, HTML_URL: %v", linterName, ch.GetHTMLURL())
+	case config.GitHubPRReview:
+		// update the timed out comment of the previous runs if exists, rather than posting one more
+		existed, err := g.listTimeoutComments(ctx, org, repo, num, linterName)
+		if err != nil {
+			log.Errorf("failed to list timed out comments: %v", err)
+			return err
+		}
+		body := fmt.Sprintf("%s%s%s", linterNamePrefixV2(linterName), timeoutMessage(linterName, timeout, a.GenLogViewURL()), timeoutCommentMarker)
+		if len(existed) == 0 {
+			if _, err := g.CreateComment(ctx, org, repo, num, &Comment{Body: body}); err != nil {
+				log.Errorf("failed to post timed out comment: %v", err)
+				return err
+			}
+			return nil
+		}
+		if err := g.EditComment(ctx, org, repo, existed[0].ID, body); err != nil {
+			log.Errorf("failed to update timed out comment: %v", err)
+			return err
+		}
+		for _, c := range existed[1:] {
+			if err := g.DeleteComment(ctx, org, repo, c.ID); err != nil {
+				log.Errorf("failed to delete timed out comment: %v", err)
+				return err
 			}
 		}
+	case config.Quiet:
+		return nil
+	default:
+		log.Errorf("unsupported report format: %v", a.LinterConfig.ReportType)
+	}
+
+	return nil
+}
+
+// listTimeoutComments lists the comments posted by ReportTimeout for the linter.
+func (g *GithubProvider) listTimeoutComments(ctx context.Context, org, repo string, num int, linterName string) ([]Comment, error) {
+	comments, err := g.ListComments(ctx, org, repo, num)
+	if err != nil {
+		return nil, err
+	}
+	var timeoutComments []Comment
+	for _, c := range comments {
+		if strings.HasPrefix(c.Body, linterNamePrefixV2(linterName)) && strings.Contains(c.Body, timeoutCommentMarker) {
+			timeoutComments = append(timeoutComments, c)
+		}
 	}
+	return timeoutComments, nil
+}
 
-	return result
+// deleteTimeoutComments deletes the timed out comments of the linter, since it finished in time now.
+func (g *GithubProvider) deleteTimeoutComments(ctx context.Context, org, repo string, num int, linterName string) error {
+	comments, err := g.listTimeoutComments(ctx, org, repo, num, linterName)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if err := g.DeleteComment(ctx, org, repo, c.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (g *GithubProvider) IsRelated(file string, line int, startLine int) bool {
-	return g.HunkChecker.InHunk(file, line, startLine)
+func (g *GithubProvider) IsRelated(file string, line int, startLine int, opts DiffOptions) bool {
+	return g.HunkChecker.IsRelated(file, line, startLine, opts)
 }
 
 func (g *GithubProvider) GetFiles(predicate func(filepath string) bool) []string {
@@ -509,6 +716,34 @@ func (g *GithubProvider) DeletePullReviewComments(ctx context.Context, owner, re
 	return nil
 }
 
+// RetirePullReviewComments marks the specified comments as retired, which are kept to preserve the discussion.
+func (g *GithubProvider) RetirePullReviewComments(ctx context.Context, owner, repo string, comments []*github.PullRequestComment) error {
+	log := util.FromContext(ctx)
+	for _, comment := range comments {
+		cmt := comment
+		err := RetryWithBackoff(ctx, func() error {
+			_, resp, err := g.GithubClient.PullRequests.EditComment(ctx, owner, repo, cmt.GetID(), &github.PullRequestComment{
+				Body: github.String(retiredBody(cmt.GetBody())),
+			})
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode != http.StatusOK {
+				log.Errorf("retire comment failed: %v", resp)
+				return ErrEditComment
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Infof("retire comment success: %v", comment.GetHTMLURL())
+	}
+
+	return nil
+}
+
 // CreatePullReviewComments creates the specified comments on the pull request.
 func (g *GithubProvider) CreatePullReviewComments(ctx context.Context, owner string, repo string, number int, comments []*github.PullRequestComment) ([]*github.PullRequestComment, error) {
 	log := util.FromContext(ctx)
@@ -639,6 +874,12 @@ func (g *GithubProvider) DeleteComment(ctx context.Context, owner string, repo s
 	return err
 }
 
+// EditComment updates the body of the issue comment.
+func (g *GithubProvider) EditComment(ctx context.Context, owner string, repo string, commentID int64, body string) error {
+	_, _, err := g.GithubClient.Issues.EditComment(ctx, owner, repo, commentID, &github.IssueComment{Body: &body})
+	return err
+}
+
 func (g *GithubProvider) CreateComment(ctx context.Context, owner string, repo string, number int, comment *Comment) (*Comment, error) {
 	log := util.FromContext(ctx)
 	c, resp, err := g.GithubClient.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{
@@ -714,12 +955,26 @@ func (g *GithubProvider) ProcessComments(ctx context.Context, a Agent, lintResul
 	}
 	log.Infof("[%s] found %d existed comments for this PR %d (%s) \n", linterName, len(existedCommentsToKeep), num, orgRepo)
 
-	toAdds, toDeletes := filterLinterOutputs(lintResults, existedCommentsToKeep)
+	// the comments with replies are retired rather than deleted to keep the discussion
+	replied := make(map[int64]bool)
+	for _, comment := range existedComments {
+		if comment.GetInReplyTo() != 0 {
+			replied[comment.GetInReplyTo()] = true
+		}
+	}
+
+	setFingerprints(a, lintResults)
+	toAdds, toDeletes, toRetires := filterLinterOutputs(lintResults, existedCommentsToKeep, replied)
 	if err := g.DeletePullReviewComments(ctx, org, repo, toDeletes); err != nil {
 		log.Errorf("failed to delete comments: %v", err)
 		return nil, err
 	}
 	log.Infof("[%s] delete %d comments for this PR %d (%s) \n", linterName, len(toDeletes), num, orgRepo)
+	if err := g.RetirePullReviewComments(ctx, org, repo, toRetires); err != nil {
+		log.Errorf("failed to retire comments: %v", err)
+		return nil, err
+	}
+	log.Infof("[%s] retire %d comments for this PR %d (%s) \n", linterName, len(toRetires), num, orgRepo)
 	comments := constructPullRequestComments(toAdds, linterNamePrefixV2(linterName), a.Provider.GetCodeReviewInfo().HeadSHA)
 	return comments, nil
 }
@@ -800,15 +1055,54 @@ func newBaseCheckRun(a Agent, lintErrs map[string][]LinterOutput) github.CreateC
 		check.Output.Summary = github.String(fmt.Sprintf("This is [the detailed log](%s).\n\n%s", logURL, Reference))
 	}
 
-	if len(annotations) > 0 {
+	switch {
+	case a.LinterConfig.FailOn != "" && HasSeverityAtLeast(lintErrs, a.LinterConfig.FailOn):
+		// only the issues of the severity opted in by the config should block the pull request
+		check.Conclusion = github.String("failure")
+	case len(annotations) > 0:
 		check.Conclusion = github.String("neutral")
-	} else {
+	default:
 		check.Conclusion = github.String("success")
 	}
 
 	return check
 }
 
+// newTimeoutCheckRun creates the check run options for a linter which is timed out.
+func newTimeoutCheckRun(a Agent, timeout time.Duration) github.CreateCheckRunOptions {
+	linterName := a.LinterConfig.Name
+	return github.CreateCheckRunOptions{
+		Name:       linterName,
+		HeadSHA:    a.Provider.GetCodeReviewInfo().HeadSHA,
+		Status:     github.String("completed"),
+		Conclusion: github.String("timed_out"),
+		StartedAt: &github.Timestamp{
+			Time: a.Provider.GetCodeReviewInfo().UpdatedAt,
+		},
+		CompletedAt: &github.Timestamp{
+			Time: time.Now(),
+		},
+		Output: &github.CheckRunOutput{
+			Title:   github.String(fmt.Sprintf("%s timed out after %v", linterName, timeout)),
+			Summary: github.String(timeoutMessage(linterName, timeout, a.GenLogViewURL()) + "\n\n" + Reference),
+		},
+	}
+}
+
+// newRepoCheckRun creates the check run options for the lint results in the whole repository.
+func newRepoCheckRun(a Agent, lintErrs map[string][]LinterOutput) github.CreateCheckRunOptions {
+	check := newBaseCheckRun(a, lintErrs)
+	// the results are not caused by the changes, so annotations on the diff are not used.
+	check.Output.Annotations = nil
+	check.Output.Title = github.String(fmt.Sprintf("%s found %d issues in the repository", a.LinterConfig.Name, countLinterErrors(lintErrs)))
+	check.Output.Text = github.String(repoScopeReport(a, lintErrs, maxCheckRunTextLen))
+	// the existing issues should not block the pull request
+	if len(lintErrs) > 0 {
+		check.Conclusion = github.String("neutral")
+	}
+	return check
+}
+
 func newMixCheckRun(a Agent, lintErrs map[string][]LinterOutput) github.CreateCheckRunOptions {
 	check := newBaseCheckRun(a, lintErrs)
 	if len(lintErrs) == 0 {
@@ -829,7 +1123,7 @@ func newMixCheckRun(a Agent, lintErrs map[string][]LinterOutput) github.CreateCh
 	b.WriteString("```text\n")
 	for file, outputs := range lintErrs {
 		for _, output := range outputs {
-			b.WriteString(fmt.Sprintf("%s:%d: %s\n", file, output.Line, output.Message))
+			b.WriteString(fmt.Sprintf("%s:%d: [%s] %s\n", file, output.Line, SeverityOf(output), output.Message))
 		}
 	}
 	b.WriteString("```\n")
@@ -850,32 +1144,38 @@ func newMixCheckRun(a Agent, lintErrs map[string][]LinterOutput) github.CreateCh
 }
 
 func newGithubHunkChecker(commitFiles []*github.CommitFile) (*FileHunkChecker, error) {
-	hunks := make(map[string][]Hunk)
+	diffs := make(map[string]*FileDiff)
 	for _, commitFile := range commitFiles {
 		if !isValidGithubCommitFile(commitFile) {
 			continue
 		}
 
-		fileHunks, err := parseGithubPatch(commitFile.GetPatch())
-		if err != nil {
-			return nil, err
+		// NOTE: GitHub omits the patch of binary files and too large diffs,
+		// keep them with no hunks so that they are only related in file mode.
+		fileDiff := &FileDiff{AddedLines: map[int]bool{}}
+		if commitFile.GetPatch() != "" {
+			var err error
+			fileDiff, err = parseGithubPatch(commitFile.GetPatch())
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		if existing, ok := hunks[commitFile.GetFilename()]; ok {
+		if existing, ok := diffs[commitFile.GetFilename()]; ok {
 			log.Warnf("duplicate commitFiles: %v, %v", commitFile, existing)
 			continue
 		}
 
-		hunks[commitFile.GetFilename()] = fileHunks
+		diffs[commitFile.GetFilename()] = fileDiff
 	}
 
-	return NewFileHunkChecker(hunks), nil
+	return NewFileDiffChecker(diffs), nil
 }
 
 func isValidGithubCommitFile(file *github.CommitFile) bool {
-	return file != nil && file.GetPatch() != "" && file.GetStatus() != "removed"
+	return file != nil && file.GetFilename() != "" && file.GetStatus() != "removed"
 }
 
-func parseGithubPatch(patch string) ([]Hunk, error) {
-	return ParsePatch(patch)
+func parseGithubPatch(patch string) (*FileDiff, error) {
+	return ParseFileDiff(patch)
 }
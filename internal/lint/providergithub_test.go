@@ -0,0 +1,162 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/qiniu/reviewbot/config"
+)
+
+// fakeIssueComments serves the issue comments API of GitHub in memory.
+type fakeIssueComments struct {
+	mu       sync.Mutex
+	nextID   int64
+	comments map[int64]string
+}
+
+func (f *fakeIssueComments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/issues/1/comments") && r.Method == http.MethodGet:
+		var comments []*github.IssueComment
+		for id := int64(1); id <= f.nextID; id++ {
+			if body, ok := f.comments[id]; ok {
+				comments = append(comments, &github.IssueComment{ID: github.Int64(id), Body: github.String(body)})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(comments)
+	case strings.HasSuffix(r.URL.Path, "/issues/1/comments") && r.Method == http.MethodPost:
+		var c github.IssueComment
+		_ = json.NewDecoder(r.Body).Decode(&c)
+		f.nextID++
+		f.comments[f.nextID] = c.GetBody()
+		c.ID = github.Int64(f.nextID)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(c)
+	case strings.Contains(r.URL.Path, "/issues/comments/"):
+		id, _ := strconv.ParseInt(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], 10, 64)
+		if r.Method == http.MethodDelete {
+			delete(f.comments, id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var c github.IssueComment
+		_ = json.NewDecoder(r.Body).Decode(&c)
+		f.comments[id] = c.GetBody()
+		c.ID = github.Int64(id)
+		_ = json.NewEncoder(w).Encode(c)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestGithubReportTimeout(t *testing.T) {
+	fake := &fakeIssueComments{comments: map[int64]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	g := &GithubProvider{
+		GithubClient: client,
+		PullRequestEvent: github.PullRequestEvent{
+			Repo:        &github.Repository{Name: github.String("reviewbot"), Owner: &github.User{Login: github.String("qiniu")}},
+			PullRequest: &github.PullRequest{Number: github.Int(1)},
+		},
+	}
+	// the comment of another linter is not touched
+	fake.nextID++
+	fake.comments[fake.nextID] = linterNamePrefixV2("other") + timeoutMessage("other", time.Minute, "") + timeoutCommentMarker
+
+	a := Agent{
+		LinterConfig:  config.Linter{Name: "golangci-lint", ReportType: config.GitHubPRReview},
+		Provider:      g,
+		GenLogViewURL: func() string { return "" },
+	}
+	ctx := context.Background()
+	for _, timeout := range []time.Duration{time.Minute, 2 * time.Minute} {
+		if err := g.ReportTimeout(ctx, a, timeout); err != nil {
+			t.Fatal(err)
+		}
+	}
+	comments, err := g.listTimeoutComments(ctx, "qiniu", "reviewbot", 1, "golangci-lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || !strings.Contains(comments[0].Body, "timed out after 2m0s") {
+		t.Fatalf("expected one updated timed out comment, got %v", comments)
+	}
+
+	// the linter finished in time
+	if err := g.deleteTimeoutComments(ctx, "qiniu", "reviewbot", 1, "golangci-lint"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.comments) != 1 || fake.comments[1] == "" {
+		t.Errorf("expected only the comment of the other linter left, got %v", fake.comments)
+	}
+}
+
+func TestCheckRunConclusion(t *testing.T) {
+	g := &GithubProvider{PullRequestEvent: github.PullRequestEvent{
+		Repo:        &github.Repository{Name: github.String("reviewbot"), Owner: &github.User{Login: github.String("qiniu")}},
+		PullRequest: &github.PullRequest{Number: github.Int(1)},
+	}}
+	errorOutputs := map[string][]LinterOutput{
+		"a.go": {{File: "a.go", Line: 1, Message: "error", Severity: config.SeverityError}},
+	}
+	infoOutputs := map[string][]LinterOutput{
+		"a.go": {{File: "a.go", Line: 1, Message: "info", Severity: config.SeverityInfo}},
+	}
+
+	tcs := []struct {
+		name     string
+		failOn   config.Severity
+		outputs  map[string][]LinterOutput
+		expected string
+	}{
+		{name: "no outputs", failOn: config.SeverityError, expected: "success"},
+		{name: "error not opted in", outputs: errorOutputs, expected: "neutral"},
+		{name: "fail on error", failOn: config.SeverityError, outputs: errorOutputs, expected: "failure"},
+		{name: "fail on warning with info", failOn: config.SeverityWarning, outputs: infoOutputs, expected: "neutral"},
+		{name: "fail on info", failOn: config.SeverityInfo, outputs: infoOutputs, expected: "failure"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Agent{
+				LinterConfig:  config.Linter{Name: "golangci-lint", FailOn: tc.failOn},
+				Provider:      g,
+				GenLogViewURL: func() string { return "" },
+			}
+			check := newBaseCheckRun(a, tc.outputs)
+			if got := check.GetConclusion(); got != tc.expected {
+				t.Errorf("expected conclusion %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
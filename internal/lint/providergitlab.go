@@ -338,6 +338,9 @@ func (g *GitlabProvider) Report(ctx context.Context, a Agent, lintResults map[st
 	num := a.Provider.GetCodeReviewInfo().Number
 	orgRepo := fmt.Sprintf("%s/%s", org, repo)
 	reportFormat := reportFormatMatCheck(g.GitLabClient, a.LinterConfig.ReportType)
+	if a.LinterConfig.Scope == config.ScopeRepo && reportFormat != config.Quiet {
+		return g.reportRepoScope(ctx, a, lintResults)
+	}
 	switch reportFormat {
 	case config.GitLabCommentAndDiscussion:
 		// list   MR  comments
@@ -348,10 +351,11 @@ func (g *GitlabProvider) Report(ctx context.Context, a Agent, lintResults map[st
 			return err
 		}
 		// filter out the comments that are not related to the linter
+		// NOTE: the diff notes belong to the discussions, which are reconciled below
 		var existedCommentsToKeep []*gitlab.Note
 		linterFlag := linterNamePrefixGitLab(linterName)
 		for _, comment := range existedComments {
-			if strings.HasPrefix(comment.Body, linterFlag) {
+			if comment.Type != gitlab.DiffNote && strings.HasPrefix(comment.Body, linterFlag) {
 				existedCommentsToKeep = append(existedCommentsToKeep, comment)
 			}
 		}
@@ -380,14 +384,22 @@ func (g *GitlabProvider) Report(ctx context.Context, a Agent, lintResults map[st
 			log.Errorf("failed to list comments: %v", err)
 			return err
 		}
-		// delete discussion that are related the linter
-		errd := DeleteMergeRequestDiscussions(ctx, g.GitLabClient, num, pid, dlist, linterFlag)
+		// reconcile the discussions that are related the linter by the fingerprints
+		topLintResults := selectTopLintResults(lintResults, a.inlineCommentsLimit())
+		setFingerprints(a, topLintResults)
+		toAdds, toDeleteDiscussions, toResolves := filterMergeRequestDiscussions(topLintResults, dlist, linterFlag)
+		errd := DeleteMergeRequestDiscussions(ctx, g.GitLabClient, num, pid, toDeleteDiscussions, linterFlag)
 		if errd != nil {
-			log.Errorf("failed to delete discussion: %v", err)
+			log.Errorf("failed to delete discussion: %v", errd)
 			return errd
 		}
+		if err := ResolveMergeRequestDiscussions(ctx, g.GitLabClient, num, pid, toResolves); err != nil {
+			log.Errorf("failed to resolve discussion: %v", err)
+			return err
+		}
+		log.Infof("%s delete %d and resolve %d discussions for this PR %d (%s) \n", linterFlag, len(toDeleteDiscussions), len(toResolves), num, orgRepo)
 		// construct discussion from lint result
-		discussion := constructMergeRequestDiscussion(lintResults, linterFlag, g.MergeRequestEvent.ObjectAttributes.LastCommit.ID, h.HeadSha, h.BaseSha, h.StartSha)
+		discussion := constructMergeRequestDiscussion(toAdds, linterFlag, g.MergeRequestEvent.ObjectAttributes.LastCommit.ID, h.HeadSha, h.BaseSha, h.StartSha)
 		if len(discussion) == 0 {
 			return nil
 		}
@@ -436,6 +448,120 @@ func (g *GitlabProvider) Report(ctx context.Context, a Agent, lintResults map[st
 	return nil
 }
 
+// reportRepoScope reports all lint results in the repository to an issue of the project,
+// since they are not related to the changes of the merge request.
+func (g *GitlabProvider) reportRepoScope(ctx context.Context, a Agent, lintResults map[string][]LinterOutput) error {
+	linterName := a.LinterConfig.Name
+	org := a.Provider.GetCodeReviewInfo().Org
+	repo := a.Provider.GetCodeReviewInfo().Repo
+	pid := g.MergeRequestEvent.ObjectAttributes.TargetProjectID
+	title := repoScopeIssueTitle(linterName, org, repo)
+	body := repoScopeReport(a, lintResults, maxIssueBodyLen)
+
+	existing, err := g.findOpenIssue(ctx, pid, title)
+	if err != nil {
+		log.Errorf("failed to list issues: %v", err)
+		return err
+	}
+
+	if existing == nil {
+		if len(lintResults) == 0 {
+			return nil
+		}
+		issue, _, err := g.GitLabClient.Issues.CreateIssue(pid, &gitlab.CreateIssueOptions{
+			Title:       gitlab.Ptr(title),
+			Description: gitlab.Ptr(body),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			log.Errorf("failed to create issue: %v", err)
+			return err
+		}
+		log.Infof("[%s] report repo scope results to issue: %v", linterName, issue.WebURL)
+		return nil
+	}
+
+	opts := &gitlab.UpdateIssueOptions{
+		Description: gitlab.Ptr(body),
+	}
+	if len(lintResults) == 0 {
+		opts.StateEvent = gitlab.Ptr("close")
+	}
+	issue, _, err := g.GitLabClient.Issues.UpdateIssue(pid, existing.IID, opts, gitlab.WithContext(ctx))
+	if err != nil {
+		log.Errorf("failed to update issue: %v", err)
+		return err
+	}
+	log.Infof("[%s] report repo scope results to issue: %v", linterName, issue.WebURL)
+	return nil
+}
+
+// findOpenIssue finds the opened issue of the project with the given title, nil if not found.
+func (g *GitlabProvider) findOpenIssue(ctx context.Context, pid int, title string) (*gitlab.Issue, error) {
+	opts := &gitlab.ListProjectIssuesOptions{
+		State:  gitlab.Ptr("opened"),
+		Search: gitlab.Ptr(title),
+		In:     gitlab.Ptr("title"),
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		issues, resp, err := g.GitLabClient.Issues.ListProjectIssues(pid, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue.Title == title {
+				return issue, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func (g *GitlabProvider) ReportTimeout(ctx context.Context, a Agent, timeout time.Duration) error {
+	linterName := a.LinterConfig.Name
+	org := a.Provider.GetCodeReviewInfo().Org
+	repo := a.Provider.GetCodeReviewInfo().Repo
+	num := a.Provider.GetCodeReviewInfo().Number
+	reportFormat := reportFormatMatCheck(g.GitLabClient, a.LinterConfig.ReportType)
+	switch reportFormat {
+	case config.GitLabComment, config.GitLabCommentAndDiscussion:
+		var pid = g.MergeRequestEvent.ObjectAttributes.TargetProjectID
+		existedComments, err := g.ListMergeRequestsComments(ctx, g.GitLabClient, org, repo, num, pid)
+		if err != nil {
+			log.Errorf("failed to list comments: %v", err)
+			return err
+		}
+		// delete the outdated comments of the linter, since they may mislead users that the linter passed
+		var toDeletes []*gitlab.Note
+		linterFlag := linterNamePrefixGitLab(linterName)
+		for _, comment := range existedComments {
+			if strings.HasPrefix(comment.Body, linterFlag) {
+				toDeletes = append(toDeletes, comment)
+			}
+		}
+		if err := DeleteMergeReviewCommentsForGitLab(ctx, g.GitLabClient, org, repo, toDeletes, pid, num); err != nil {
+			log.Errorf("failed to delete comments: %v", err)
+			return err
+		}
+		body := fmt.Sprintf("%s  %s", linterFlag, timeoutMessage(linterName, timeout, a.GenLogViewURL()))
+		if _, err := g.CreateComment(ctx, org, repo, num, &Comment{Body: body}); err != nil {
+			log.Errorf("failed to post timed out comment: %v", err)
+			return err
+		}
+		log.Infof("%s report timed out for this PR %d (%s/%s) \n", linterFlag, num, org, repo)
+	case config.Quiet:
+		return nil
+	default:
+		log.Errorf("unsupported report format: %v", a.LinterConfig.ReportType)
+	}
+	return nil
+}
+
 func CreateGitLabCommentsReport(ctx context.Context, gc *gitlab.Client, outputs map[string][]LinterOutput, lintername string, pid int, number int, logurl string) error {
 	const comentDetailHeader = "<details>"
 	const commentDetail = `
@@ -448,8 +574,8 @@ func CreateGitLabCommentsReport(ctx context.Context, gc *gitlab.Client, outputs
 	var message string
 	var errormessage string
 	var tabletop10 string
-	tabletop10 = "\n| FilePath    | Line    |ErrorMessage     |\n"
-	tabletop10 += "| -------- | -------- | -------- |\n"
+	tabletop10 = "\n| FilePath    | Line    | Severity    |ErrorMessage     |\n"
+	tabletop10 += "| -------- | -------- | -------- | -------- |\n"
 	var top10 = 0
 	var totalerrorscount int
 	totalerrorscount = 0
@@ -460,7 +586,7 @@ func CreateGitLabCommentsReport(ctx context.Context, gc *gitlab.Client, outputs
 			for _, outputmessage := range output {
 				errormessage = errormessage + "<br><code>" + outputmessage.File + ", line:" + strconv.Itoa(outputmessage.Line) + "</code> <br>&nbsp;" + outputmessage.Message
 				if top10 < 10 {
-					tabletop10 += "|" + outputmessage.File + "|" + strconv.Itoa(outputmessage.Line) + "|" + outputmessage.Message + "|\n"
+					tabletop10 += "|" + outputmessage.File + "|" + strconv.Itoa(outputmessage.Line) + "|" + string(SeverityOf(outputmessage)) + "|" + outputmessage.Message + "|\n"
 				}
 				top10++
 			}
@@ -492,8 +618,8 @@ func CreateGitLabCommentsReport(ctx context.Context, gc *gitlab.Client, outputs
 	return nil
 }
 
-func (g *GitlabProvider) IsRelated(file string, line int, startLine int) bool {
-	return g.HunkChecker.InHunk(file, line, startLine)
+func (g *GitlabProvider) IsRelated(file string, line int, startLine int, opts DiffOptions) bool {
+	return g.HunkChecker.IsRelated(file, line, startLine, opts)
 }
 
 func (g *GitlabProvider) GetFiles(predicate func(filepath string) bool) []string {
@@ -548,6 +674,81 @@ func DeleteMergeRequestDiscussions(ctx context.Context, gc *gitlab.Client, numbe
 	return nil
 }
 
+// filterMergeRequestDiscussions reconciles the linter outputs with the discussions already posted by the bot.
+// A discussion is kept if it has the same fingerprint as the output, even if the line of the output is shifted by the new commits,
+// so that the discussion on it is not lost. The nearest one is kept if more discussions have the same fingerprint.
+// The resolved discussions are matched as well, so that the issues still reported are not posted again after resolved.
+// The outputs without discussions of the same fingerprint are posted as new discussions.
+// The discussions whose issues are not reported anymore are deleted, or resolved if someone replied to keep the discussion.
+// NOTE: the discussions without fingerprint are posted by the old versions, which are always deleted as before.
+func filterMergeRequestDiscussions(outputs map[string][]LinterOutput, dlist []*gitlab.Discussion, linterFlag string) (toAdds map[string][]LinterOutput, toDeletes, toResolves []*gitlab.Discussion) {
+	var candidates []*gitlab.Discussion
+	for _, d := range dlist {
+		if len(d.Notes) == 0 || d.Notes[0].Type != gitlab.DiffNote || !strings.HasPrefix(d.Notes[0].Body, linterFlag) {
+			continue
+		}
+		if parseFingerprintMarker(d.Notes[0].Body) == "" {
+			toDeletes = append(toDeletes, d)
+			continue
+		}
+		candidates = append(candidates, d)
+	}
+
+	toAdds = make(map[string][]LinterOutput)
+	kept := make(map[string]bool)
+	for file, fileOutputs := range outputs {
+		for _, o := range fileOutputs {
+			var matched *gitlab.Discussion
+			for _, d := range candidates {
+				note := d.Notes[0]
+				if kept[d.ID] || note.Position == nil || note.Position.NewPath != file || parseFingerprintMarker(note.Body) != o.Fingerprint {
+					continue
+				}
+				if matched == nil || lineDistance(note.Position.NewLine, o.Line) < lineDistance(matched.Notes[0].Position.NewLine, o.Line) {
+					matched = d
+				}
+			}
+			if matched == nil {
+				toAdds[file] = append(toAdds[file], o)
+				continue
+			}
+			kept[matched.ID] = true
+		}
+	}
+
+	for _, d := range candidates {
+		switch {
+		case kept[d.ID], d.Notes[0].Resolved:
+		case len(d.Notes) > 1:
+			toResolves = append(toResolves, d)
+		default:
+			toDeletes = append(toDeletes, d)
+		}
+	}
+	return toAdds, toDeletes, toResolves
+}
+
+// ResolveMergeRequestDiscussions resolves the specified discussions, which are kept to preserve the replies.
+func ResolveMergeRequestDiscussions(ctx context.Context, gc *gitlab.Client, number int, pid int, dlist []*gitlab.Discussion) error {
+	for _, d := range dlist {
+		dis := d
+		err := RetryWithBackoff(ctx, func() error {
+			_, resp, err := gc.Discussions.ResolveMergeRequestDiscussion(pid, number, dis.ID, &gitlab.ResolveMergeRequestDiscussionOptions{
+				Resolved: gitlab.Ptr(true),
+			})
+			if err != nil {
+				log.Errorf("resolve mergerequest discussion failed:%v,response is:%v", err, resp)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (g *GitlabProvider) ListMergeRequestsComments(ctx context.Context, gc *gitlab.Client, owner string, repo string, number int, pid int) ([]*gitlab.Note, error) {
 	var allComments []*gitlab.Note
 	opts := gitlab.ListMergeRequestNotesOptions{
@@ -648,8 +849,8 @@ func constructMergeRequestDiscussion(linterOutputs map[string][]LinterOutput, li
 	for z := range linterOutputs {
 		for i := range linterOutputs[z] {
 			var ptype = "text"
-			message := fmt.Sprintf("%s %s\n%s",
-				linterName, linterOutputs[z][i].Message, CommentFooter)
+			message := fmt.Sprintf("%s %s %s%s%s\n%s%s",
+				linterName, severityBadge(SeverityOf(linterOutputs[z][i])), linterOutputs[z][i].Message, alsoReportedBy(linterOutputs[z][i]), gitlabSuggestion(linterOutputs[z][i]), CommentFooter, fingerprintMarker(linterOutputs[z][i].Fingerprint))
 			if linterOutputs[z][i].StartLine != 0 {
 				comments = append(comments, &gitlab.CreateMergeRequestDiscussionOptions{
 					Body:     &message,
@@ -687,33 +888,47 @@ func constructMergeRequestDiscussion(linterOutputs map[string][]LinterOutput, li
 	return comments
 }
 
+// gitlabSuggestion returns the suggested change block of the output, empty if no suggestion.
+// the discussion is placed on the last line, so the lines above are included by `-N`.
+// see https://docs.gitlab.com/ee/user/project/merge_requests/reviews/suggestions.html
+func gitlabSuggestion(output LinterOutput) string {
+	if output.Suggestion == "" {
+		return ""
+	}
+	var above int
+	if output.StartLine != 0 && output.StartLine < output.Line {
+		above = output.Line - output.StartLine
+	}
+	return fmt.Sprintf("\n\n```suggestion:-%d+0\n%s\n```", above, output.Suggestion)
+}
+
 func newGitlabHunkChecker(commitFiles []*gitlab.MergeRequestDiff) (*FileHunkChecker, error) {
-	hunks := make(map[string][]Hunk)
+	diffs := make(map[string]*FileDiff)
 	for _, commitFile := range commitFiles {
 		if !isValidGitlabCommitFile(commitFile) {
 			continue
 		}
 
-		fileHunks, err := parseGitlabPatch(commitFile.Diff)
+		fileDiff, err := parseGitlabPatch(commitFile.Diff)
 		if err != nil {
 			return nil, err
 		}
 
-		if existing, ok := hunks[commitFile.NewPath]; ok {
+		if existing, ok := diffs[commitFile.NewPath]; ok {
 			log.Warnf("duplicate commitFiles: %v, %v", commitFile, existing)
 			continue
 		}
 
-		hunks[commitFile.NewPath] = fileHunks
+		diffs[commitFile.NewPath] = fileDiff
 	}
 
-	return NewFileHunkChecker(hunks), nil
+	return NewFileDiffChecker(diffs), nil
 }
 
 func isValidGitlabCommitFile(file *gitlab.MergeRequestDiff) bool {
 	return file != nil && file.NewPath != "" && !file.DeletedFile
 }
 
-func parseGitlabPatch(patch string) ([]Hunk, error) {
-	return ParsePatch(patch)
+func parseGitlabPatch(patch string) (*FileDiff, error) {
+	return ParseFileDiff(patch)
 }
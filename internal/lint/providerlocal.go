@@ -0,0 +1,135 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/qiniu/reviewbot/internal/util"
+)
+
+// LocalProvider is a provider for running linters on a local repository without any PR/MR.
+// All files of the repository are treated as changed, and the lint results are collected instead of reported.
+// It is used to generate the baseline of the repository.
+type LocalProvider struct {
+	org   string
+	repo  string
+	files []string
+
+	mu       sync.Mutex
+	results  map[string]map[string][]LinterOutput
+	timedOut []string
+}
+
+var _ Provider = (*LocalProvider)(nil)
+
+// NewLocalProvider returns a LocalProvider for the repository with the given files.
+func NewLocalProvider(org, repo string, files []string) *LocalProvider {
+	return &LocalProvider{
+		org:     org,
+		repo:    repo,
+		files:   files,
+		results: make(map[string]map[string][]LinterOutput),
+	}
+}
+
+// Results returns the collected lint results grouped by linter name.
+func (l *LocalProvider) Results() map[string]map[string][]LinterOutput {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	results := make(map[string]map[string][]LinterOutput, len(l.results))
+	for name, r := range l.results {
+		results[name] = r
+	}
+	return results
+}
+
+// TimedOut returns the names of linters which are timed out.
+func (l *LocalProvider) TimedOut() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.timedOut...)
+}
+
+func (l *LocalProvider) IsRelated(file string, line int, startLine int, opts DiffOptions) bool {
+	return true
+}
+
+func (l *LocalProvider) HandleComments(ctx context.Context, outputs map[string][]LinterOutput) error {
+	return nil
+}
+
+func (l *LocalProvider) Report(ctx context.Context, a Agent, lintResults map[string][]LinterOutput) error {
+	log := util.FromContext(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.results[a.LinterConfig.Name] = lintResults
+	log.Infof("[%s] collected %d lint errors in %d files", a.LinterConfig.Name, countLinterErrors(lintResults), len(lintResults))
+	return nil
+}
+
+func (l *LocalProvider) ReportTimeout(ctx context.Context, a Agent, timeout time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timedOut = append(l.timedOut, a.LinterConfig.Name)
+	return nil
+}
+
+func (l *LocalProvider) GetFiles(predicate func(filepath string) bool) []string {
+	var files []string
+	for _, file := range l.files {
+		if predicate == nil || predicate(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (l *LocalProvider) GetCodeReviewInfo() CodeReview {
+	return CodeReview{
+		Org:       l.org,
+		Repo:      l.repo,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// GetToken returns empty token since there is no git provider to interact with.
+func (l *LocalProvider) GetToken() (string, error) {
+	return "", nil
+}
+
+func (l *LocalProvider) GetProviderInfo() ProviderInfo {
+	return ProviderInfo{}
+}
+
+func (l *LocalProvider) ListCommits(ctx context.Context, org, repo string, number int) ([]Commit, error) {
+	return nil, nil
+}
+
+func (l *LocalProvider) ListComments(ctx context.Context, org, repo string, number int) ([]Comment, error) {
+	return nil, nil
+}
+
+func (l *LocalProvider) DeleteComment(ctx context.Context, org, repo string, commentID int64) error {
+	return nil
+}
+
+func (l *LocalProvider) CreateComment(ctx context.Context, org, repo string, number int, comment *Comment) (*Comment, error) {
+	return comment, nil
+}
@@ -0,0 +1,364 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/xlog"
+)
+
+// The SARIF 2.1.0 objects used by reviewbot, only the fields we care about are declared.
+// see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     *sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration *sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID       string              `json:"ruleId"`
+	RuleIndex    *int                `json:"ruleIndex"`
+	Rule         *sarifRuleReference `json:"rule"`
+	Kind         string              `json:"kind"`
+	Level        string              `json:"level"`
+	Message      sarifMessage        `json:"message"`
+	Locations    []sarifLocation     `json:"locations"`
+	Fixes        []sarifFix          `json:"fixes"`
+	Suppressions []json.RawMessage   `json:"suppressions"`
+}
+
+type sarifRuleReference struct {
+	ID    string `json:"id"`
+	Index *int   `json:"index"`
+}
+
+type sarifMessage struct {
+	Text      string   `json:"text"`
+	Markdown  string   `json:"markdown"`
+	Arguments []string `json:"arguments"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarifFix struct {
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion         `json:"deletedRegion"`
+	InsertedContent *sarifArtifactBytes `json:"insertedContent"`
+}
+
+type sarifArtifactBytes struct {
+	Text string `json:"text"`
+}
+
+// SarifParse parses the SARIF 2.1.0 output of linters, such as semgrep, CodeQL, gosec, trivy and eslint.
+// Multiple SARIF documents are allowed, e.g. one for each file under $ARTIFACT.
+func SarifParse(log *xlog.Logger, output []byte) (map[string][]LinterOutput, []string) {
+	results := make(map[string][]LinterOutput)
+	docs, unexpected := splitJSONDocuments(output)
+	for _, doc := range docs {
+		var sarif sarifLog
+		if err := json.Unmarshal(doc, &sarif); err != nil {
+			log.Warnf("failed to unmarshal sarif: %v", err)
+			unexpected = append(unexpected, string(doc))
+			continue
+		}
+
+		for _, run := range sarif.Runs {
+			for _, result := range run.Results {
+				o, ok := sarifResultToOutput(run.Tool.Driver, result)
+				if !ok {
+					continue
+				}
+				results[o.File] = append(results[o.File], o)
+			}
+		}
+	}
+
+	return results, unexpected
+}
+
+func sarifResultToOutput(driver sarifDriver, result sarifResult) (LinterOutput, bool) {
+	// only the failed and not suppressed results are the issues
+	if (result.Kind != "" && result.Kind != "fail") || len(result.Suppressions) > 0 {
+		return LinterOutput{}, false
+	}
+	if len(result.Locations) == 0 || result.Locations[0].PhysicalLocation == nil {
+		return LinterOutput{}, false
+	}
+
+	location := result.Locations[0].PhysicalLocation
+	file := sarifFilePath(location.ArtifactLocation.URI)
+	if file == "" {
+		return LinterOutput{}, false
+	}
+
+	rule := driver.rule(result)
+	o := LinterOutput{
+		File:     file,
+		Line:     1,
+		Rule:     result.ruleID(rule),
+		Severity: sarifSeverity(result.Level, rule),
+	}
+
+	startLine, endLine := 1, 1
+	if region := location.Region; region != nil && region.StartLine > 0 {
+		startLine, endLine = region.StartLine, region.StartLine
+		if region.EndLine > region.StartLine {
+			endLine = region.EndLine
+		}
+		o.Column = region.StartColumn
+	}
+	o.Line = endLine
+	if endLine > startLine {
+		o.StartLine = startLine
+	}
+
+	o.Message = result.Message.format()
+	if o.Message == "" && rule != nil && rule.ShortDescription != nil {
+		o.Message = rule.ShortDescription.format()
+	}
+	if o.Rule != "" && !strings.Contains(o.Message, o.Rule) {
+		o.Message = fmt.Sprintf("%s (%s)", o.Message, o.Rule)
+	}
+
+	o.Suggestion = sarifSuggestion(result.Fixes, file, startLine, endLine)
+	return o, true
+}
+
+// rule returns the rule descriptor of the result, nil if not found.
+func (d sarifDriver) rule(result sarifResult) *sarifRule {
+	index := result.RuleIndex
+	if index == nil && result.Rule != nil {
+		index = result.Rule.Index
+	}
+	if index != nil && *index >= 0 && *index < len(d.Rules) {
+		return &d.Rules[*index]
+	}
+
+	id := result.ruleID(nil)
+	for i := range d.Rules {
+		if id != "" && d.Rules[i].ID == id {
+			return &d.Rules[i]
+		}
+	}
+	return nil
+}
+
+func (r sarifResult) ruleID(rule *sarifRule) string {
+	switch {
+	case r.RuleID != "":
+		return r.RuleID
+	case r.Rule != nil && r.Rule.ID != "":
+		return r.Rule.ID
+	case rule != nil:
+		return rule.ID
+	}
+	return ""
+}
+
+// format returns the plain text of the message with the placeholders replaced by the arguments.
+func (m sarifMessage) format() string {
+	text := m.Text
+	if text == "" {
+		text = m.Markdown
+	}
+	for i, arg := range m.Arguments {
+		text = strings.ReplaceAll(text, "{"+strconv.Itoa(i)+"}", arg)
+	}
+	return strings.TrimSpace(text)
+}
+
+// sarifSeverity converts the SARIF level into severity.
+// the level of the rule configuration is used if the result has no level, and warning is the default.
+func sarifSeverity(level string, rule *sarifRule) config.Severity {
+	if level == "" && rule != nil && rule.DefaultConfiguration != nil {
+		level = rule.DefaultConfiguration.Level
+	}
+
+	switch level {
+	case "error":
+		return config.SeverityError
+	case "note", "none":
+		return config.SeverityInfo
+	default:
+		return config.SeverityWarning
+	}
+}
+
+// sarifFilePath converts the artifact uri into the file path.
+func sarifFilePath(uri string) string {
+	if !strings.HasPrefix(uri, "file:") {
+		if p, err := url.PathUnescape(uri); err == nil {
+			return p
+		}
+		return uri
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	if u.Path == "" {
+		// relative file uri, e.g. file:pkg/a.go
+		return u.Opaque
+	}
+	return u.Path
+}
+
+// sarifSuggestion returns the replacement of the first fix if it replaces exactly the whole lines between startLine and endLine,
+// which is the only kind of fix that can be presented as a suggestion on the PR/MR.
+func sarifSuggestion(fixes []sarifFix, file string, startLine, endLine int) string {
+	if len(fixes) == 0 || len(fixes[0].ArtifactChanges) != 1 {
+		return ""
+	}
+
+	change := fixes[0].ArtifactChanges[0]
+	if len(change.Replacements) != 1 || sarifFilePath(change.ArtifactLocation.URI) != file {
+		return ""
+	}
+
+	replacement := change.Replacements[0]
+	region := replacement.DeletedRegion
+	start, end := region.StartLine, region.EndLine
+	if end == 0 {
+		end = start
+	}
+	switch {
+	case region.StartColumn > 1:
+		return ""
+	case region.EndColumn == 1 && end > start:
+		// the end position is exclusive, e.g. the beginning of the next line
+		end--
+	case region.EndColumn > 0:
+		// part of the line is replaced, which can not be presented as a suggestion
+		return ""
+	}
+	if start != startLine || end != endLine {
+		return ""
+	}
+
+	var text string
+	if replacement.InsertedContent != nil {
+		text = replacement.InsertedContent.Text
+	}
+	return strings.TrimSuffix(text, "\n")
+}
+
+// splitJSONDocuments finds all the top-level JSON objects in the output,
+// the other non-empty lines are returned as unexpected.
+func splitJSONDocuments(output []byte) (docs []json.RawMessage, unexpected []string) {
+	for len(output) > 0 {
+		idx := bytes.IndexByte(output, '{')
+		if idx == -1 {
+			return docs, append(unexpected, unexpectedLines(output)...)
+		}
+		unexpected = append(unexpected, unexpectedLines(output[:idx])...)
+		output = output[idx:]
+
+		dec := json.NewDecoder(bytes.NewReader(output))
+		var doc json.RawMessage
+		if err := dec.Decode(&doc); err != nil {
+			// not a json document, skip the line
+			end := bytes.IndexByte(output, '\n')
+			if end == -1 {
+				return docs, append(unexpected, unexpectedLines(output)...)
+			}
+			unexpected = append(unexpected, unexpectedLines(output[:end])...)
+			output = output[end+1:]
+			continue
+		}
+
+		docs = append(docs, doc)
+		output = output[dec.InputOffset():]
+	}
+	return docs, unexpected
+}
+
+// unexpectedLines returns the non-empty lines which are not the artifact headers.
+func unexpectedLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isArtifactHeader(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// isArtifactHeader reports whether the line is the header added by the docker runner
+// when combining the files under $ARTIFACT, such as `---artifacts-xxx/lint.sarif---`.
+func isArtifactHeader(line string) bool {
+	return len(line) > 6 && strings.HasPrefix(line, "---") && strings.HasSuffix(line, "---")
+}
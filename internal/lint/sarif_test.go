@@ -0,0 +1,151 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/x/xlog"
+)
+
+func TestSarifParse(t *testing.T) {
+	tcs := []struct {
+		name       string
+		input      string
+		expected   map[string][]LinterOutput
+		unexpected []string
+	}{
+		{
+			name: "semgrep with rules and fixes",
+			input: `{
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "semgrep",
+          "rules": [
+            {"id": "go.lang.security.audit.md5", "defaultConfiguration": {"level": "error"}},
+            {"id": "go.lang.style.useless-if", "shortDescription": {"text": "useless if"}, "defaultConfiguration": {"level": "note"}}
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "go.lang.security.audit.md5",
+          "ruleIndex": 0,
+          "message": {"text": "Detected MD5 hash algorithm"},
+          "locations": [{"physicalLocation": {"artifactLocation": {"uri": "pkg/hash.go"}, "region": {"startLine": 10, "startColumn": 2, "endLine": 10, "endColumn": 20}}}]
+        },
+        {
+          "ruleId": "go.lang.style.useless-if",
+          "ruleIndex": 1,
+          "message": {"text": ""},
+          "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///src/pkg/if.go"}, "region": {"startLine": 3, "endLine": 5}}}],
+          "fixes": [
+            {
+              "artifactChanges": [
+                {
+                  "artifactLocation": {"uri": "file:///src/pkg/if.go"},
+                  "replacements": [{"deletedRegion": {"startLine": 3, "startColumn": 1, "endLine": 6, "endColumn": 1}, "insertedContent": {"text": "\tdoSomething()\n"}}]
+                }
+              ]
+            }
+          ]
+        },
+        {
+          "ruleId": "go.lang.style.useless-if",
+          "level": "warning",
+          "message": {"text": "suppressed"},
+          "suppressions": [{"kind": "inSource"}],
+          "locations": [{"physicalLocation": {"artifactLocation": {"uri": "pkg/if.go"}, "region": {"startLine": 8}}}]
+        }
+      ]
+    }
+  ]
+}`,
+			expected: map[string][]LinterOutput{
+				"pkg/hash.go": {
+					{
+						File:     "pkg/hash.go",
+						Line:     10,
+						Column:   2,
+						Message:  "Detected MD5 hash algorithm (go.lang.security.audit.md5)",
+						Severity: config.SeverityError,
+						Rule:     "go.lang.security.audit.md5",
+					},
+				},
+				"/src/pkg/if.go": {
+					{
+						File:       "/src/pkg/if.go",
+						Line:       5,
+						StartLine:  3,
+						Message:    "useless if (go.lang.style.useless-if)",
+						Severity:   config.SeverityInfo,
+						Rule:       "go.lang.style.useless-if",
+						Suggestion: "\tdoSomething()",
+					},
+				},
+			},
+		},
+		{
+			name: "multiple documents from artifacts",
+			input: `---artifacts-xxx/a.sarif---
+{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"gosec"}},"results":[{"ruleId":"G104","level":"warning","message":{"text":"Errors unhandled."},"locations":[{"physicalLocation":{"artifactLocation":{"uri":"main.go"},"region":{"startLine":7,"startColumn":3}}}]}]}]}
+---artifacts-xxx/b.sarif---
+some noise
+{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"trivy"}},"results":[{"ruleId":"CVE-2024-0001","message":{"text":"Package {0} is vulnerable","arguments":["foo"]},"locations":[{"physicalLocation":{"artifactLocation":{"uri":"go.mod"}}}]}]}]}
+`,
+			expected: map[string][]LinterOutput{
+				"main.go": {
+					{
+						File:     "main.go",
+						Line:     7,
+						Column:   3,
+						Message:  "Errors unhandled. (G104)",
+						Severity: config.SeverityWarning,
+						Rule:     "G104",
+					},
+				},
+				"go.mod": {
+					{
+						File:     "go.mod",
+						Line:     1,
+						Message:  "Package foo is vulnerable (CVE-2024-0001)",
+						Severity: config.SeverityWarning,
+						Rule:     "CVE-2024-0001",
+					},
+				},
+			},
+			unexpected: []string{"some noise"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, unexpected := SarifParse(xlog.New("ut"), []byte(tc.input))
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+			if !reflect.DeepEqual(unexpected, tc.unexpected) {
+				t.Errorf("expected unexpected %v, got %v", tc.unexpected, unexpected)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxCheckRunTextLen is the max length of the text of a GitHub check run output.
+	maxCheckRunTextLen = 65535
+	// maxIssueBodyLen is the max length of the body of a GitHub issue, also used for GitLab issues.
+	maxIssueBodyLen = 65536
+)
+
+// repoScopeIssueTitle returns the title of the issue to report the repo scope results of the linter.
+// The title is used to find the existing issue, so it should be stable.
+func repoScopeIssueTitle(linterName, org, repo string) string {
+	return fmt.Sprintf("[reviewbot] %s issues in %s/%s", linterName, org, repo)
+}
+
+// repoScopeReport returns the markdown report of all lint results in the repository, truncated to maxLen.
+func repoScopeReport(a Agent, lintResults map[string][]LinterOutput, maxLen int) string {
+	var header strings.Builder
+	linterName := a.LinterConfig.Name
+	header.WriteString(fmt.Sprintf("## 🔍 %s found %d issues in the repository\n\n", linterName, countLinterErrors(lintResults)))
+	info := a.Provider.GetCodeReviewInfo()
+	if info.HeadSHA != "" {
+		header.WriteString(fmt.Sprintf("Scanned at commit %s", info.HeadSHA))
+		if info.URL != "" {
+			header.WriteString(fmt.Sprintf(" of %s", info.URL))
+		}
+		header.WriteString(".\n\n")
+	}
+	if logURL := a.GenLogViewURL(); logURL != "" {
+		header.WriteString(fmt.Sprintf("This is [the detailed log](%s).\n\n", logURL))
+	}
+	if len(lintResults) == 0 {
+		return header.String()
+	}
+
+	files := make([]string, 0, len(lintResults))
+	for file := range lintResults {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var lines []string
+	for _, file := range files {
+		outputs := append([]LinterOutput(nil), lintResults[file]...)
+		sort.SliceStable(outputs, func(i, j int) bool {
+			return outputs[i].Line < outputs[j].Line
+		})
+		for _, output := range outputs {
+			lines = append(lines, fmt.Sprintf("%s:%d: [%s] %s\n", file, output.Line, SeverityOf(output), output.Message))
+		}
+	}
+
+	const (
+		codeStart = "```text\n"
+		codeEnd   = "```\n"
+	)
+	var b strings.Builder
+	b.WriteString(header.String())
+	b.WriteString(codeStart)
+	for i, line := range lines {
+		truncated := fmt.Sprintf("```\n\n... and %d more issues, see the detailed log for all of them.\n", len(lines)-i)
+		if b.Len()+len(line)+len(truncated) > maxLen {
+			b.WriteString(truncated)
+			return b.String()
+		}
+		b.WriteString(line)
+	}
+	b.WriteString(codeEnd)
+	return b.String()
+}
@@ -0,0 +1,109 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/qiniu/reviewbot/config"
+)
+
+func TestRepoScopeReport(t *testing.T) {
+	a := Agent{
+		LinterConfig:  config.Linter{Name: "golangci-lint"},
+		Provider:      NewLocalProvider("qiniu", "reviewbot", nil),
+		GenLogViewURL: func() string { return "https://example.com/log" },
+	}
+	results := map[string][]LinterOutput{
+		"b.go": {{File: "b.go", Line: 2, Message: "b2"}, {File: "b.go", Line: 1, Message: "b1", Severity: config.SeverityError}},
+		"a.go": {{File: "a.go", Line: 3, Message: "a3"}},
+	}
+
+	report := repoScopeReport(a, results, maxIssueBodyLen)
+	expectedLines := "a.go:3: [warning] a3\nb.go:1: [error] b1\nb.go:2: [warning] b2\n"
+	if !strings.Contains(report, "golangci-lint found 3 issues in the repository") {
+		t.Errorf("unexpected header: %s", report)
+	}
+	if !strings.Contains(report, expectedLines) {
+		t.Errorf("expected sorted issues %q in report: %s", expectedLines, report)
+	}
+	if !strings.Contains(report, "https://example.com/log") {
+		t.Errorf("expected log url in report: %s", report)
+	}
+
+	many := make(map[string][]LinterOutput)
+	for i := 0; i < 1000; i++ {
+		file := fmt.Sprintf("pkg/file_%04d.go", i)
+		many[file] = []LinterOutput{{File: file, Line: 1, Message: strings.Repeat("x", 100)}}
+	}
+	truncated := repoScopeReport(a, many, 4096)
+	if len(truncated) > 4096 {
+		t.Errorf("expected report truncated to 4096, got %d", len(truncated))
+	}
+	if !strings.Contains(truncated, "more issues, see the detailed log") {
+		t.Errorf("expected truncation note in report: %s", truncated)
+	}
+}
+
+func TestFiltersWithScope(t *testing.T) {
+	p, err := NewGithubProvider(context.TODO(), nil, github.PullRequestEvent{}, WithPullRequestChangedFiles([]*github.CommitFile{
+		{
+			Filename: github.String("changed.go"),
+			Patch:    github.String("@@ -1,2 +1,2 @@\n-a\n+b\n c"),
+		},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create github provider: %v", err)
+	}
+
+	tcs := []struct {
+		scope    config.Scope
+		expected map[string]int
+	}{
+		{scope: config.ScopeDiff, expected: map[string]int{"changed.go": 1}},
+		{scope: config.ScopeFiles, expected: map[string]int{"changed.go": 2}},
+		{scope: config.ScopeRepo, expected: map[string]int{"changed.go": 2, "untouched.go": 1}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(string(tc.scope), func(t *testing.T) {
+			input := map[string][]LinterOutput{
+				"changed.go":   {{File: "changed.go", Line: 1, Message: "added"}, {File: "changed.go", Line: 10, Message: "untouched line"}},
+				"untouched.go": {{File: "untouched.go", Line: 1, Message: "untouched file"}},
+			}
+			results, err := Filters(nil, Agent{
+				Provider:     p,
+				LinterConfig: config.Linter{Scope: tc.scope},
+			}, input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != len(tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, results)
+			}
+			for file, count := range tc.expected {
+				if len(results[file]) != count {
+					t.Errorf("expected %d results of %s, got %v", count, file, results[file])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"strings"
+
+	"github.com/qiniu/reviewbot/config"
+)
+
+// ParseSeverity converts the level reported by a linter into config.Severity.
+// It understands the common spellings used by the supported linters, such as
+// gcc-style "error"/"warning"/"note" and cppcheck's "style"/"performance"/"portability".
+// Empty severity is returned if the level is unknown.
+func ParseSeverity(level string) config.Severity {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "error", "fatal", "critical", "high", "blocker":
+		return config.SeverityError
+	case "warning", "warn", "medium", "major":
+		return config.SeverityWarning
+	case "info", "information", "note", "low", "minor":
+		return config.SeverityInfo
+	case "suggestion", "hint", "style", "performance", "portability":
+		return config.SeveritySuggest
+	}
+	return ""
+}
+
+// TrimSeverityPrefix splits the leading "<level>: " of a message, which is common in gcc-style outputs.
+// The message is returned unchanged with empty severity if no known level is found.
+func TrimSeverityPrefix(msg string) (string, config.Severity) {
+	level, rest, ok := strings.Cut(msg, ": ")
+	if !ok {
+		return msg, ""
+	}
+	severity := ParseSeverity(level)
+	if severity == "" {
+		return msg, ""
+	}
+	return strings.TrimSpace(rest), severity
+}
+
+// SeverityOf returns the severity of the output, defaults to warning.
+func SeverityOf(o LinterOutput) config.Severity {
+	if o.Severity == "" {
+		return config.SeverityWarning
+	}
+	return o.Severity
+}
+
+// HasSeverityAtLeast reports whether any output is at least as severe as the given severity.
+func HasSeverityAtLeast(lintResults map[string][]LinterOutput, severity config.Severity) bool {
+	for _, outputs := range lintResults {
+		for _, o := range outputs {
+			if severityRank(SeverityOf(o)) >= severityRank(severity) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// severityBadge returns the markdown badge of the severity used in comments.
+func severityBadge(severity config.Severity) string {
+	switch severity {
+	case config.SeverityError:
+		return "🔴 **error**"
+	case config.SeverityInfo:
+		return "🔵 **info**"
+	case config.SeveritySuggest:
+		return "💡 **suggestion**"
+	default:
+		return "🟠 **warning**"
+	}
+}
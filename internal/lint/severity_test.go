@@ -0,0 +1,108 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+)
+
+func TestTrimSeverityPrefix(t *testing.T) {
+	tcs := []struct {
+		input    string
+		message  string
+		severity config.Severity
+	}{
+		{
+			input:    "warning: Double quote to prevent globbing and word splitting. [SC2086]",
+			message:  "Double quote to prevent globbing and word splitting. [SC2086]",
+			severity: config.SeverityWarning,
+		},
+		{
+			input:    "note: Use ./*glob* or -- *glob* so names with dashes won't become options. [SC2035]",
+			message:  "Use ./*glob* or -- *glob* so names with dashes won't become options. [SC2035]",
+			severity: config.SeverityInfo,
+		},
+		{
+			input:    "performance: Function parameter 's' should be passed by const reference.",
+			message:  "Function parameter 's' should be passed by const reference.",
+			severity: config.SeveritySuggest,
+		},
+		{
+			input:    "error: Array 'a[10]' accessed at index 10, which is out of bounds.",
+			message:  "Array 'a[10]' accessed at index 10, which is out of bounds.",
+			severity: config.SeverityError,
+		},
+		{
+			input:    "printf: fmt.Sprintf format %d has arg x of wrong type string (govet)",
+			message:  "printf: fmt.Sprintf format %d has arg x of wrong type string (govet)",
+			severity: "",
+		},
+		{
+			input:    "no level here",
+			message:  "no level here",
+			severity: "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.input, func(t *testing.T) {
+			message, severity := TrimSeverityPrefix(tc.input)
+			if message != tc.message {
+				t.Errorf("expected message %q, got %q", tc.message, message)
+			}
+			if severity != tc.severity {
+				t.Errorf("expected severity %q, got %q", tc.severity, severity)
+			}
+		})
+	}
+}
+
+func TestToGithubCheckRunAnnotations(t *testing.T) {
+	outputs := map[string][]LinterOutput{
+		"a.go": {
+			{File: "a.go", Line: 1, Message: "default"},
+			{File: "a.go", Line: 2, Message: "error", Severity: config.SeverityError},
+			{File: "a.go", Line: 3, Message: "info", Severity: config.SeverityInfo},
+			{File: "a.go", Line: 4, Message: "suggestion", Severity: config.SeveritySuggest},
+		},
+	}
+	expected := map[string]string{
+		"default":    "warning",
+		"error":      "failure",
+		"info":       "notice",
+		"suggestion": "notice",
+	}
+
+	annotations := toGithubCheckRunAnnotations(outputs)
+	if len(annotations) != len(expected) {
+		t.Fatalf("expected %d annotations, got %d", len(expected), len(annotations))
+	}
+	for _, annotation := range annotations {
+		if got := annotation.GetAnnotationLevel(); got != expected[annotation.GetMessage()] {
+			t.Errorf("expected level %s for %s, got %s", expected[annotation.GetMessage()], annotation.GetMessage(), got)
+		}
+	}
+	if !HasSeverityAtLeast(outputs, config.SeverityError) {
+		t.Errorf("expected error severity found")
+	}
+	outputs = map[string][]LinterOutput{"a.go": outputs["a.go"][2:]}
+	if HasSeverityAtLeast(outputs, config.SeverityWarning) {
+		t.Errorf("expected no severity at least warning found, got %v", outputs)
+	}
+}
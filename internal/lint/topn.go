@@ -0,0 +1,191 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"math"
+	"sort"
+
+	"github.com/qiniu/reviewbot/config"
+)
+
+// defaultMixInlineComments is the default max inline comments of a linter in github_mix mode.
+const defaultMixInlineComments = 10
+
+// InlineCommentsLimit returns the max inline comments the linter posts according to its config.
+// math.MaxInt means no limit.
+func InlineCommentsLimit(linter config.Linter) int {
+	if linter.MaxInlineComments > 0 {
+		return linter.MaxInlineComments
+	}
+	if linter.ReportType == config.GitHubMixType {
+		return defaultMixInlineComments
+	}
+	return math.MaxInt
+}
+
+// inlineCommentsLimit returns the max inline comments the linter posts in the PR/MR,
+// which is limited by both the linter config and the budget of the PR/MR.
+func (a Agent) inlineCommentsLimit() int {
+	limit := InlineCommentsLimit(a.LinterConfig)
+	if a.InlineCommentsBudget != nil && *a.InlineCommentsBudget < limit {
+		limit = *a.InlineCommentsBudget
+	}
+	return limit
+}
+
+// postsInlineComments reports whether the linter reports the results as inline comments of the PR/MR.
+func postsInlineComments(linter config.Linter) bool {
+	if linter.Scope == config.ScopeRepo {
+		return false
+	}
+	switch linter.ReportType {
+	case config.GitHubPRReview, config.GitHubMixType, config.GitLabCommentAndDiscussion:
+		return true
+	default:
+		return false
+	}
+}
+
+// rankedOutput is a lint output to be ranked.
+type rankedOutput struct {
+	linter string
+	file   string
+	output LinterOutput
+	// occurrence is the number of outputs of the same rule ranked before it.
+	occurrence int
+}
+
+// rankOutputs sorts the outputs by priority, the most important first:
+//  1. severity, higher first
+//  2. issue reference match, the outputs matched by the issue references first
+//  3. rule diversity, the first output of each rule first
+//  4. file, line and message order
+//
+// The order doesn't depend on the map iteration, so reruns report the same results.
+func rankOutputs(items []rankedOutput) {
+	less := func(a, b rankedOutput) bool {
+		if ra, rb := severityRank(SeverityOf(a.output)), severityRank(SeverityOf(b.output)); ra != rb {
+			return ra > rb
+		}
+		if ra, rb := hasIssueReference(a.output), hasIssueReference(b.output); ra != rb {
+			return ra
+		}
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		if a.output.Line != b.output.Line {
+			return a.output.Line < b.output.Line
+		}
+		if a.output.Message != b.output.Message {
+			return a.output.Message < b.output.Message
+		}
+		return a.linter < b.linter
+	}
+	sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+
+	seen := make(map[string]int)
+	for i := range items {
+		key := items[i].linter + "\x00" + ruleKey(items[i].output)
+		items[i].occurrence = seen[key]
+		seen[key]++
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if ra, rb := severityRank(SeverityOf(a.output)), severityRank(SeverityOf(b.output)); ra != rb {
+			return ra > rb
+		}
+		if ra, rb := hasIssueReference(a.output), hasIssueReference(b.output); ra != rb {
+			return ra
+		}
+		return a.occurrence < b.occurrence
+	})
+}
+
+// hasIssueReference reports whether the output is matched by the issue references,
+// whose typed message is set before reporting.
+func hasIssueReference(o LinterOutput) bool {
+	return o.TypedMessage != ""
+}
+
+// ruleKey returns the rule of the output, or the normalized message if the linter doesn't report rules.
+func ruleKey(o LinterOutput) string {
+	if o.Rule != "" {
+		return o.Rule
+	}
+	return normalizeMessage(o.Message)
+}
+
+// flattenOutputs returns the outputs of the linter to be ranked.
+func flattenOutputs(linterName string, lintResults map[string][]LinterOutput) []rankedOutput {
+	var items []rankedOutput
+	for file, outputs := range lintResults {
+		for _, o := range outputs {
+			items = append(items, rankedOutput{linter: linterName, file: file, output: o})
+		}
+	}
+	return items
+}
+
+// selectTopLintResults returns the top n lint results by priority, see rankOutputs for details.
+func selectTopLintResults(lintResults map[string][]LinterOutput, n int) map[string][]LinterOutput {
+	items := flattenOutputs("", lintResults)
+	if n >= len(items) {
+		return lintResults
+	}
+	rankOutputs(items)
+
+	result := make(map[string][]LinterOutput)
+	for _, item := range items[:n] {
+		result[item.file] = append(result[item.file], item.output)
+	}
+	return result
+}
+
+// allocateInlineComments allocates the inline comments budget of the PR/MR to the linters,
+// the most important results across linters are selected, see rankOutputs for details.
+func allocateInlineComments(reports []pendingReport, budget int) {
+	if budget <= 0 {
+		return
+	}
+
+	var items []rankedOutput
+	for _, r := range reports {
+		if !postsInlineComments(r.agent.LinterConfig) {
+			continue
+		}
+		top := selectTopLintResults(r.results, InlineCommentsLimit(r.agent.LinterConfig))
+		items = append(items, flattenOutputs(r.agent.LinterConfig.Name, top)...)
+	}
+	rankOutputs(items)
+	if len(items) > budget {
+		items = items[:budget]
+	}
+
+	allocated := make(map[string]int)
+	for _, item := range items {
+		allocated[item.linter]++
+	}
+	for i := range reports {
+		if !postsInlineComments(reports[i].agent.LinterConfig) {
+			continue
+		}
+		n := allocated[reports[i].agent.LinterConfig.Name]
+		reports[i].agent.InlineCommentsBudget = &n
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lint
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+)
+
+func TestSelectTopLintResults(t *testing.T) {
+	tcs := []struct {
+		name    string
+		results map[string][]LinterOutput
+		n       int
+		want    map[string][]LinterOutput
+	}{
+		{
+			name: "less than n",
+			results: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 1, Message: "m1"}},
+			},
+			n: 10,
+			want: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 1, Message: "m1"}},
+			},
+		},
+		{
+			name: "severity first",
+			results: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 1, Message: "m1"}},
+				"b.go": {{File: "b.go", Line: 1, Message: "m2", Severity: config.SeverityError}},
+				"c.go": {{File: "c.go", Line: 1, Message: "m3", Severity: config.SeverityInfo}},
+			},
+			n: 2,
+			want: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 1, Message: "m1"}},
+				"b.go": {{File: "b.go", Line: 1, Message: "m2", Severity: config.SeverityError}},
+			},
+		},
+		{
+			name: "issue reference before file order",
+			results: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 1, Message: "m1"}},
+				"b.go": {{File: "b.go", Line: 1, Message: "m2", TypedMessage: "m2 typed"}},
+			},
+			n: 1,
+			want: map[string][]LinterOutput{
+				"b.go": {{File: "b.go", Line: 1, Message: "m2", TypedMessage: "m2 typed"}},
+			},
+		},
+		{
+			name: "rule diversity",
+			results: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 3, Message: "m3", Rule: "ST1000"},
+					{File: "a.go", Line: 1, Message: "m1", Rule: "ST1000"},
+					{File: "a.go", Line: 2, Message: "m2", Rule: "ST1000"},
+				},
+				"b.go": {{File: "b.go", Line: 9, Message: "unused variable x", Rule: "U1000"}},
+			},
+			n: 2,
+			want: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 1, Message: "m1", Rule: "ST1000"}},
+				"b.go": {{File: "b.go", Line: 9, Message: "unused variable x", Rule: "U1000"}},
+			},
+		},
+		{
+			name: "messages as rules if no rule",
+			results: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 2, Message: "line too long (130 > 120)"},
+					{File: "a.go", Line: 1, Message: "line too long (125 > 120)"},
+					{File: "a.go", Line: 5, Message: "missing comment"},
+				},
+			},
+			n: 2,
+			want: map[string][]LinterOutput{
+				"a.go": {
+					{File: "a.go", Line: 1, Message: "line too long (125 > 120)"},
+					{File: "a.go", Line: 5, Message: "missing comment"},
+				},
+			},
+		},
+		{
+			name: "zero",
+			results: map[string][]LinterOutput{
+				"a.go": {{File: "a.go", Line: 1, Message: "m1"}},
+			},
+			n:    0,
+			want: map[string][]LinterOutput{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			// the selection must be stable across runs
+			for i := 0; i < 10; i++ {
+				got := selectTopLintResults(tc.results, tc.n)
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestInlineCommentsLimit(t *testing.T) {
+	budget := 3
+	tcs := []struct {
+		name  string
+		agent Agent
+		want  int
+	}{
+		{
+			name:  "github_mix default",
+			agent: Agent{LinterConfig: config.Linter{ReportType: config.GitHubMixType}},
+			want:  defaultMixInlineComments,
+		},
+		{
+			name:  "github_pr_review default",
+			agent: Agent{LinterConfig: config.Linter{ReportType: config.GitHubPRReview}},
+			want:  math.MaxInt,
+		},
+		{
+			name:  "configured",
+			agent: Agent{LinterConfig: config.Linter{ReportType: config.GitHubMixType, MaxInlineComments: 5}},
+			want:  5,
+		},
+		{
+			name:  "limited by budget",
+			agent: Agent{LinterConfig: config.Linter{ReportType: config.GitHubMixType, MaxInlineComments: 5}, InlineCommentsBudget: &budget},
+			want:  3,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.agent.inlineCommentsLimit(); got != tc.want {
+				t.Errorf("expected %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAllocateInlineComments(t *testing.T) {
+	newReport := func(name string, reportType config.ReportType, results map[string][]LinterOutput) pendingReport {
+		return pendingReport{agent: Agent{LinterConfig: config.Linter{Name: name, ReportType: reportType}}, results: results}
+	}
+	reports := []pendingReport{
+		newReport("golangci-lint", config.GitHubMixType, map[string][]LinterOutput{
+			"a.go": {
+				{File: "a.go", Line: 1, Message: "m1", Severity: config.SeverityError},
+				{File: "a.go", Line: 2, Message: "m2"},
+			},
+		}),
+		newReport("gosec", config.GitHubPRReview, map[string][]LinterOutput{
+			"b.go": {
+				{File: "b.go", Line: 1, Message: "m3", Severity: config.SeverityError},
+				{File: "b.go", Line: 2, Message: "m4", Severity: config.SeverityInfo},
+			},
+		}),
+		newReport("shellcheck", config.GitHubCheckRuns, map[string][]LinterOutput{
+			"c.sh": {{File: "c.sh", Line: 1, Message: "m5", Severity: config.SeverityError}},
+		}),
+	}
+
+	allocateInlineComments(reports, 3)
+
+	want := map[string]int{"golangci-lint": 2, "gosec": 1}
+	for _, r := range reports {
+		n, ok := want[r.agent.LinterConfig.Name]
+		if !ok {
+			if r.agent.InlineCommentsBudget != nil {
+				t.Errorf("expected no budget for %s, got %d", r.agent.LinterConfig.Name, *r.agent.InlineCommentsBudget)
+			}
+			continue
+		}
+		if r.agent.InlineCommentsBudget == nil || *r.agent.InlineCommentsBudget != n {
+			t.Errorf("expected budget %d for %s, got %v", n, r.agent.LinterConfig.Name, r.agent.InlineCommentsBudget)
+		}
+	}
+}
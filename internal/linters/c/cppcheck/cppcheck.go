@@ -40,7 +40,7 @@ func cppcheckHandler(ctx context.Context, a lint.Agent) error {
 		// In exhaustive mode, Cppcheck performs additional inspection rules and more complex analysis, potentially uncovering issues that may not be detected in the default normal mode.
 		// However, this mode comes at the cost of longer execution times, making it suitable for scenarios where higher code quality is desired and longer waiting times are acceptable.
 		// From version 2.14, the linter will prompt: "Limiting analysis of branches. Use --check-level=exhaustive to analyze all branches."
-		a.LinterConfig.Args = append([]string{}, "--quiet", "--check-level=exhaustive", "--template='{file}:{line}:{column}: {message}'", ".")
+		a.LinterConfig.Args = append([]string{}, "--quiet", "--check-level=exhaustive", "--template='{file}:{line}:{column}: {severity}: {message}'", ".")
 	}
 
 	return lint.GeneralHandler(ctx, log, a, lint.ExecRun, parser)
@@ -55,7 +55,14 @@ func parser(log *xlog.Logger, input []byte) (map[string][]lint.LinterOutput, []s
 		// remove the first and last character of the line,
 		// which are the single quotes
 		line = line[1 : len(line)-1]
-		return lint.GeneralLineParser(line)
+		output, err := lint.GeneralLineParser(line)
+		if err != nil || output == nil {
+			return output, err
+		}
+
+		// the severity is only available with the default template, see cppcheckHandler
+		output.Message, output.Severity = lint.TrimSeverityPrefix(output.Message)
+		return output, nil
 	}
 	return lint.Parse(log, input, lineParser)
 }
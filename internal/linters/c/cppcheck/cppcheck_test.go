@@ -20,6 +20,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/reviewbot/internal/lint"
 	"github.com/qiniu/x/xlog"
 )
@@ -44,6 +45,21 @@ func TestParser(t *testing.T) {
 			},
 			unexpected: nil,
 		},
+		{
+			input: "'cppcheck_test.c:9:5: style: Variable 'b' is assigned a value that is never used.'",
+			expected: map[string][]lint.LinterOutput{
+				"cppcheck_test.c": {
+					{
+						File:     "cppcheck_test.c",
+						Line:     9,
+						Column:   5,
+						Message:  "Variable 'b' is assigned a value that is never used.",
+						Severity: config.SeveritySuggest,
+					},
+				},
+			},
+			unexpected: nil,
+		},
 		{
 			input:      "''",
 			expected:   map[string][]lint.LinterOutput{},
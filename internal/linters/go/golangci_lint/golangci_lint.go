@@ -25,6 +25,7 @@ import (
 
 	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/reviewbot/internal/lint"
+	"github.com/qiniu/reviewbot/internal/linters/go/staticcheck"
 	"github.com/qiniu/reviewbot/internal/util"
 	"github.com/qiniu/x/log"
 	"github.com/qiniu/x/xlog"
@@ -36,6 +37,7 @@ var lintName = "golangci-lint"
 func init() {
 	lint.RegisterPullRequestHandler(lintName, golangciLintHandler)
 	lint.RegisterLinterLanguages(lintName, []string{".go", ".mod", ".sum"})
+	lint.RegisterLinterFilters(lintName, staticcheck.GoZeroTagFilter)
 }
 
 func golangciLintHandler(ctx context.Context, a lint.Agent) error {
@@ -96,6 +98,10 @@ func (g *gitConfigModifier) Modify(cfg *config.Linter) (*config.Linter, error) {
 	if err != nil {
 		return nil, err
 	}
+	// no token means there is no git provider to interact with, like running locally, nothing to configure.
+	if token == "" {
+		return newCfg, nil
+	}
 
 	info := g.provider.GetProviderInfo()
 	var gitUsername string
@@ -199,6 +205,7 @@ func parser(log *xlog.Logger, output []byte) (map[string][]lint.LinterOutput, []
 			return nil, []string{strings.TrimSpace(unexpected)}
 		}
 
+		o.Severity = severity(o.Message)
 		return &o, []string{}
 	}
 
@@ -233,6 +240,57 @@ func parser(log *xlog.Logger, output []byte) (map[string][]lint.LinterOutput, []
 	return rawResults, unexpected
 }
 
+var (
+	// errorLinters are the golangci-lint linters whose issues are most likely real bugs.
+	errorLinters = map[string]bool{
+		"govet":         true,
+		"staticcheck":   true,
+		"errcheck":      true,
+		"gosec":         true,
+		"bodyclose":     true,
+		"sqlclosecheck": true,
+		"rowserrcheck":  true,
+		"nilerr":        true,
+	}
+	// styleLinters are the golangci-lint linters which only care about code style.
+	styleLinters = map[string]bool{
+		"gofmt":      true,
+		"gofumpt":    true,
+		"goimports":  true,
+		"gci":        true,
+		"godot":      true,
+		"golint":     true,
+		"misspell":   true,
+		"whitespace": true,
+		"wsl":        true,
+		"lll":        true,
+		"stylecheck": true,
+		"dupword":    true,
+		"nlreturn":   true,
+	}
+)
+
+// severity returns the severity of the golangci-lint issue according to the linter name,
+// which is at the end of the message with line-number format, such as `xxx (govet)`.
+// golangci-lint does not print the severity in line-number format, so we have to guess it.
+func severity(msg string) config.Severity {
+	msg = strings.TrimSpace(msg)
+	idx := strings.LastIndex(msg, "(")
+	if idx == -1 || !strings.HasSuffix(msg, ")") {
+		return config.SeverityWarning
+	}
+
+	linter := msg[idx+1 : len(msg)-1]
+	switch {
+	case errorLinters[linter]:
+		return config.SeverityError
+	case styleLinters[linter]:
+		return config.SeveritySuggest
+	default:
+		return config.SeverityWarning
+	}
+}
+
 // argsApply is used to set the default parameters for golangci-lint
 // see: ./docs/website/docs/component/go/golangci-lint
 func argsApply(log *xlog.Logger, a lint.Agent) lint.Agent {
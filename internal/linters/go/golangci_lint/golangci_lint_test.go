@@ -47,18 +47,20 @@ golangci_lint.go:18:3: error: (golint)
 			want: map[string][]lint.LinterOutput{
 				"golangci_lint/golangci_lint.go": {
 					{
-						File:    "golangci_lint/golangci_lint.go",
-						Line:    16,
-						Column:  1,
-						Message: "warning: (golint)",
+						File:     "golangci_lint/golangci_lint.go",
+						Line:     16,
+						Column:   1,
+						Message:  "warning: (golint)",
+						Severity: config.SeveritySuggest,
 					},
 				},
 				"golangci_lint.go": {
 					{
-						File:    "golangci_lint.go",
-						Line:    18,
-						Column:  3,
-						Message: "error: (golint)",
+						File:     "golangci_lint.go",
+						Line:     18,
+						Column:   3,
+						Message:  "error: (golint)",
+						Severity: config.SeveritySuggest,
 					},
 				},
 			},
@@ -73,10 +75,11 @@ golangci_lint.go:16:1: error (gochecknoglobals)
 			want: map[string][]lint.LinterOutput{
 				"golangci_lint.go": {
 					{
-						File:    "golangci_lint.go",
-						Line:    16,
-						Column:  1,
-						Message: "error (gochecknoglobals)",
+						File:     "golangci_lint.go",
+						Line:     16,
+						Column:   1,
+						Message:  "error (gochecknoglobals)",
+						Severity: config.SeverityWarning,
 					},
 				},
 			},
@@ -92,10 +95,29 @@ golangci_lint.go:16:1: warning: (gochecknoglobals)
 			want: map[string][]lint.LinterOutput{
 				"golangci_lint.go": {
 					{
-						File:    "golangci_lint.go",
-						Line:    16,
-						Column:  1,
-						Message: "warning: (gochecknoglobals)",
+						File:     "golangci_lint.go",
+						Line:     16,
+						Column:   1,
+						Message:  "warning: (gochecknoglobals)",
+						Severity: config.SeverityWarning,
+					},
+				},
+			},
+			unexpected: []string{},
+		},
+		{
+			id: "case4 - with error level linter",
+			output: []byte(`
+golangci_lint.go:20:2: printf: fmt.Sprintf format %d has arg x of wrong type string (govet)
+`),
+			want: map[string][]lint.LinterOutput{
+				"golangci_lint.go": {
+					{
+						File:     "golangci_lint.go",
+						Line:     20,
+						Column:   2,
+						Message:  "printf: fmt.Sprintf format %d has arg x of wrong type string (govet)",
+						Severity: config.SeverityError,
 					},
 				},
 			},
@@ -0,0 +1,96 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package staticcheck
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/qiniu/reviewbot/internal/lint"
+	"github.com/qiniu/x/log"
+	"github.com/qiniu/x/xlog"
+)
+
+// GoZeroTagFilter is the filter to ignore the SA5008 (unknown JSON option) errors caused by go-zero custom tags.
+// It's applied on both staticcheck and golangci-lint, which embeds staticcheck.
+// Background:
+//   - https://github.com/qiniu/reviewbot/issues/24
+const GoZeroTagFilter = "go-zero-tag"
+
+func init() {
+	lint.RegisterFilter(GoZeroTagFilter, filterBySA5008)
+}
+
+var tagRex = regexp.MustCompile(`unknown JSON option "(.*)" \(SA5008\)`)
+
+func filterBySA5008(_ *xlog.Logger, _ lint.Agent, results map[string][]lint.LinterOutput) (map[string][]lint.LinterOutput, error) {
+	finalResults := make(map[string][]lint.LinterOutput)
+
+	for file, linterResults := range results {
+		var lintersCopy []lint.LinterOutput
+		for _, linter := range linterResults {
+			matches := tagRex.FindStringSubmatch(linter.Message)
+			if len(matches) == 2 && isGoZeroCustomTag(matches[1]) {
+				log.Warnf("ignore this error: %v", linter.Message)
+				continue
+			}
+			lintersCopy = append(lintersCopy, linter)
+		}
+		if len(lintersCopy) > 0 {
+			finalResults[file] = lintersCopy
+		}
+	}
+
+	return finalResults, nil
+}
+
+// isGoZeroCustomTag checks if the tag is a go-zero custom tag.
+// refer: https://go-zero.dev/en/docs/tutorials/go-zero/configuration/overview#tag-checksum-rule
+const (
+	goZeroDefaultOption  = "default"
+	goZeroEnvOption      = "env"
+	goZeroInheritOption  = "inherit"
+	goZeroOptionalOption = "optional"
+	goZeroOptionsOption  = "options"
+	goZeroRangeOption    = "range"
+)
+
+// FIXME(CarlJi): this function is a temporary solution for go-zero custom tag, see [#24](https://github.com/qiniu/reviewbot/issues/24)
+// expect to remove this function after staticcheck supports go-zero custom tag.
+func isGoZeroCustomTag(jsonOption string) bool {
+	var found bool
+	switch {
+	case strings.HasPrefix(jsonOption, goZeroDefaultOption):
+		found = true
+	case strings.HasPrefix(jsonOption, goZeroEnvOption):
+		found = true
+	case strings.HasPrefix(jsonOption, goZeroInheritOption):
+		found = true
+	case strings.HasPrefix(jsonOption, goZeroOptionalOption):
+		found = true
+	case strings.HasPrefix(jsonOption, goZeroOptionsOption):
+		found = true
+	case strings.HasPrefix(jsonOption, goZeroRangeOption):
+		found = true
+	}
+
+	if found {
+		log.Warnf("this tag %v seems belongs to go-zero, ignore it temporary, see [#24](https://github.com/qiniu/reviewbot/issues/24) for more information", jsonOption)
+	}
+
+	return found
+}
@@ -0,0 +1,76 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package staticcheck
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/internal/lint"
+)
+
+func TestFilterBySA5008(t *testing.T) {
+	tcs := []struct {
+		name     string
+		input    map[string][]lint.LinterOutput
+		expected map[string][]lint.LinterOutput
+	}{
+		{
+			name: "go-zero custom tags",
+			input: map[string][]lint.LinterOutput{
+				"a.go": {
+					{Line: 4, Column: 16, Message: `normal error`},
+					{Line: 5, Column: 16, Message: `unknown JSON option "default=abc" (SA5008)`},
+				},
+				"b.go": {
+					{Line: 4, Column: 16, Message: `unknown JSON option "optional" (SA5008)`},
+					{Line: 5, Column: 16, Message: `unknown JSON option "range=[1:2]" (SA5008)`},
+				},
+			},
+			expected: map[string][]lint.LinterOutput{
+				"a.go": {
+					{Line: 4, Column: 16, Message: `normal error`},
+				},
+			},
+		},
+		{
+			name: "not go-zero tags",
+			input: map[string][]lint.LinterOutput{
+				"a.go": {
+					{Line: 4, Column: 16, Message: `unknown JSON option "abc" (SA5008)`},
+				},
+			},
+			expected: map[string][]lint.LinterOutput{
+				"a.go": {
+					{Line: 4, Column: 16, Message: `unknown JSON option "abc" (SA5008)`},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := filterBySA5008(nil, lint.Agent{}, tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ const linterName = "staticcheck"
 func init() {
 	lint.RegisterPullRequestHandler(linterName, staticcheckHandler)
 	lint.RegisterLinterLanguages(linterName, []string{".go"})
+	lint.RegisterLinterFilters(linterName, GoZeroTagFilter)
 }
 
 func staticcheckHandler(ctx context.Context, a lint.Agent) error {
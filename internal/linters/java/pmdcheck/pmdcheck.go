@@ -18,12 +18,16 @@ package pmdcheck
 
 import (
 	"context"
+	"encoding/csv"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/reviewbot/internal/lint"
 	"github.com/qiniu/reviewbot/internal/util"
 	"github.com/qiniu/x/errors"
@@ -72,7 +76,8 @@ func argsApply(log *xlog.Logger, a lint.Agent) lint.Agent {
 	log.Info("pmdcheck comamnd:" + strings.Join(config.Command, " "))
 	if lint.IsEmpty(config.Args...) {
 		args := append([]string{}, "check")
-		args = append(args, "-f", "emacs")
+		// use csv format since it contains the priority of the rule
+		args = append(args, "-f", "csv")
 		config.Args = args
 	}
 	a.LinterConfig = config
@@ -86,11 +91,60 @@ func pmdcheckParser(plog *xlog.Logger, output []byte) (map[string][]lint.LinterO
 		if strings.Contains(line, "[WARN]") || strings.Contains(line, "[ERROR]") {
 			return nil, nil
 		}
+		if strings.HasPrefix(line, `"`) {
+			return csvLineParser(line)
+		}
 		return lint.GeneralLineParser(strings.TrimLeft(line, " "))
 	}
 	return lint.Parse(plog, output, lineParse)
 }
 
+// csvLineParser parses the line of pmd csv format, such as:
+// "Problem","Package","File","Priority","Line","Description","Rule set","Rule"
+// "1","com.qiniu","./test.java","3","8","Avoid unused local variables such as 'test'.","Best Practices","UnusedLocalVariable"
+func csvLineParser(line string) (*lint.LinterOutput, error) {
+	records, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 6 {
+		return nil, fmt.Errorf("unexpected pmd csv line: %s", line)
+	}
+	// skip the header
+	if records[0] == "Problem" {
+		return nil, nil
+	}
+
+	lineNumber, err := strconv.Atoi(records[4])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected line number of pmd csv line: %s", line)
+	}
+
+	return &lint.LinterOutput{
+		File:     records[2],
+		Line:     lineNumber,
+		Message:  records[5],
+		Severity: prioritySeverity(records[3]),
+	}, nil
+}
+
+// prioritySeverity converts the pmd rule priority into severity.
+// see https://docs.pmd-code.org/latest/pmd_userdocs_extending_rule_guidelines.html#priority
+func prioritySeverity(priority string) config.Severity {
+	switch priority {
+	case "1", "2":
+		return config.SeverityError
+	case "3":
+		return config.SeverityWarning
+	case "4":
+		return config.SeverityInfo
+	case "5":
+		return config.SeveritySuggest
+	default:
+		return config.SeverityWarning
+	}
+}
+
 func getFileFromURL(plog *xlog.Logger, url string) (string, error) {
 	newfile := filepath.Join(pmdRuleDir, filepath.Base(url))
 	res, err := http.Get(url)
@@ -47,7 +47,7 @@ func TestArgs(t *testing.T) {
 				LinterConfig: config.Linter{
 					Enable:  &tp,
 					Command: []string{"pmd"},
-					Args:    []string{"check", "-f", "emacs"},
+					Args:    []string{"check", "-f", "csv"},
 				},
 			},
 		},
@@ -63,7 +63,7 @@ func TestArgs(t *testing.T) {
 				LinterConfig: config.Linter{
 					Enable:  &tp,
 					Command: []string{"/usr/pmdcheck"},
-					Args:    []string{"check", "-f", "emacs"},
+					Args:    []string{"check", "-f", "csv"},
 				},
 			},
 		},
@@ -160,6 +160,28 @@ func TestFormatPmdCheckLine(t *testing.T) {
 			},
 			unexpected: nil,
 		},
+		{
+			input: []byte(`"Problem","Package","File","Priority","Line","Description","Rule set","Rule"
+"1","com.qiniu","./test.java","3","8","Avoid unused local variables such as 'test'.","Best Practices","UnusedLocalVariable"
+"2","com.qiniu","./test.java","1","12","Avoid using a branching statement as the last in a loop.","Error Prone","AvoidBranchingStatementAsLastInLoop"`),
+			expected: map[string][]lint.LinterOutput{
+				"./test.java": {
+					{
+						File:     "./test.java",
+						Line:     8,
+						Message:  "Avoid unused local variables such as 'test'.",
+						Severity: config.SeverityWarning,
+					},
+					{
+						File:     "./test.java",
+						Line:     12,
+						Message:  "Avoid using a branching statement as the last in a loop.",
+						Severity: config.SeverityError,
+					},
+				},
+			},
+			unexpected: nil,
+		},
 	}
 	for _, c := range tc {
 		got, err := pmdcheckParser(xlog.New("UnitJavaPmdCheckTest"), c.input)
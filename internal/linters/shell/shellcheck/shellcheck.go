@@ -18,11 +18,12 @@ package shellcheck
 
 import (
 	"context"
-	"strings"
+	"errors"
 
 	"github.com/qiniu/reviewbot/internal/lint"
 	"github.com/qiniu/reviewbot/internal/metric"
 	"github.com/qiniu/reviewbot/internal/util"
+	"github.com/qiniu/x/xlog"
 )
 
 // refer to https://github.com/koalaman/shellcheck
@@ -30,17 +31,13 @@ const linterName = "shellcheck"
 
 func init() {
 	lint.RegisterPullRequestHandler(linterName, shellcheck)
-	lint.RegisterLinterLanguages(linterName, []string{".sh"})
+	// shellcheck supports sh, bash, dash and ksh, the scripts without extension are detected by the shebang
+	lint.RegisterLinterLanguages(linterName, []string{".sh", ".bash", ".ksh", "#!sh", "#!bash", "#!dash", "#!ksh"})
 }
 
 func shellcheck(ctx context.Context, a lint.Agent) error {
 	log := util.FromContext(ctx)
-	var shellFiles []string
-	for _, arg := range a.Provider.GetFiles(nil) {
-		if strings.HasSuffix(arg, ".sh") {
-			shellFiles = append(shellFiles, arg)
-		}
-	}
+	shellFiles := lint.LanguageFiles(linterName, a)
 
 	var lintResults map[string][]lint.LinterOutput
 	if len(shellFiles) > 0 {
@@ -55,11 +52,14 @@ func shellcheck(ctx context.Context, a lint.Agent) error {
 		}
 
 		output, err := lint.ExecRun(ctx, a)
+		if errors.Is(err, lint.ErrLinterTimeout) {
+			return lint.ReportTimeout(ctx, a)
+		}
 		if err != nil {
 			log.Warnf("%s run with error: %v, mark and continue", cmd, err)
 		}
 
-		results, unexpected := lint.GeneralParse(log, output)
+		results, unexpected := parser(log, output)
 		if len(unexpected) > 0 {
 			msg := util.LimitJoin(unexpected, 1000)
 			log.Warnf("unexpected output: %v", msg)
@@ -73,3 +73,18 @@ func shellcheck(ctx context.Context, a lint.Agent) error {
 	// since we need delete the existed comments related to the linter
 	return lint.Report(ctx, a, lintResults)
 }
+
+// parser parses the gcc format output of shellcheck, such as:
+// xxx.sh:3:8: warning: Double quote to prevent globbing and word splitting. [SC2086]
+func parser(log *xlog.Logger, output []byte) (map[string][]lint.LinterOutput, []string) {
+	results, unexpected := lint.GeneralParse(log, output)
+	for file, outputs := range results {
+		for i := range outputs {
+			// keep the level in message since it's a part of shellcheck's own format
+			if _, severity := lint.TrimSeverityPrefix(outputs[i].Message); severity != "" {
+				results[file][i].Severity = severity
+			}
+		}
+	}
+	return results, unexpected
+}
@@ -0,0 +1,71 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package shellcheck
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
+	"github.com/qiniu/x/xlog"
+)
+
+func TestParser(t *testing.T) {
+	tcs := []struct {
+		input    string
+		expected map[string][]lint.LinterOutput
+	}{
+		{
+			input: `test.sh:3:8: warning: Double quote to prevent globbing and word splitting. [SC2086]
+test.sh:5:1: note: Use ./*glob* or -- *glob* so names with dashes won't become options. [SC2035]
+test.sh:7:3: error: Couldn't parse this test expression. [SC1073]`,
+			expected: map[string][]lint.LinterOutput{
+				"test.sh": {
+					{
+						File:     "test.sh",
+						Line:     3,
+						Column:   8,
+						Message:  "warning: Double quote to prevent globbing and word splitting. [SC2086]",
+						Severity: config.SeverityWarning,
+					},
+					{
+						File:     "test.sh",
+						Line:     5,
+						Column:   1,
+						Message:  "note: Use ./*glob* or -- *glob* so names with dashes won't become options. [SC2035]",
+						Severity: config.SeverityInfo,
+					},
+					{
+						File:     "test.sh",
+						Line:     7,
+						Column:   3,
+						Message:  "error: Couldn't parse this test expression. [SC1073]",
+						Severity: config.SeverityError,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		got, _ := parser(xlog.New("ut"), []byte(tc.input))
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("expected: %v, got: %v", tc.expected, got)
+		}
+	}
+}
@@ -41,6 +41,16 @@ func IncIssueCounter(repo, linter, pull_request, commit string, count float64) {
 	issueCounter.WithLabelValues(repo, linter, pull_request, commit).Add(count)
 }
 
+var pendingJobs = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "reviewbot_queue_pending_jobs",
+	Help: "jobs waiting in the queue",
+})
+
+// SetPendingJobs sets the number of jobs waiting in the queue.
+func SetPendingJobs(n int) {
+	pendingJobs.Set(float64(n))
+}
+
 type MessageBody struct {
 	MsgType  string     `json:"msgtype"`
 	Text     MsgContent `json:"text,omitempty"`
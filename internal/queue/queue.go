@@ -0,0 +1,479 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/reviewbot/internal/metric"
+	"github.com/qiniu/x/log"
+)
+
+// Job is a unit of work in the queue, which is persisted until it's done or failed finally.
+type Job struct {
+	// ID is the unique id of the job, assigned when enqueued.
+	ID string `json:"id"`
+	// Kind tells the handler how to decode the payload, e.g. "github/pull_request".
+	Kind string `json:"kind"`
+	// Org is the organization the job belongs to, the jobs are scheduled fairly across the orgs.
+	Org string `json:"org"`
+	// Key identifies the subject of the job, e.g. the PR/MR. A pending job is replaced by the newly enqueued one
+	// of the same key since only the latest event matters. Empty key means the job is never replaced.
+	Key string `json:"key,omitempty"`
+	// EventGUID is the id of the event which creates the job, used to trace the logs.
+	EventGUID string `json:"eventGUID,omitempty"`
+	// Payload is the content of the job, e.g. the webhook payload.
+	Payload []byte `json:"payload"`
+	// Attempts is the number of attempts started, including the running one.
+	Attempts int `json:"attempts"`
+	// LastError is the error of the last failed attempt.
+	LastError string `json:"lastError,omitempty"`
+	// CreatedAt is when the job is enqueued.
+	CreatedAt time.Time `json:"createdAt"`
+	// NotBefore is when the job can be run, which is set to delay the retries.
+	NotBefore time.Time `json:"notBefore,omitempty"`
+}
+
+// Handler runs the job. The job is retried with backoff if an error is returned,
+// unless the error is marked by Permanent or the context is canceled.
+type Handler func(ctx context.Context, job *Job) error
+
+var (
+	ErrQueueClosed = errors.New("queue is closed")
+	errPermanent   = errors.New("permanent error")
+)
+
+// Permanent marks the error as not retryable, e.g. the payload can not be decoded.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", errPermanent, err)
+}
+
+// IsPermanent reports whether the error is marked by Permanent.
+func IsPermanent(err error) bool {
+	return errors.Is(err, errPermanent)
+}
+
+const (
+	defaultWorkers     = 4
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 30 * time.Second
+	defaultMaxDelay    = 10 * time.Minute
+)
+
+// Options is the options of the queue.
+type Options struct {
+	// Dir is the directory where the jobs are persisted.
+	Dir string
+	// Workers is the number of jobs running concurrently.
+	Workers int
+	// MaxAttempts is the max number of attempts of a job, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled for each following retry.
+	BaseDelay time.Duration
+	// MaxDelay is the max delay between the retries.
+	MaxDelay time.Duration
+}
+
+// Queue is a job queue backed by the local disk, the jobs are run by a fixed number of workers.
+// The jobs not finished are recovered from the disk when the queue is created again, e.g. after restart.
+type Queue struct {
+	opts    Options
+	store   *store
+	handler Handler
+
+	mu sync.Mutex
+	// pending is the jobs waiting to run of each org, in the order of enqueuing.
+	pending map[string][]*Job
+	// orgs is the orgs having pending jobs, in the round-robin order.
+	orgs []string
+	// next is the index in orgs to pick the next job from.
+	next int
+	// latest is the id of the latest job enqueued of each key, to drop the retries of the superseded jobs.
+	latest map[jobKey]string
+	// notify wakes up the idle workers when a job is enqueued.
+	notify chan struct{}
+	closed bool
+	seq    atomic.Uint64
+}
+
+// jobKey identifies the jobs of the same subject, see Job.Key.
+type jobKey struct {
+	org, key string
+}
+
+// New creates the queue and loads the jobs persisted in the dir.
+func New(opts Options, handler Handler) (*Queue, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultMaxDelay
+	}
+
+	s, err := newStore(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{
+		opts:    opts,
+		store:   s,
+		handler: handler,
+		pending: make(map[string][]*Job),
+		latest:  make(map[jobKey]string),
+		notify:  make(chan struct{}, 1),
+	}
+	for _, job := range jobs {
+		if job.Attempts >= opts.MaxAttempts {
+			// the last attempt was interrupted by the crash, which may be caused by the job itself
+			job.LastError = "interrupted at the last attempt"
+			log.Errorf("job %s failed after %d attempts: %s", job.ID, job.Attempts, job.LastError)
+			if err := s.fail(job); err != nil {
+				log.Errorf("failed to move job %s to the failed jobs: %v", job.ID, err)
+			}
+			continue
+		}
+		q.track(job)
+		q.push(job)
+	}
+	if len(jobs) > 0 {
+		log.Infof("recovered %d jobs from %s", len(jobs), s.dir)
+	}
+	return q, nil
+}
+
+// Enqueue persists the job and schedules it to run.
+// The pending job of the same key is replaced.
+func (q *Queue) Enqueue(job *Job) error {
+	job.ID = q.newID()
+	job.Attempts = 0
+	job.LastError = ""
+	job.CreatedAt = time.Now()
+	job.NotBefore = time.Time{}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if err := q.store.save(job); err != nil {
+		return err
+	}
+	if replaced := q.removePending(job.Org, job.Key); replaced != nil {
+		log.Infof("job %s of %s is replaced by %s", replaced.ID, replaced.Key, job.ID)
+		if err := q.store.remove(replaced); err != nil {
+			log.Errorf("failed to remove job %s: %v", replaced.ID, err)
+		}
+	}
+	q.track(job)
+	q.push(job)
+	q.wakeup()
+	return nil
+}
+
+// Run starts the workers and blocks until the context is done and the running jobs are returned.
+// The jobs canceled by the context are kept on the disk to run again next time.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+}
+
+// Len returns the number of the pending jobs.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.countLocked()
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		job, wait := q.pop(time.Now())
+		if job == nil {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-q.notify:
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+
+		q.run(ctx, job)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (q *Queue) run(ctx context.Context, job *Job) {
+	job.Attempts++
+	// record the attempt before running, so that a job crashing the process is not retried forever
+	if err := q.store.save(job); err != nil {
+		log.Errorf("failed to save job %s: %v", job.ID, err)
+	}
+
+	err := q.handle(ctx, job)
+	switch {
+	case err == nil:
+		q.done(job)
+	case ctx.Err() != nil:
+		// the queue is stopped, the job is recovered from the disk next time without counting this attempt
+		log.Infof("job %s is interrupted: %v", job.ID, err)
+		job.Attempts--
+		if err := q.store.save(job); err != nil {
+			log.Errorf("failed to save job %s: %v", job.ID, err)
+		}
+	case errors.Is(err, context.Canceled):
+		// superseded by a newer job of the same subject
+		log.Infof("job %s is canceled: %v", job.ID, err)
+		q.done(job)
+	case IsPermanent(err) || job.Attempts >= q.opts.MaxAttempts:
+		job.LastError = err.Error()
+		log.Errorf("job %s failed after %d attempts: %v", job.ID, job.Attempts, err)
+		if err := q.store.fail(job); err != nil {
+			log.Errorf("failed to move job %s to the failed jobs: %v", job.ID, err)
+		}
+	default:
+		q.retry(job, err)
+		return
+	}
+
+	q.mu.Lock()
+	q.untrack(job)
+	q.mu.Unlock()
+}
+
+// retry schedules the failed job to run again after the backoff,
+// unless a newer job of the same key was enqueued while it was running.
+func (q *Queue) retry(job *Job, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.superseded(job) {
+		log.Infof("job %s failed at attempt %d, not retried as superseded: %v", job.ID, job.Attempts, err)
+		q.done(job)
+		return
+	}
+
+	job.LastError = err.Error()
+	delay := q.backoff(job.Attempts)
+	job.NotBefore = time.Now().Add(delay)
+	log.Warnf("job %s failed at attempt %d, retry in %v: %v", job.ID, job.Attempts, delay, err)
+	if err := q.store.save(job); err != nil {
+		log.Errorf("failed to save job %s: %v", job.ID, err)
+	}
+	q.push(job)
+}
+
+// track records the job as the latest one of its key, the caller must hold the lock.
+func (q *Queue) track(job *Job) {
+	if job.Key != "" {
+		q.latest[jobKey{job.Org, job.Key}] = job.ID
+	}
+}
+
+// untrack forgets the finished job if it is still the latest one of its key, the caller must hold the lock.
+func (q *Queue) untrack(job *Job) {
+	k := jobKey{job.Org, job.Key}
+	if job.Key != "" && q.latest[k] == job.ID {
+		delete(q.latest, k)
+	}
+}
+
+// superseded reports whether a newer job of the same key was enqueued, the caller must hold the lock.
+func (q *Queue) superseded(job *Job) bool {
+	if job.Key == "" {
+		return false
+	}
+	id, ok := q.latest[jobKey{job.Org, job.Key}]
+	return ok && id != job.ID
+}
+
+// handle runs the handler, a panic is recovered as an error.
+func (q *Queue) handle(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panic: %v", job.ID, r)
+		}
+	}()
+	return q.handler(ctx, job)
+}
+
+func (q *Queue) done(job *Job) {
+	if err := q.store.remove(job); err != nil {
+		log.Errorf("failed to remove job %s: %v", job.ID, err)
+	}
+}
+
+// backoff returns the delay before the next attempt, with jitter to spread the retries.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.opts.BaseDelay
+	for i := 1; i < attempts && delay < q.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay += time.Duration(rand.Int63n(int64(delay)/4 + 1))
+	if delay > q.opts.MaxDelay {
+		delay = q.opts.MaxDelay
+	}
+	return delay
+}
+
+// push adds the job to the pending jobs of its org, the caller must hold the lock.
+func (q *Queue) push(job *Job) {
+	if _, ok := q.pending[job.Org]; !ok {
+		q.orgs = append(q.orgs, job.Org)
+	}
+	q.pending[job.Org] = append(q.pending[job.Org], job)
+	metric.SetPendingJobs(q.countLocked())
+}
+
+// pop picks the next ready job in the round-robin order of the orgs.
+// If no job is ready, it returns how long to wait for the earliest delayed one.
+func (q *Queue) pop(now time.Time) (*Job, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// wait for the notification if nothing pending, the timeout is just a safeguard
+	wait := time.Minute
+	for i := 0; i < len(q.orgs); i++ {
+		idx := (q.next + i) % len(q.orgs)
+		org := q.orgs[idx]
+		jobs := q.pending[org]
+		for j, job := range jobs {
+			if job.NotBefore.After(now) {
+				wait = min(wait, job.NotBefore.Sub(now))
+				continue
+			}
+
+			q.pending[org] = append(jobs[:j:j], jobs[j+1:]...)
+			if len(q.pending[org]) == 0 {
+				delete(q.pending, org)
+				q.orgs = append(q.orgs[:idx:idx], q.orgs[idx+1:]...)
+				q.next = idx
+			} else {
+				q.next = idx + 1
+			}
+			if len(q.orgs) > 0 {
+				q.next %= len(q.orgs)
+			} else {
+				q.next = 0
+			}
+			n := q.countLocked()
+			metric.SetPendingJobs(n)
+			if n > 0 {
+				// let another idle worker pick up the rest
+				q.wakeup()
+			}
+			return job, 0
+		}
+	}
+	return nil, wait
+}
+
+// removePending removes the pending job of the same key, the caller must hold the lock.
+func (q *Queue) removePending(org, key string) *Job {
+	if key == "" {
+		return nil
+	}
+	jobs := q.pending[org]
+	for i, job := range jobs {
+		if job.Key != key {
+			continue
+		}
+		q.pending[org] = append(jobs[:i:i], jobs[i+1:]...)
+		if len(q.pending[org]) == 0 {
+			delete(q.pending, org)
+			for idx, o := range q.orgs {
+				if o != org {
+					continue
+				}
+				q.orgs = append(q.orgs[:idx:idx], q.orgs[idx+1:]...)
+				if q.next > idx {
+					q.next--
+				}
+				if len(q.orgs) == 0 || q.next >= len(q.orgs) {
+					q.next = 0
+				}
+				break
+			}
+		}
+		metric.SetPendingJobs(q.countLocked())
+		return job
+	}
+	return nil
+}
+
+func (q *Queue) wakeup() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) countLocked() int {
+	var n int
+	for _, jobs := range q.pending {
+		n += len(jobs)
+	}
+	return n
+}
+
+// newID returns an id ordered by the enqueuing time, so that the jobs are recovered in order.
+func (q *Queue) newID() string {
+	return fmt.Sprintf("%d-%s", time.Now().UnixNano(), strconv.FormatUint(q.seq.Add(1), 10))
+}
+
+// sortJobs sorts the jobs in the order of enqueuing.
+func sortJobs(jobs []*Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+}
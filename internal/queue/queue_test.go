@@ -0,0 +1,232 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = errors.New("transient error")
+
+func newTestQueue(t *testing.T, dir string, handler Handler) *Queue {
+	t.Helper()
+	q, err := New(Options{Dir: dir, Workers: 2, MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}, handler)
+	require.NoError(t, err)
+	return q
+}
+
+// runUntil runs the queue until the condition is met.
+func runUntil(t *testing.T, q *Queue, condition func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(stopped)
+	}()
+	assert.Eventually(t, condition, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	<-stopped
+}
+
+func jobFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+jobFileExt))
+	require.NoError(t, err)
+	return files
+}
+
+func TestRunJobs(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		mu       sync.Mutex
+		payloads []string
+	)
+	q := newTestQueue(t, dir, func(_ context.Context, job *Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		payloads = append(payloads, string(job.Payload))
+		return nil
+	})
+	for _, p := range []string{"a", "b", "c"} {
+		require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu", Payload: []byte(p)}))
+	}
+
+	runUntil(t, q, func() bool { return len(jobFiles(t, dir)) == 0 })
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, payloads)
+}
+
+func TestRetry(t *testing.T) {
+	dir := t.TempDir()
+	var attempts []int
+	q := newTestQueue(t, dir, func(_ context.Context, job *Job) error {
+		attempts = append(attempts, job.Attempts)
+		if job.Attempts < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	q.opts.Workers = 1
+	require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu"}))
+
+	runUntil(t, q, func() bool { return len(jobFiles(t, dir)) == 0 })
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestFailedJobs(t *testing.T) {
+	tcs := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{
+			name:     "permanent error",
+			err:      Permanent(errors.New("bad payload")),
+			attempts: 1,
+		},
+		{
+			name:     "too many attempts",
+			err:      errTransient,
+			attempts: 3,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q := newTestQueue(t, dir, func(context.Context, *Job) error {
+				return tc.err
+			})
+			q.opts.Workers = 1
+			require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu"}))
+
+			runUntil(t, q, func() bool { return len(jobFiles(t, dir)) == 0 })
+			failed := jobFiles(t, filepath.Join(dir, failedDir))
+			require.Len(t, failed, 1)
+			job, err := readJob(failed[0])
+			require.NoError(t, err)
+			assert.Equal(t, tc.attempts, job.Attempts)
+			assert.Equal(t, tc.err.Error(), job.LastError)
+		})
+	}
+}
+
+func TestRecoverJobs(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestQueue(t, dir, nil)
+	for _, p := range []string{"a", "b", "c"} {
+		require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu", Payload: []byte(p)}))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+jobFileExt), []byte("{"), 0o600))
+	// the job running at the last attempt when crashed
+	crashed := &Job{ID: "0-1", Kind: "test", Org: "qiniu", Attempts: 3}
+	require.NoError(t, q.store.save(crashed))
+
+	// the jobs not finished are recovered in order, e.g. after restart
+	q = newTestQueue(t, dir, nil)
+	require.Equal(t, 3, q.Len())
+	for _, p := range []string{"a", "b", "c"} {
+		job, _ := q.pop(time.Now())
+		require.NotNil(t, job)
+		assert.Equal(t, p, string(job.Payload))
+	}
+	assert.Len(t, jobFiles(t, filepath.Join(dir, failedDir)), 2)
+}
+
+func TestFairScheduling(t *testing.T) {
+	q := newTestQueue(t, t.TempDir(), nil)
+	for _, org := range []string{"a", "a", "a", "b", "c", "b"} {
+		require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: org}))
+	}
+
+	var orgs []string
+	for q.Len() > 0 {
+		job, _ := q.pop(time.Now())
+		require.NotNil(t, job)
+		orgs = append(orgs, job.Org)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a", "b", "a"}, orgs)
+}
+
+func TestDelayedJobs(t *testing.T) {
+	q := newTestQueue(t, t.TempDir(), nil)
+	require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "a", Payload: []byte("delayed")}))
+	require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "a", Payload: []byte("ready")}))
+	now := time.Now()
+	q.pending["a"][0].NotBefore = now.Add(time.Second)
+
+	// the delayed job does not block the others of the same org
+	job, _ := q.pop(now)
+	require.NotNil(t, job)
+	assert.Equal(t, "ready", string(job.Payload))
+
+	job, wait := q.pop(now)
+	assert.Nil(t, job)
+	assert.Equal(t, time.Second, wait)
+}
+
+func TestReplacePendingJob(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestQueue(t, dir, nil)
+	require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu", Key: "qiniu/reviewbot#1", Payload: []byte("first")}))
+	require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu", Key: "qiniu/reviewbot#2", Payload: []byte("other")}))
+	require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu", Key: "qiniu/reviewbot#1", Payload: []byte("second")}))
+
+	require.Equal(t, 2, q.Len())
+	assert.Len(t, jobFiles(t, dir), 2)
+	var payloads []string
+	for q.Len() > 0 {
+		job, _ := q.pop(time.Now())
+		payloads = append(payloads, string(job.Payload))
+	}
+	assert.Equal(t, []string{"other", "second"}, payloads)
+}
+
+func TestDropSupersededRetry(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		mu       sync.Mutex
+		payloads []string
+		q        *Queue
+	)
+	q = newTestQueue(t, dir, func(_ context.Context, job *Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		payloads = append(payloads, string(job.Payload))
+		if string(job.Payload) == "first" {
+			// a newer job of the same key is enqueued while the first one is running
+			require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu", Key: job.Key, Payload: []byte("second")}))
+			return errTransient
+		}
+		return nil
+	})
+	q.opts.Workers = 1
+	require.NoError(t, q.Enqueue(&Job{Kind: "test", Org: "qiniu", Key: "qiniu/reviewbot#1", Payload: []byte("first")}))
+
+	runUntil(t, q, func() bool { return len(jobFiles(t, dir)) == 0 })
+	assert.Equal(t, []string{"first", "second"}, payloads)
+	assert.Empty(t, q.latest)
+}
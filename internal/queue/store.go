@@ -0,0 +1,145 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qiniu/x/log"
+)
+
+const (
+	jobFileExt = ".json"
+	// failedDir keeps the jobs failed finally for troubleshooting, they are never loaded again.
+	failedDir = "failed"
+)
+
+// store persists each job as a json file in the dir.
+type store struct {
+	dir string
+}
+
+func newStore(dir string) (*store, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, failedDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to make queue dir: %w", err)
+	}
+	return &store{dir: dir}, nil
+}
+
+func (s *store) path(id string) string {
+	return filepath.Join(s.dir, id+jobFileExt)
+}
+
+// save writes the job atomically, so that a crash never leaves a partial job file.
+func (s *store) save(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job %s: %w", job.ID, err)
+	}
+	tmp, err := os.CreateTemp(s.dir, job.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create job file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write job file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync job file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close job file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(job.ID)); err != nil {
+		return fmt.Errorf("failed to save job file: %w", err)
+	}
+	return nil
+}
+
+func (s *store) remove(job *Job) error {
+	if err := os.Remove(s.path(job.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove job file: %w", err)
+	}
+	return nil
+}
+
+// fail moves the job to the failed dir.
+func (s *store) fail(job *Job) error {
+	if err := s.save(job); err != nil {
+		return err
+	}
+	if err := os.Rename(s.path(job.ID), filepath.Join(s.dir, failedDir, job.ID+jobFileExt)); err != nil {
+		return fmt.Errorf("failed to move job file: %w", err)
+	}
+	return nil
+}
+
+// load reads all the jobs not finished in the order of enqueuing.
+// The broken job files are moved to the failed dir.
+func (s *store) load() ([]*Job, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue dir: %w", err)
+	}
+
+	var jobs []*Job
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, jobFileExt) {
+			continue
+		}
+		file := filepath.Join(s.dir, name)
+		job, err := readJob(file)
+		if err != nil {
+			log.Errorf("failed to load job from %s, move it to %s: %v", file, failedDir, err)
+			if err := os.Rename(file, filepath.Join(s.dir, failedDir, name)); err != nil {
+				log.Errorf("failed to move job file %s: %v", file, err)
+			}
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+	return jobs, nil
+}
+
+func readJob(file string) (*Job, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	if job.ID+jobFileExt != filepath.Base(file) {
+		return nil, fmt.Errorf("job id %q does not match the file name", job.ID)
+	}
+	return &job, nil
+}
@@ -29,6 +29,7 @@ import (
 	"os/exec"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
@@ -44,6 +45,8 @@ type DockerRunner struct {
 
 	// script is the final script to be executed
 	script string
+	// secrets is the secrets resolved from the env of the linter.
+	secrets []string
 }
 
 func NewDockerRunner(cli DockerClientInterface) (Runner, error) {
@@ -58,7 +61,11 @@ func NewDockerRunner(cli DockerClientInterface) (Runner, error) {
 }
 
 func (d *DockerRunner) GetFinalScript() string {
-	return d.script
+	return d.Redact(d.script)
+}
+
+func (d *DockerRunner) Redact(s string) string {
+	return redact(s, d.secrets)
 }
 
 // Prepare will pull the docker image if it is not exist.
@@ -105,6 +112,15 @@ func (d *DockerRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClos
 		return nil, err
 	}
 
+	env, err := resolveEnv(cfg.Env)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.k8sSecrets) > 0 {
+		return nil, ErrK8sSecretNotSupported
+	}
+	d.secrets = env.secrets
+
 	// construct the script content
 	scriptContent := "set -e\n"
 
@@ -127,7 +143,7 @@ func (d *DockerRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClos
 	var (
 		dockerConfig = &container.Config{
 			Image:      cfg.DockerAsRunner.Image,
-			Env:        cfg.Env,
+			Env:        env.env,
 			Entrypoint: entrypoint,
 			Cmd:        []string{scriptContent},
 			WorkingDir: cfg.WorkDir,
@@ -137,8 +153,9 @@ func (d *DockerRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClos
 		}
 	)
 
+	// NOTE: log the env with the secret references rather than the resolved secrets
 	log.Infof("Docker config: entrypoint: %v, cmd: %v, env: %v, working dir: %v, volume: %v",
-		dockerConfig.Entrypoint, dockerConfig.Cmd, dockerConfig.Env, dockerConfig.WorkingDir, dockerHostConfig.Binds)
+		dockerConfig.Entrypoint, dockerConfig.Cmd, cfg.Env, dockerConfig.WorkingDir, dockerHostConfig.Binds)
 
 	resp, err := d.Cli.ContainerCreate(ctx, dockerConfig, dockerHostConfig, nil, nil, "")
 	if err != nil {
@@ -193,6 +210,10 @@ func (d *DockerRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClos
 	var statusCode int64
 	select {
 	case err := <-errCh:
+		if ctx.Err() != nil {
+			// the linter is timed out or cancelled, kill the container instead of leaving it running
+			return d.killContainer(ctx, resp.ID)
+		}
 		return nil, fmt.Errorf("error waiting for container: %w", err)
 	case status := <-statusCh:
 		statusCode = status.StatusCode
@@ -227,6 +248,35 @@ func (d *DockerRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClos
 	return d.readLogFromContainer(ctx, resp.ID)
 }
 
+// killContainer kills the container and returns the logs produced so far together with the cause.
+func (d *DockerRunner) killContainer(ctx context.Context, containerID string) (io.ReadCloser, error) {
+	log := util.FromContext(ctx)
+	// ctx is already done, so use a new one for cleaning up
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+	defer cancel()
+
+	if err := d.Cli.ContainerKill(cleanupCtx, containerID, "SIGKILL"); err != nil {
+		log.Errorf("failed to kill container %s: %v", containerID, err)
+	} else {
+		log.Warnf("container %s killed: %v", containerID, ctx.Err())
+	}
+
+	cause := fmt.Errorf("container %s killed: %w", containerID, ctx.Err())
+	logReader, err := d.readLogFromContainer(cleanupCtx, containerID)
+	if err != nil {
+		log.Errorf("failed to read log from killed container %s: %v", containerID, err)
+		return nil, cause
+	}
+	defer logReader.Close()
+
+	// read the logs before cleanupCtx is cancelled
+	content, err := io.ReadAll(logReader)
+	if err != nil {
+		log.Errorf("failed to read log from killed container %s: %v", containerID, err)
+	}
+	return io.NopCloser(bytes.NewReader(content)), cause
+}
+
 func (d *DockerRunner) Clone() Runner {
 	return &DockerRunner{Cli: d.Cli, ArchiveWrapper: d.ArchiveWrapper}
 }
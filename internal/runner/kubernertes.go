@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -40,12 +42,17 @@ const (
 	VolumeScriptConfig = "script-config"
 )
 
+// defaultJobTimeout is the time to wait for the job completion if the linter has no timeout configured.
+const defaultJobTimeout = 10 * time.Minute
+
 // KubernetesRunner is a runner that runs the linter in a Kubernetes pod.
 type KubernetesRunner struct {
 	client *kubernetes.Clientset
 
 	// following fields are used for context aware fields for each execution
 	script string
+	// secrets is the secrets resolved from the env of the linter.
+	secrets []string
 }
 
 func NewKubernetesRunner(kubeConfig string) (Runner, error) {
@@ -76,6 +83,12 @@ func (k *KubernetesRunner) Run(ctx context.Context, cfg *config.Linter) (io.Read
 	}
 	log.Infof("final config: %v", newCfg)
 
+	env, err := resolveEnv(newCfg.Env)
+	if err != nil {
+		return nil, err
+	}
+	k.secrets = env.secrets
+
 	scriptContent := ""
 	// handle args
 	scriptContent += strings.Join(newCfg.Args, " ")
@@ -89,7 +102,7 @@ func (k *KubernetesRunner) Run(ctx context.Context, cfg *config.Linter) (io.Read
 		return nil, err
 	}
 
-	job := newJob(cfg, uniqueName, scriptConfigMap.Name)
+	job := newJob(cfg, uniqueName, scriptConfigMap.Name, containerEnv(env))
 	containerName := job.Spec.Template.Spec.Containers[0].Name
 
 	var srcPath, dstPath string
@@ -145,9 +158,17 @@ func (k *KubernetesRunner) Run(ctx context.Context, cfg *config.Linter) (io.Read
 	}
 
 	// wait for job completion
-	err = k.waitForJobCompletion(ctx, cfg.KubernetesAsRunner.Namespace, createdJob.Name)
+	timeout := defaultJobTimeout
+	if cfg.Timeout > 0 {
+		timeout = cfg.Timeout.Duration()
+	}
+	err = k.waitForJobCompletion(ctx, namespace, createdJob.Name, timeout)
 	if err != nil {
 		log.Errorf("wait for job completion failed: %v", err)
+		if ctx.Err() != nil || wait.Interrupted(err) {
+			// the linter is timed out or cancelled, kill the job instead of leaving it running
+			return k.killJob(ctx, namespace, createdJob.Name, podName, err)
+		}
 		return nil, err
 	}
 
@@ -155,7 +176,11 @@ func (k *KubernetesRunner) Run(ctx context.Context, cfg *config.Linter) (io.Read
 }
 
 func (k *KubernetesRunner) GetFinalScript() string {
-	return k.script
+	return k.Redact(k.script)
+}
+
+func (k *KubernetesRunner) Redact(s string) string {
+	return redact(s, k.secrets)
 }
 
 // check the permission to create pod in the namespace.
@@ -249,8 +274,31 @@ func copyToPod(ctx context.Context, namespace, podName, containerName, srcPath,
 	return nil
 }
 
-func (k *KubernetesRunner) waitForJobCompletion(ctx context.Context, namespace, jobName string) error {
-	return wait.PollUntilContextTimeout(ctx, time.Second, 10*time.Minute, true, func(ctx context.Context) (bool, error) {
+// killJob deletes the job and its pod, and returns the logs produced so far together with the cause.
+func (k *KubernetesRunner) killJob(ctx context.Context, namespace, jobName, podName string, cause error) (io.ReadCloser, error) {
+	log := util.FromContext(ctx)
+	// ctx may be already done, so use a new one for cleaning up
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+	defer cancel()
+
+	logs, err := k.getPodLogs(cleanupCtx, namespace, podName)
+	if err != nil {
+		log.Errorf("failed to get logs of pod %s: %v", podName, err)
+	}
+
+	deletePolicy := metav1.DeletePropagationBackground
+	err = k.client.BatchV1().Jobs(namespace).Delete(cleanupCtx, jobName, metav1.DeleteOptions{PropagationPolicy: &deletePolicy})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Errorf("failed to delete job %s: %v", jobName, err)
+	} else {
+		log.Warnf("job %s killed: %v", jobName, cause)
+	}
+
+	return logs, fmt.Errorf("job %s killed: %w", jobName, cause)
+}
+
+func (k *KubernetesRunner) waitForJobCompletion(ctx context.Context, namespace, jobName string, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
 		job, err := k.client.BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
 		if err != nil {
 			return false, err
@@ -402,7 +450,7 @@ func (k *KubernetesRunner) createOrRecreateJobAndWaitForPod(ctx context.Context,
 	return createdJob, nil
 }
 
-func newJob(cfg *config.Linter, jobName, scriptConfigMapName string) *batchv1.Job {
+func newJob(cfg *config.Linter, jobName, scriptConfigMapName string, env []corev1.EnvVar) *batchv1.Job {
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: cfg.KubernetesAsRunner.Namespace,
@@ -420,12 +468,23 @@ func newJob(cfg *config.Linter, jobName, scriptConfigMapName string) *batchv1.Jo
 	addKubefreeLabelsAndAnnotations(job)
 	addVolumes(job, scriptConfigMapName)
 	addInitContainers(job, cfg)
-	addLinterContainer(job, cfg)
+	addLinterContainer(job, cfg, env)
 	setRestartPolicy(job)
+	setActiveDeadline(job, cfg)
 
 	return job
 }
 
+// setActiveDeadline makes kubernetes kill the job once the linter timeout is exceeded,
+// so that the job won't run forever even if reviewbot fails to delete it.
+func setActiveDeadline(job *batchv1.Job, cfg *config.Linter) {
+	if cfg.Timeout <= 0 {
+		return
+	}
+	seconds := int64(math.Ceil(cfg.Timeout.Duration().Seconds()))
+	job.Spec.ActiveDeadlineSeconds = &seconds
+}
+
 // for resource recycling, see https://github.com/kubefree/kubefree
 func addKubefreeLabelsAndAnnotations(job *batchv1.Job) {
 	currentTime := time.Now().UTC().Format(time.RFC3339)
@@ -495,7 +554,7 @@ func addInitContainers(job *batchv1.Job, cfg *config.Linter) {
 	}
 }
 
-func addLinterContainer(job *batchv1.Job, cfg *config.Linter) {
+func addLinterContainer(job *batchv1.Job, cfg *config.Linter, env []corev1.EnvVar) {
 	job.Spec.Template.Spec.Containers = []corev1.Container{
 		{
 			Name:       "linter",
@@ -503,6 +562,7 @@ func addLinterContainer(job *batchv1.Job, cfg *config.Linter) {
 			Command:    []string{"/bin/sh", "-c"},
 			Args:       []string{"/scripts/script.sh"},
 			WorkingDir: cfg.WorkDir,
+			Env:        env,
 			VolumeMounts: []corev1.VolumeMount{
 				{
 					Name:      VolumeCodeMount,
@@ -518,6 +578,34 @@ func addLinterContainer(job *batchv1.Job, cfg *config.Linter) {
 	}
 }
 
+// containerEnv converts the resolved env to the env of the container,
+// the Kubernetes secret references are read from the secrets in the namespace of the job by kubernetes.
+func containerEnv(env resolvedEnv) []corev1.EnvVar {
+	vars := make([]corev1.EnvVar, 0, len(env.env)+len(env.k8sSecrets))
+	for _, e := range env.env {
+		name, value, _ := strings.Cut(e, "=")
+		vars = append(vars, corev1.EnvVar{Name: name, Value: value})
+	}
+	names := make([]string, 0, len(env.k8sSecrets))
+	for name := range env.k8sSecrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ref := env.k8sSecrets[name]
+		vars = append(vars, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+					Key:                  ref.Key,
+				},
+			},
+		})
+	}
+	return vars
+}
+
 func setRestartPolicy(job *batchv1.Job) {
 	job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
 }
@@ -0,0 +1,35 @@
+//go:build unix
+
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runner
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroupOnCancel makes the command run in its own process group,
+// and kills the whole group once the context is done.
+// The linter is usually a child process of the shell, killing the shell only will leave it running.
+func killProcessGroupOnCancel(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		// negative pid means the process group
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -44,14 +45,22 @@ type Runner interface {
 	// GetFinalScript returns the final script to be executed.
 	// It should be called after Run function. and it's used for logging and debugging.
 	GetFinalScript() string
+	// Redact replaces the secrets resolved from the env of the linter in s, e.g. the script and the output.
+	// It should be called after Run function.
+	Redact(s string) string
 	// Clone returns a new Runner instance with the same configuration to keep concurrency safe.
 	// It's used for creating a new runner for each linter.
 	Clone() Runner
 }
 
+// processWaitDelay is the time to wait for the I/O of the local command after it's killed.
+const processWaitDelay = 10 * time.Second
+
 // LocalRunner is a runner that runs the linter locally.
 type LocalRunner struct {
 	script string
+	// secrets is the secrets resolved from the env of the linter.
+	secrets []string
 }
 
 func NewLocalRunner() Runner {
@@ -63,7 +72,11 @@ func (l *LocalRunner) Clone() Runner {
 }
 
 func (l *LocalRunner) GetFinalScript() string {
-	return l.script
+	return l.Redact(l.script)
+}
+
+func (l *LocalRunner) Redact(s string) string {
+	return redact(s, l.secrets)
 }
 
 func (l *LocalRunner) Prepare(ctx context.Context, cfg *config.Linter) error {
@@ -78,6 +91,15 @@ func (l *LocalRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClose
 	}
 	log.Infof("final config: %v", newCfg)
 
+	env, err := resolveEnv(newCfg.Env)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.k8sSecrets) > 0 {
+		return nil, ErrK8sSecretNotSupported
+	}
+	l.secrets = env.secrets
+
 	// construct the script content
 	scriptContent := "set -e\n"
 
@@ -101,6 +123,9 @@ func (l *LocalRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClose
 	//nolint:gosec
 	c := exec.CommandContext(ctx, shell[0], append(shell[1:], scriptContent)...)
 	c.Dir = newCfg.WorkDir
+	killProcessGroupOnCancel(c)
+	// make sure the command returns in time even if the output pipes are still held by orphaned processes
+	c.WaitDelay = processWaitDelay
 
 	// create a temp dir for the artifact
 	artifact, err := os.MkdirTemp("", "artifact")
@@ -109,7 +134,7 @@ func (l *LocalRunner) Run(ctx context.Context, cfg *config.Linter) (io.ReadClose
 	}
 	defer os.RemoveAll(artifact)
 	c.Env = append(os.Environ(), fmt.Sprintf("ARTIFACT=%s", artifact))
-	c.Env = append(c.Env, newCfg.Env...)
+	c.Env = append(c.Env, env.env...)
 
 	log.Infof("run command: %v, workDir: %v", c, c.Dir)
 	output, execErr := c.CombinedOutput()
@@ -161,6 +186,7 @@ type DockerClientInterface interface {
 	ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error)
 	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
 	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	ContainerKill(ctx context.Context, containerID, signal string) error
 	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error)
 	ContainerStatPath(ctx context.Context, containerID, path string) (container.PathStat, error)
 }
@@ -109,6 +109,121 @@ func TestLocalRunner(t *testing.T) {
 	}
 }
 
+func TestLocalRunnerSecretEnv(t *testing.T) {
+	t.Setenv("REVIEWBOT_TEST_TOKEN", "env-secret-value")
+	secretFile := t.TempDir() + "/token"
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret-value\n"), 0o600))
+
+	lr := runner.NewLocalRunner()
+	cfg := &config.Linter{
+		Command: []string{"/bin/sh", "-c"},
+		Args:    []string{"echo $TOKEN; echo $FILE_TOKEN"},
+		Env: []string{
+			"TOKEN=${env:REVIEWBOT_TEST_TOKEN}",
+			"FILE_TOKEN=prefix-${file:" + secretFile + "}",
+		},
+		Modifier: config.NewBaseModifier(),
+	}
+	ctx := context.WithValue(context.Background(), util.EventGUIDKey, "test")
+	output, err := lr.Run(ctx, cfg)
+	require.NoError(t, err)
+	defer output.Close()
+
+	content, err := io.ReadAll(output)
+	require.NoError(t, err)
+	require.Equal(t, "env-secret-value\nprefix-file-secret-value\n", string(content))
+	require.Equal(t, "******\nprefix-******\n", lr.Redact(string(content)))
+
+	// the kubernetes secrets can not be resolved by the local runner
+	cfg.Env = []string{"TOKEN=${k8s:npm/token}"}
+	_, err = runner.NewLocalRunner().Run(ctx, cfg)
+	require.ErrorIs(t, err, runner.ErrK8sSecretNotSupported)
+
+	cfg.Env = []string{"TOKEN=${env:REVIEWBOT_TEST_TOKEN_NOT_EXIST}"}
+	_, err = runner.NewLocalRunner().Run(ctx, cfg)
+	require.ErrorIs(t, err, runner.ErrSecretNotFound)
+}
+
+func TestLocalRunnerTimeout(t *testing.T) {
+	lr := runner.NewLocalRunner()
+	cfg := &config.Linter{
+		Command: []string{"/bin/sh", "-c"},
+		// the sleep is a child process of the shell, which must be killed as well
+		Args:     []string{"echo started; sleep 30 | cat; echo finished"},
+		Modifier: config.NewBaseModifier(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), util.EventGUIDKey, "test"), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	output, err := lr.Run(ctx, cfg)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.NotNil(t, output)
+	defer output.Close()
+
+	content, err := io.ReadAll(output)
+	require.NoError(t, err)
+	require.Equal(t, "started\n", string(content))
+}
+
+func TestDockerRunnerTimeout(t *testing.T) {
+	mockCli := new(MockDockerClient)
+	mockCli.On("ImageInspectWithRaw", mock.Anything, mock.Anything).Return(types.ImageInspect{}, []byte{}, nil)
+	mockCli.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(container.CreateResponse{ID: "test-container-id"}, nil)
+	mockCli.On("CopyToContainer", mock.Anything, "test-container-id", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockCli.On("ContainerStart", mock.Anything, "test-container-id", mock.Anything).Return(nil)
+	// the container never exits until the context is done
+	waitRespCh := make(chan container.WaitResponse)
+	errCh := make(chan error, 1)
+	mockCli.On("ContainerWait", mock.Anything, "test-container-id", mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			go func() {
+				<-ctx.Done()
+				errCh <- ctx.Err()
+			}()
+		}).Return(waitRespCh, errCh)
+	mockCli.On("ContainerKill", mock.Anything, "test-container-id", "SIGKILL").Return(nil)
+	mockCli.On("ContainerLogs", mock.Anything, "test-container-id", mock.Anything).
+		// with the 8 bytes header of docker logs
+		Return(&mockReadCloser{Reader: strings.NewReader("\x01\x00\x00\x00\x00\x00\x00\x0fpartial output\n")}, nil)
+
+	mockArchiveWrapper := new(MockArchiveWrapper)
+	mockArchiveWrapper.On("CopyInfoSourcePath", mock.Anything, mock.Anything).Return(archive.CopyInfo{}, nil)
+	mockArchiveWrapper.On("TarResource", mock.Anything).Return(io.NopCloser(bytes.NewReader([]byte{})), nil)
+	mockArchiveWrapper.On("PrepareArchiveCopy", mock.Anything, mock.Anything, mock.Anything).Return("dstDir", io.NopCloser(bytes.NewReader([]byte{})), nil)
+
+	dr := runner.DockerRunner{
+		Cli:            mockCli,
+		ArchiveWrapper: mockArchiveWrapper,
+	}
+	cfg := &config.Linter{
+		DockerAsRunner: config.DockerAsRunner{
+			Image: "alpine:latest",
+		},
+		Command:  []string{"sleep"},
+		Args:     []string{"30"},
+		Modifier: config.NewBaseModifier(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), util.EventGUIDKey, "test"), 100*time.Millisecond)
+	defer cancel()
+
+	output, err := dr.Run(ctx, cfg)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, output)
+	defer output.Close()
+
+	content, err := io.ReadAll(output)
+	require.NoError(t, err)
+	require.Equal(t, "partial output\n", string(content))
+
+	mockCli.AssertExpectations(t)
+}
+
 func TestDockerRunner(t *testing.T) {
 	tcs := []struct {
 		name         string
@@ -315,6 +430,11 @@ func (m *MockDockerClient) ContainerWait(ctx context.Context, containerID string
 	return args.Get(0).(chan container.WaitResponse), args.Get(1).(chan error)
 }
 
+func (m *MockDockerClient) ContainerKill(ctx context.Context, containerID, signal string) error {
+	args := m.Called(ctx, containerID, signal)
+	return args.Error(0)
+}
+
 func (m *MockDockerClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
 	args := m.Called(ctx, imageID)
 	return args.Get(0).(types.ImageInspect), args.Get(1).([]byte), args.Error(2)
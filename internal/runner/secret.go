@@ -0,0 +1,114 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qiniu/reviewbot/config"
+)
+
+var (
+	ErrSecretNotFound        = errors.New("secret not found")
+	ErrK8sSecretNotSupported = errors.New("kubernetes secret references are only supported by the kubernetes runner")
+)
+
+// minRedactedSecretLength is the min length of the secrets to redact,
+// the shorter ones are not redacted since replacing them would mess up the whole output.
+const minRedactedSecretLength = 4
+
+// redactedSecret is the placeholder of the redacted secrets.
+const redactedSecret = "******"
+
+// resolvedEnv is the env of the linter with the secret references resolved.
+type resolvedEnv struct {
+	// env is the resolved env, in the form of NAME=value.
+	env []string
+	// k8sSecrets is the env referring to the Kubernetes secrets, keyed by the env name.
+	k8sSecrets map[string]config.SecretRef
+	// secrets is the resolved secret values, which should be redacted.
+	secrets []string
+}
+
+// resolveEnv resolves the ${env:NAME} and ${file:/path} references in the env from the reviewbot process,
+// the ${k8s:secret-name/key} references are left to the runner.
+func resolveEnv(env []string) (resolvedEnv, error) {
+	var r resolvedEnv
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		refs, err := config.ParseSecretRefs(value)
+		if err != nil {
+			return r, fmt.Errorf("env %s: %w", name, err)
+		}
+		if len(refs) == 0 {
+			r.env = append(r.env, e)
+			continue
+		}
+
+		if refs[0].Source == config.SecretSourceK8s {
+			if r.k8sSecrets == nil {
+				r.k8sSecrets = make(map[string]config.SecretRef)
+			}
+			r.k8sSecrets[name] = refs[0]
+			continue
+		}
+
+		for _, ref := range refs {
+			secret, err := resolveSecret(ref)
+			if err != nil {
+				return r, fmt.Errorf("env %s: %w", name, err)
+			}
+			value = strings.ReplaceAll(value, ref.Ref, secret)
+			r.secrets = append(r.secrets, secret)
+		}
+		r.env = append(r.env, name+"="+value)
+	}
+	return r, nil
+}
+
+func resolveSecret(ref config.SecretRef) (string, error) {
+	switch ref.Source {
+	case config.SecretSourceEnv:
+		secret, ok := os.LookupEnv(ref.Name)
+		if !ok {
+			return "", fmt.Errorf("%w: env %s", ErrSecretNotFound, ref.Name)
+		}
+		return secret, nil
+	case config.SecretSourceFile:
+		content, err := os.ReadFile(ref.Name)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrK8sSecretNotSupported, ref.Ref)
+	}
+}
+
+// redact replaces the secrets in s.
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if len(secret) < minRedactedSecretLength {
+			continue
+		}
+		s = strings.ReplaceAll(s, secret, redactedSecret)
+	}
+	return s
+}
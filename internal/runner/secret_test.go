@@ -0,0 +1,57 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestContainerEnv(t *testing.T) {
+	t.Setenv("REVIEWBOT_TEST_TOKEN", "env-secret-value")
+
+	env, err := resolveEnv([]string{
+		"GOFLAGS=-mod=mod",
+		"NPM_TOKEN=${k8s:npm-secret/token}",
+		"GITHUB_TOKEN=${env:REVIEWBOT_TEST_TOKEN}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []corev1.EnvVar{
+		{Name: "GOFLAGS", Value: "-mod=mod"},
+		{Name: "GITHUB_TOKEN", Value: "env-secret-value"},
+		{
+			Name: "NPM_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "npm-secret"},
+					Key:                  "token",
+				},
+			},
+		},
+	}
+	if got := containerEnv(env); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if !reflect.DeepEqual(env.secrets, []string{"env-secret-value"}) {
+		t.Errorf("expected the resolved secrets to redact, got %v", env.secrets)
+	}
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"expvar"
 	"flag"
@@ -26,13 +27,18 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/google/go-github/v57/github"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
 	"github.com/qiniu/reviewbot/internal/llm"
+	"github.com/qiniu/reviewbot/internal/queue"
 	"github.com/qiniu/reviewbot/internal/storage"
 	"github.com/qiniu/reviewbot/internal/version"
 	"github.com/qiniu/x/log"
@@ -52,6 +58,12 @@ import (
 	_ "github.com/qiniu/reviewbot/internal/linters/shell/shellcheck"
 )
 
+const (
+	// shutdownTimeout is the max time to wait for the in-flight webhooks when shutting down.
+	shutdownTimeout   = 30 * time.Second
+	readHeaderTimeout = 10 * time.Second
+)
+
 type options struct {
 	port          int
 	dryRun        bool
@@ -86,6 +98,14 @@ type options struct {
 	llmModel     string
 	llmServerURL string
 	llmAPIKey    string
+
+	// linter concurrency related
+	maxLinterConcurrency      int
+	maxLinterConcurrencyPerPR int
+
+	// job queue related
+	queueDir string
+	workers  int
 }
 
 var (
@@ -93,6 +113,7 @@ var (
 	errWebHookNotSet   = errors.New("webhook-secret is required")
 	errLLMKeyNotSet    = errors.New("llm api key is not set")
 	errLLMServerNotSet = errors.New("llm model or server url is not set")
+	errWorkersNotSet   = errors.New("workers must be positive")
 )
 
 func (o options) Validate() error {
@@ -104,6 +125,10 @@ func (o options) Validate() error {
 		return errWebHookNotSet
 	}
 
+	if o.workers <= 0 {
+		return errWorkersNotSet
+	}
+
 	if o.llmProvider != "" {
 		switch o.llmProvider {
 		case "openai":
@@ -151,6 +176,12 @@ func gatherOptions() options {
 	fs.StringVar(&o.llmServerURL, "llm.server-url", "", "llm server url")
 	fs.StringVar(&o.llmAPIKey, "llm.api-key", "", "llm api key")
 
+	fs.IntVar(&o.maxLinterConcurrency, "max-linter-concurrency", runtime.NumCPU(), "max number of linters running concurrently across all PRs, 0 means no limit")
+	fs.IntVar(&o.maxLinterConcurrencyPerPR, "max-linter-concurrency-per-pr", 4, "max number of linters running concurrently for one PR")
+
+	fs.StringVar(&o.queueDir, "queue-dir", "/tmp/reviewbot-queue", "dir to persist the queued webhook events, the unfinished ones are resumed after restart")
+	fs.IntVar(&o.workers, "workers", 4, "number of webhook events processed concurrently")
+
 	err := fs.Parse(os.Args[1:])
 	if err != nil {
 		log.Fatalf("failed to parse flags: %v", err)
@@ -373,6 +404,18 @@ func main() {
 		fmt.Println(version.Version())
 		return
 	}
+	if len(os.Args) >= 2 && os.Args[1] == "baseline" {
+		if err := runBaseline(os.Args[2:]); err != nil {
+			log.Fatalf("failed to generate baseline: %v", err)
+		}
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "config" {
+		if err := runConfigCommand(os.Args[2:]); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+		return
+	}
 	o := gatherOptions()
 	if err := o.Validate(); err != nil {
 		log.Fatalf("invalid options: %v", err)
@@ -405,6 +448,9 @@ func main() {
 		if err != nil {
 			log.Fatalf("failed to load config: %v", err)
 		}
+		if err := lint.ValidateFilters(cfg); err != nil {
+			log.Fatalf("invalid filters in config: %v", err)
+		}
 	}
 
 	modelConfig := llm.Config{
@@ -417,7 +463,7 @@ func main() {
 	s := &Server{
 		webhookSecret:             []byte(o.webhookSecret),
 		gitClientFactory:          v2,
-		config:                    cfg,
+		configFile:                o.config,
 		debug:                     o.debug,
 		serverAddr:                o.serverAddr,
 		repoCacheDir:              o.codeCacheDir,
@@ -425,6 +471,11 @@ func main() {
 		gitLabHost:                o.gitLabHost,
 		gitLabPersonalAccessToken: o.gitLabPersonalAccessToken,
 		modelConfig:               modelConfig,
+		maxLinterConcurrencyPerPR: o.maxLinterConcurrencyPerPR,
+		builtinLinters:            registeredLinters(),
+	}
+	if o.maxLinterConcurrency > 0 {
+		s.linterSemaphore = make(chan struct{}, o.maxLinterConcurrency)
 	}
 
 	// github access token
@@ -443,9 +494,19 @@ func main() {
 		}
 	}
 
+	s.config.Store(&cfg)
+
+	// ctx is canceled on SIGTERM or SIGINT to shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
 	go s.initDockerRunner()
 	go s.initKubernetesRunner()
-	s.initCustomLinters()
+	if o.config != "" {
+		if err := s.watchConfig(ctx); err != nil {
+			log.Errorf("failed to watch config, hot reload is disabled: %v", err)
+		}
+	}
 	if o.llmProvider != "" {
 		s.initLLMModel()
 	}
@@ -463,6 +524,16 @@ func main() {
 		}
 	}
 
+	s.jobQueue, err = queue.New(queue.Options{Dir: o.queueDir, Workers: o.workers}, s.handleJob)
+	if err != nil {
+		log.Fatalf("failed to create job queue: %v", err)
+	}
+	queueStopped := make(chan struct{})
+	go func() {
+		defer close(queueStopped)
+		s.jobQueue.Run(ctx)
+	}()
+
 	mux := http.NewServeMux()
 	mux.Handle("/", s)
 	mux.Handle("/view/", http.HandlerFunc(s.HandleView))
@@ -480,12 +551,30 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to listen: %v\n", err)
 	}
-	defer listener.Close()
 	log.Infof("debug port running in: %s\n", listener.Addr().String())
+	debugServer := &http.Server{Handler: debugMux, ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		if err := debugServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	server := &http.Server{Addr: fmt.Sprintf(":%d", o.port), Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
 	go func() {
-		log.Fatal(http.Serve(listener, debugMux))
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
 	}()
-	// TODO(CarlJi): graceful shutdown
-	//nolint:gocritic
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", o.port), mux))
+
+	<-ctx.Done()
+	log.Infof("shutting down, waiting for the running jobs to stop")
+	// stop accepting the webhooks first, the jobs not finished are kept in the queue dir to run after restart
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("failed to shut down the server: %v", err)
+	}
+	_ = debugServer.Close()
+	<-queueStopped
+	log.Infof("shut down")
 }
@@ -0,0 +1,140 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
+	"github.com/qiniu/x/log"
+)
+
+// reloadDebounce merges the burst of file events, e.g. editors usually write the file more than once.
+const reloadDebounce = 500 * time.Millisecond
+
+var errKubernetesRunnerNotInitialized = errors.New("kubernetes runner is not initialized, restart is required to use it")
+
+// watchConfig reloads the config when the config file is changed or SIGHUP is received, until ctx is done.
+func (s *Server) watchConfig(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// NOTE: watch the dir rather than the file, since editors and kubernetes configmaps
+	// replace the file instead of writing it, which stops the watch on the file.
+	configFile := filepath.Clean(s.configFile)
+	if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+		defer signal.Stop(sighup)
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// kubernetes configmaps are updated by swapping the ..data symlink
+				if filepath.Clean(event.Name) == configFile || filepath.Base(event.Name) == "..data" {
+					debounce = time.After(reloadDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorf("config watcher error: %v", err)
+			case <-sighup:
+				log.Infof("SIGHUP received, reload config")
+				s.reloadConfigAndLog()
+			case <-debounce:
+				debounce = nil
+				s.reloadConfigAndLog()
+			}
+		}
+	}()
+
+	log.Infof("watching config file %s", configFile)
+	return nil
+}
+
+func (s *Server) reloadConfigAndLog() {
+	if err := s.reloadConfig(); err != nil {
+		log.Errorf("failed to reload config, keep the current one: %v", err)
+	}
+}
+
+// reloadConfig re-parses and re-validates the config file, and swaps it into the server if valid.
+// The runs in flight keep using the snapshot of the old config.
+func (s *Server) reloadConfig() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	cfg, err := config.NewConfig(s.configFile)
+	if err != nil {
+		return err
+	}
+	if err := lint.ValidateFilters(cfg); err != nil {
+		return err
+	}
+	if len(kubernetesRunners(cfg)) > 0 && s.kubernetesRunner.Load() == nil {
+		return errKubernetesRunnerNotInitialized
+	}
+
+	old := s.currentConfig()
+	changes := config.Diff(old, cfg)
+	if len(changes) == 0 {
+		log.Infof("config is not changed")
+		return nil
+	}
+
+	s.config.Store(&cfg)
+	for _, change := range changes {
+		log.Infof("config reloaded, %s", change)
+	}
+
+	// pre-pull the new images
+	existed := make(map[string]bool)
+	for _, image := range dockerImages(old) {
+		existed[image] = true
+	}
+	var images []string
+	for _, image := range dockerImages(cfg) {
+		if !existed[image] {
+			existed[image] = true
+			images = append(images, image)
+		}
+	}
+	return s.pullDockerImages(images)
+}
@@ -0,0 +1,61 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
+	"github.com/qiniu/reviewbot/internal/util"
+)
+
+// readFileAtRef reads the file at the git ref of the repository, nil if the file doesn't exist in the ref.
+func readFileAtRef(repoDir, ref, file string) ([]byte, error) {
+	if err := exec.Command("git", "-C", repoDir, "cat-file", "-e", ref+":"+file).Run(); err != nil {
+		return nil, nil
+	}
+	return exec.Command("git", "-C", repoDir, "show", ref+":"+file).Output()
+}
+
+// loadBaseline loads the baseline of the repo from the base branch rather than the PR itself,
+// so that the PR can not accept its own findings by changing the baseline.
+func loadBaseline(repoDir, baseRef string) (*lint.Baseline, error) {
+	data, err := readFileAtRef(repoDir, baseRef, lint.BaselineFile)
+	if err != nil {
+		return nil, err
+	}
+	return lint.ParseBaseline(data)
+}
+
+// withRepoConfig merges the repo-local config into the central config.
+// The invalid repo-local config is ignored, so that the linters still run with the central config.
+func withRepoConfig(ctx context.Context, cfg config.Config, org, repo string, data []byte) config.Config {
+	log := util.FromContext(ctx)
+	if data == nil {
+		return cfg
+	}
+
+	rc, err := cfg.ParseRepoConfig(data)
+	if err != nil {
+		log.Errorf("invalid %s of %s/%s, ignore it: %v", config.RepoConfigFile, org, repo, err)
+		return cfg
+	}
+	log.Infof("merge %s of %s/%s into the config", config.RepoConfigFile, org, repo)
+	return cfg.WithRepoConfig(org, repo, rc)
+}
@@ -0,0 +1,140 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
+	"github.com/qiniu/x/xlog"
+)
+
+func TestLoadBaselineFromBaseRef(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(file, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const linterName = "golangci-lint"
+	oldFinding := lint.LinterOutput{File: "main.go", Line: 1, Message: "old finding"}
+	newFinding := lint.LinterOutput{File: "main.go", Line: 2, Message: "new finding"}
+	saveBaseline := func(outputs ...lint.LinterOutput) {
+		t.Helper()
+		b := &lint.Baseline{}
+		b.Update(dir, linterName, map[string][]lint.LinterOutput{"main.go": outputs})
+		if err := b.Save(filepath.Join(dir, lint.BaselineFile)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("main.go", "old()\n")
+	saveBaseline(oldFinding)
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+	git("tag", "base")
+
+	// the PR adds a new finding and accepts it in the baseline at the same time
+	write("main.go", "old()\nnew()\n")
+	saveBaseline(oldFinding, newFinding)
+	git("add", "-A")
+	git("commit", "-q", "-m", "pr")
+
+	baseline, err := loadBaseline(dir, "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := lint.Agent{
+		LinterConfig: config.Linter{Name: linterName, Filters: []string{lint.FilterBaseline}},
+		RepoDir:      dir,
+		Baseline:     baseline,
+	}
+	results := map[string][]lint.LinterOutput{"main.go": {oldFinding, newFinding}}
+	got, err := lint.Filters(xlog.New("test"), a, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]lint.LinterOutput{"main.go": {newFinding}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// no baseline in the base ref
+	baseline, err = loadBaseline(dir, "base~1")
+	if err != nil || baseline != nil {
+		t.Errorf("expected no baseline, got %v, %v", baseline, err)
+	}
+}
+
+func TestBaselineUnderPathAlias(t *testing.T) {
+	workspace := t.TempDir()
+	// the repo is checked out under the path alias rather than the repo name
+	workDir := filepath.Join(workspace, "src", "github.com", "qiniu", "reviewbot")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "main.go"), []byte("old()\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	const linterName = "golangci-lint"
+	finding := lint.LinterOutput{File: "main.go", Line: 1, Message: "old finding"}
+	results := map[string][]lint.LinterOutput{"main.go": {finding}}
+	// the baseline is generated from the checkout, see `reviewbot baseline`
+	baseline := &lint.Baseline{}
+	baseline.Update(workDir, linterName, results)
+
+	s := &Server{}
+	info := &codeRequestInfo{
+		platform: config.GitHub,
+		org:      "qiniu",
+		repo:     "reviewbot",
+		orgRepo:  "qiniu/reviewbot",
+		workDir:  workDir,
+		repoDir:  workspace,
+		provider: fakeProvider{files: []string{"main.go"}},
+		baseline: baseline,
+	}
+	a, ok := s.newAgent(context.Background(), info, linterName, []string{".go"})
+	if !ok {
+		t.Fatal("expected the linter to be related")
+	}
+	a.LinterConfig.Filters = []string{lint.FilterBaseline}
+	got, err := lint.Filters(xlog.New("test"), a, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected the finding suppressed by the baseline, got %v", got)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
@@ -40,6 +41,7 @@ import (
 	"github.com/qiniu/reviewbot/config"
 	"github.com/qiniu/reviewbot/internal/lint"
 	"github.com/qiniu/reviewbot/internal/llm"
+	"github.com/qiniu/reviewbot/internal/queue"
 	"github.com/qiniu/reviewbot/internal/runner"
 	"github.com/qiniu/reviewbot/internal/storage"
 	"github.com/qiniu/reviewbot/internal/util"
@@ -56,22 +58,32 @@ var (
 )
 
 var (
-	ErrPrepareDir = errors.New("failed to prepare repo dir")
+	ErrPrepareDir     = errors.New("failed to prepare repo dir")
+	errUnknownJobKind = errors.New("unknown job kind")
 )
 
 type Server struct {
 	gitClientFactory gitv2.ClientFactory
-	config           config.Config
-	storage          storage.Storage
+	// config is swapped atomically when reloaded, use currentConfig to get the snapshot.
+	config     atomic.Pointer[config.Config]
+	configFile string
+	// reloadMu serializes the config reloads.
+	reloadMu sync.Mutex
+	// builtinLinters is the linters registered by the code, which are overridden by the custom linters of the config.
+	builtinLinters map[string]linterHandler
+	storage        storage.Storage
 	// server addr which is used to generate the log view url
 	// e.g. https://domain
-	serverAddr          string
-	getDockerRunner     func() runner.Runner
-	getKubernetesRunner func() runner.Runner
-	kubeConfig          string
-	webhookSecret       []byte
-	debug               bool
-	repoCacheDir        string
+	serverAddr string
+	// dockerRunner is initialized on demand, which may happen when the config is reloaded
+	// while the workers are running, so it's accessed atomically, use getDockerRunner to get one.
+	dockerRunner atomic.Pointer[runner.Runner]
+	// kubernetesRunner is initialized in background at startup, use getKubernetesRunner to get one.
+	kubernetesRunner atomic.Pointer[runner.Runner]
+	kubeConfig       string
+	webhookSecret    []byte
+	debug            bool
+	repoCacheDir     string
 
 	// support gitlab
 	gitLabHost                string
@@ -84,8 +96,19 @@ type Server struct {
 	// llm model related
 	modelConfig llm.Config
 	modelClient llms.Model
+
+	// linterSemaphore limits the number of linters running concurrently across all PRs, nil means no limit.
+	linterSemaphore chan struct{}
+	// maxLinterConcurrencyPerPR is the max number of linters running concurrently for one PR.
+	maxLinterConcurrencyPerPR int
+
+	// jobQueue persists the webhook events, which are processed by a fixed number of workers.
+	jobQueue *queue.Queue
 }
 
+// defaultLinterConcurrencyPerPR is used if the per-PR concurrency is not set.
+const defaultLinterConcurrencyPerPR = 4
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("X-Gitlab-Event") != "" {
 		s.serveGitLab(w, r)
@@ -116,42 +139,33 @@ func (s *Server) serveGitHub(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Fprint(w, "Event received. Have a nice day.")
-
+	job := &queue.Job{
+		Kind:      jobKind(config.GitHub, github.WebHookType(r)),
+		EventGUID: eventGUID,
+		Payload:   payload,
+	}
 	switch event := event.(type) {
 	case *github.IssueCommentEvent:
-		go func() {
-			if err := s.processIssueCommentEvent(ctx, event); err != nil {
-				log.Errorf("process issue comment event: %v", err)
-			}
-		}()
+		job.Org = event.GetRepo().GetOwner().GetLogin()
 	case *github.PullRequestReviewCommentEvent:
-		go func() {
-			if err := s.processPullRequestReviewCommentEvent(ctx, event); err != nil {
-				log.Errorf("process pull request review comment event: %v", err)
-			}
-		}()
+		job.Org = event.GetRepo().GetOwner().GetLogin()
 	case *github.PullRequestEvent:
-		go func() {
-			if err := s.processPullRequestEvent(ctx, event); err != nil {
-				log.Errorf("process pull request event: %v", err)
-			}
-		}()
+		job.Org = event.GetRepo().GetOwner().GetLogin()
+		if isPullRequestRunAction(event.GetAction()) {
+			// the pending run of the same PR is outdated
+			job.Key = codeRequestID(config.GitHub, job.Org, event.GetRepo().GetName(), event.GetPullRequest().GetNumber())
+		}
 	case *github.CheckRunEvent:
-		go func() {
-			if err := s.processCheckRunRequestEvent(ctx, event); err != nil {
-				log.Errorf("process check run request event: %v", err)
-			}
-		}()
+		job.Org = event.GetRepo().GetOwner().GetLogin()
 	case *github.CheckSuiteEvent:
-		go func() {
-			if err := s.processCheckSuiteEvent(ctx, event); err != nil {
-				log.Errorf("process check run request event: %v", err)
-			}
-		}()
+		job.Org = event.GetRepo().GetOwner().GetLogin()
 	default:
+		fmt.Fprint(w, "Event received. Have a nice day.")
 		log.Debugf("skipping event type %s\n", github.WebHookType(r))
+		return
 	}
+
+	s.enqueue(w, job)
 }
 
 func (s *Server) serveGitLab(w http.ResponseWriter, r *http.Request) {
@@ -179,27 +193,99 @@ func (s *Server) serveGitLab(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	switch event := event.(type) {
+	case *gitlab.MergeEvent:
+		job := &queue.Job{
+			Kind:      jobKind(config.GitLab, string(v)),
+			Org:       event.Project.Namespace,
+			EventGUID: eventGUID,
+			Payload:   payload,
+		}
+		if isMergeRequestRunState(event.ObjectAttributes.State) {
+			// the pending run of the same MR is outdated
+			job.Key = codeRequestID(config.GitLab, event.Project.Namespace, event.Project.Name, event.ObjectAttributes.IID)
+		}
+		s.enqueue(w, job)
+	default:
+		fmt.Fprint(w, "Event received. Have a nice day.")
+		log.Debugf("skipping gitlab event %v\n", event)
+	}
+}
+
+// enqueue persists the event to be processed by the workers, the webhook is failed if it can't be persisted
+// so that the platform can redeliver it.
+func (s *Server) enqueue(w http.ResponseWriter, job *queue.Job) {
+	if err := s.jobQueue.Enqueue(job); err != nil {
+		log.Errorf("failed to enqueue event %s of %s: %v", job.EventGUID, job.Kind, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	fmt.Fprint(w, "Event received. Have a nice day.")
+}
+
+// jobKind is the kind of the queued job of the webhook event, e.g. "GitHub/pull_request".
+func jobKind(platform config.Platform, eventType string) string {
+	return string(platform) + "/" + eventType
+}
+
+// handleJob processes the webhook event queued.
+func (s *Server) handleJob(ctx context.Context, job *queue.Job) error {
+	ctx = context.WithValue(ctx, util.EventGUIDKey, job.EventGUID)
+	platform, eventType, _ := strings.Cut(job.Kind, "/")
+	switch config.Platform(platform) {
+	case config.GitHub:
+		event, err := github.ParseWebHook(eventType, job.Payload)
+		if err != nil {
+			return queue.Permanent(err)
+		}
+		return s.processGitHubEvent(ctx, event)
+	case config.GitLab:
+		event, err := gitlab.ParseHook(gitlab.EventType(eventType), job.Payload)
+		if err != nil {
+			return queue.Permanent(err)
+		}
+		return s.processGitLabEvent(ctx, event)
+	default:
+		return queue.Permanent(fmt.Errorf("%w: %s", errUnknownJobKind, job.Kind))
+	}
+}
 
+func (s *Server) processGitHubEvent(ctx context.Context, event interface{}) error {
+	switch event := event.(type) {
+	case *github.IssueCommentEvent:
+		return s.processIssueCommentEvent(ctx, event)
+	case *github.PullRequestReviewCommentEvent:
+		return s.processPullRequestReviewCommentEvent(ctx, event)
+	case *github.PullRequestEvent:
+		return s.processPullRequestEvent(ctx, event)
+	case *github.CheckRunEvent:
+		return s.processCheckRunRequestEvent(ctx, event)
+	case *github.CheckSuiteEvent:
+		return s.processCheckSuiteEvent(ctx, event)
+	default:
+		return queue.Permanent(fmt.Errorf("%w: %T", errUnknownJobKind, event))
+	}
+}
+
+func (s *Server) processGitLabEvent(ctx context.Context, event interface{}) error {
 	switch event := event.(type) {
 	case *gitlab.MergeEvent:
-		go func() {
-			if err := s.processMergeRequestEvent(ctx, event); err != nil {
-				log.Errorf("process merge request event: %v", err)
-			}
-		}()
+		return s.processMergeRequestEvent(ctx, event)
 	default:
-		log.Debugf("skipping gitlab event %v\n", event)
+		return queue.Permanent(fmt.Errorf("%w: %T", errUnknownJobKind, event))
 	}
 }
 
 func (s *Server) handleGitHubEvent(ctx context.Context, event *github.PullRequestEvent) error {
 	info := &codeRequestInfo{
-		platform: config.GitHub,
-		num:      event.GetPullRequest().GetNumber(),
-		org:      event.GetRepo().GetOwner().GetLogin(),
-		repo:     event.GetRepo().GetName(),
-		orgRepo:  event.GetRepo().GetOwner().GetLogin() + "/" + event.GetRepo().GetName(),
+		platform:   config.GitHub,
+		num:        event.GetPullRequest().GetNumber(),
+		org:        event.GetRepo().GetOwner().GetLogin(),
+		repo:       event.GetRepo().GetName(),
+		orgRepo:    event.GetRepo().GetOwner().GetLogin() + "/" + event.GetRepo().GetName(),
+		baseRef:    event.GetPullRequest().GetBase().GetSHA(),
+		baseBranch: event.GetPullRequest().GetBase().GetRef(),
+		config:     s.currentConfig(),
 	}
 
 	return s.withCancel(ctx, info, func(ctx context.Context) error {
@@ -216,13 +302,15 @@ func (s *Server) handleGitHubEvent(ctx context.Context, event *github.PullReques
 		}
 		platformInfo.GitHubAppName = appName
 
-		provider, err := lint.NewGithubProvider(ctx, s.GithubClient(installationID), *event, lint.WithGitHubProviderInfo(platformInfo))
+		githubClient := s.GithubClient(installationID)
+		info.issueContentGetter = s.issueContentGetter(githubClient)
+		provider, err := lint.NewGithubProvider(ctx, githubClient, *event, lint.WithGitHubProviderInfo(platformInfo))
 		if err != nil {
 			return err
 		}
 		info.provider = provider
 
-		workspace, workDir, err := s.prepareGitRepos(ctx, info.org, info.repo, info.num, config.GitHub, installationID, provider)
+		workspace, workDir, err := s.prepareGitRepos(ctx, info.config, info.org, info.repo, info.num, config.GitHub, installationID, provider)
 		if err != nil {
 			return err
 		}
@@ -306,11 +394,14 @@ func (s *Server) getAccountName(ctx context.Context) (string, error) {
 
 func (s *Server) handleGitLabEvent(ctx context.Context, event *gitlab.MergeEvent) error {
 	info := &codeRequestInfo{
-		platform: config.GitLab,
-		num:      event.ObjectAttributes.IID,
-		org:      event.Project.Namespace,
-		repo:     event.Project.Name,
-		orgRepo:  event.Project.Namespace + "/" + event.Project.Name,
+		platform:   config.GitLab,
+		num:        event.ObjectAttributes.IID,
+		org:        event.Project.Namespace,
+		repo:       event.Project.Name,
+		orgRepo:    event.Project.Namespace + "/" + event.Project.Name,
+		baseRef:    "origin/" + event.ObjectAttributes.TargetBranch,
+		baseBranch: event.ObjectAttributes.TargetBranch,
+		config:     s.currentConfig(),
 	}
 
 	return s.withCancel(ctx, info, func(ctx context.Context) error {
@@ -323,6 +414,7 @@ func (s *Server) handleGitLabEvent(ctx context.Context, event *gitlab.MergeEvent
 			platformInfo.Host = "gitlab.com"
 		}
 
+		info.issueContentGetter = s.issueContentGetter(nil)
 		gitlabProvider, err := lint.NewGitlabProvider(ctx, s.GitLabClient(), *event, lint.WithGitlabProviderInfo(platformInfo))
 		if err != nil {
 			log.Errorf("failed to create provider: %v", err)
@@ -330,7 +422,7 @@ func (s *Server) handleGitLabEvent(ctx context.Context, event *gitlab.MergeEvent
 		}
 		info.provider = gitlabProvider
 
-		workspace, workDir, err := s.prepareGitRepos(ctx, info.org, info.repo, info.num, config.GitLab, 0, gitlabProvider)
+		workspace, workDir, err := s.prepareGitRepos(ctx, info.config, info.org, info.repo, info.num, config.GitLab, 0, gitlabProvider)
 		if err != nil {
 			log.Errorf("prepare repo dir failed: %v", err)
 			return ErrPrepareDir
@@ -356,94 +448,216 @@ type codeRequestInfo struct {
 	orgRepo  string
 	workDir  string
 	repoDir  string
+	// baseRef is the git ref of the base branch, where the repo-local config is read from.
+	baseRef string
+	// baseBranch is the name of the base branch, to pick up the branch specific linter config.
+	baseBranch string
+	// config is the snapshot of the config when the event is received, merged with the repo-local config if exists.
+	// NOTE: it's kept during the whole run even if the config is reloaded.
+	config config.Config
 	// affectedFiles []string
 	provider lint.Provider
+	// baseline is the accepted findings of the repo, nil if not exists.
+	baseline *lint.Baseline
+	// generatedFileDetector detects the generated files of the repo.
+	generatedFileDetector lint.GeneratedFileDetector
+	// aggregator collects the results of all linters to merge duplicates before reporting.
+	aggregator *lint.Aggregator
+	// issueContentGetter fetches the content of the issue references with the credentials of the server.
+	issueContentGetter lint.IssueContentGetter
 }
 
 func (s *Server) handleCodeRequestEvent(ctx context.Context, info *codeRequestInfo) error {
 	log := util.FromContext(ctx)
 
-	for name, fn := range lint.TotalPullRequestHandlers() {
-		linterConfig := s.config.GetLinterConfig(info.org, info.repo, name, info.platform)
+	// NOTE: the repo-local config is read from the base branch rather than the PR itself,
+	// so that the PR can not change the config to bypass the linters.
+	data, err := readFileAtRef(info.workDir, info.baseRef, config.RepoConfigFile)
+	if err != nil {
+		log.Errorf("failed to read %s, ignore it: %v", config.RepoConfigFile, err)
+	}
+	info.config = withRepoConfig(ctx, info.config, info.org, info.repo, data)
+
+	// findings recorded in the baseline of the repo will not be reported
+	baseline, err := loadBaseline(info.workDir, info.baseRef)
+	if err != nil {
+		log.Errorf("failed to load baseline, ignore it: %v", err)
+	}
+	info.baseline = baseline
+	info.generatedFileDetector = lint.NewGeneratedFileDetector(info.workDir, info.config.GetGeneratedFiles(info.org, info.repo))
+	info.aggregator = lint.NewAggregator(info.config.GetMaxInlineCommentsPerPR(info.org, info.repo))
+
+	// limit the number of linters running concurrently for this PR
+	prSemaphore := make(chan struct{}, s.linterConcurrencyPerPR())
 
-		// skip if linter is not enabled
-		if linterConfig.Enable != nil && !*linterConfig.Enable {
+	var wg sync.WaitGroup
+	for name, linter := range s.linterHandlers(info.config) {
+		agent, ok := s.newAgent(ctx, info, name, linter.languages)
+		if !ok {
 			continue
 		}
 
-		// set work dir
-		if linterConfig.WorkDir != "" {
-			linterConfig.WorkDir = info.workDir + "/" + linterConfig.WorkDir
-		} else {
-			linterConfig.WorkDir = info.workDir
-		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				// one linter should not break the others
+				if r := recover(); r != nil {
+					log.Errorf("linter %s panic: %v", name, r)
+				}
+			}()
+
+			release, err := s.acquireLinterSlot(ctx, prSemaphore)
+			if err != nil {
+				log.Infof("linter %s is canceled before running: %v", name, err)
+				return
+			}
+			defer release()
+
+			// run linter finally
+			if err := linter.fn(ctx, agent); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return
+				}
+				log.Errorf("failed to run linter %s: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
 
-		// set workspace
-		linterConfig.Workspace = info.repoDir
+	// report the results of all linters together, the duplicates across linters are merged
+	if err := info.aggregator.Flush(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		log.Errorf("failed to report lint results: %v", err)
+	}
 
-		log.Infof("[%s] config on repo %v: %+v", name, info.orgRepo, linterConfig)
+	return nil
+}
 
-		agent := lint.Agent{
-			LinterConfig: linterConfig,
-			// workspace is the root dir of the repo
-			RepoDir:  linterConfig.Workspace + "/" + info.repo,
-			ID:       util.GetEventGUID(ctx),
-			Provider: info.provider,
-		}
+// newAgent creates the agent to run the given linter for the code request.
+// false is returned if the linter should be skipped.
+func (s *Server) newAgent(ctx context.Context, info *codeRequestInfo, name string, languages []string) (lint.Agent, bool) {
+	log := util.FromContext(ctx)
+	linterConfig := info.config.GetLinterConfigForBranch(info.org, info.repo, info.baseBranch, name, info.platform)
 
-		// skip if linter is not language related
-		if !lint.LinterRelated(linterConfig.Name, agent) {
-			log.Debugf("linter %s is not related, skipping", linterConfig.Name)
-			continue
-		}
+	// skip if linter is not enabled
+	if linterConfig.Enable != nil && !*linterConfig.Enable {
+		return lint.Agent{}, false
+	}
 
-		// set runner
-		r := runner.NewLocalRunner()
-		if linterConfig.DockerAsRunner.Image != "" {
-			r = s.getDockerRunner()
-		} else if linterConfig.KubernetesAsRunner.Image != "" {
-			r = s.getKubernetesRunner()
-		}
-		agent.Runner = r
+	// set work dir
+	if linterConfig.WorkDir != "" {
+		linterConfig.WorkDir = info.workDir + "/" + linterConfig.WorkDir
+	} else {
+		linterConfig.WorkDir = info.workDir
+	}
 
-		// set storage
-		agent.Storage = s.storage
+	// set workspace
+	linterConfig.Workspace = info.repoDir
+
+	log.Infof("[%s] config on repo %v: %+v", name, info.orgRepo, linterConfig)
+
+	agent := lint.Agent{
+		LinterConfig: linterConfig,
+		// the checkout of the main repo, which may be under the path alias rather than the repo name
+		RepoDir:  info.workDir,
+		ID:       util.GetEventGUID(ctx),
+		Provider: info.provider,
+	}
 
-		// generate log key
-		agent.GenLogKey = func() string {
-			return fmt.Sprintf("%s/%s/%s", agent.LinterConfig.Name, agent.Provider.GetCodeReviewInfo().Org+"/"+agent.Provider.GetCodeReviewInfo().Repo, agent.ID)
+	// skip if linter is not language related
+	if !lint.LanguagesRelated(languages, agent) {
+		log.Debugf("linter %s is not related, skipping", linterConfig.Name)
+		return lint.Agent{}, false
+	}
+
+	// set runner, each linter has its own runner since runner is not concurrency-safe
+	r := runner.NewLocalRunner()
+	if linterConfig.DockerAsRunner.Image != "" {
+		if r = s.getDockerRunner(); r == nil {
+			log.Errorf("docker runner is not initialized, skip linter %s", linterConfig.Name)
+			return lint.Agent{}, false
 		}
-		// generate log view url
-		agent.GenLogViewURL = func() string {
-			// if serverAddr is not provided, return empty string
-			if s.serverAddr == "" {
-				return ""
-			}
-			return s.serverAddr + "/view/" + agent.GenLogKey()
+	} else if linterConfig.KubernetesAsRunner.Image != "" {
+		if r = s.getKubernetesRunner(); r == nil {
+			log.Errorf("kubernetes runner is not initialized, skip linter %s", linterConfig.Name)
+			return lint.Agent{}, false
 		}
+	}
+	agent.Runner = r
 
-		// set issue references
-		agent.IssueReferences = s.config.GetCompiledIssueReferences(name)
-
-		// set model client
-		agent.ModelClient = s.modelClient
+	// set storage
+	agent.Storage = s.storage
 
-		// run linter finally
-		if err := fn(ctx, agent); err != nil {
-			if errors.Is(err, context.Canceled) {
-				return nil
-			}
-			log.Errorf("failed to run linter: %v", err)
-			continue
+	// generate log key
+	agent.GenLogKey = func() string {
+		return fmt.Sprintf("%s/%s/%s", agent.LinterConfig.Name, agent.Provider.GetCodeReviewInfo().Org+"/"+agent.Provider.GetCodeReviewInfo().Repo, agent.ID)
+	}
+	// generate log view url
+	agent.GenLogViewURL = func() string {
+		// if serverAddr is not provided, return empty string
+		if s.serverAddr == "" {
+			return ""
 		}
+		return s.serverAddr + "/view/" + agent.GenLogKey()
 	}
 
-	return nil
+	// set issue references
+	agent.IssueReferences = info.config.GetCompiledIssueReferences(name)
+	agent.IssueContentGetter = info.issueContentGetter
+
+	// set model client
+	agent.ModelClient = s.modelClient
+
+	// set baseline
+	agent.Baseline = info.baseline
+	agent.GeneratedFileDetector = info.generatedFileDetector
+	agent.Aggregator = info.aggregator
+
+	return agent, true
+}
+
+// linterConcurrencyPerPR returns the max number of linters running concurrently for one PR.
+func (s *Server) linterConcurrencyPerPR() int {
+	if s.maxLinterConcurrencyPerPR <= 0 {
+		return defaultLinterConcurrencyPerPR
+	}
+	return s.maxLinterConcurrencyPerPR
+}
+
+// acquireLinterSlot waits until both the per-PR and the global concurrency limits allow one more linter to run.
+// the returned release function must be called when the linter finished.
+func (s *Server) acquireLinterSlot(ctx context.Context, prSemaphore chan struct{}) (release func(), err error) {
+	select {
+	case prSemaphore <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if s.linterSemaphore == nil {
+		return func() { <-prSemaphore }, nil
+	}
+
+	select {
+	case s.linterSemaphore <- struct{}{}:
+	case <-ctx.Done():
+		<-prSemaphore
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-s.linterSemaphore
+		<-prSemaphore
+	}, nil
+}
+
+// codeRequestID identifies the PR/MR.
+func codeRequestID(platform config.Platform, org, repo string, num int) string {
+	return fmt.Sprintf("%s-%s-%s-%d", platform, org, repo, num)
 }
 
 func (s *Server) withCancel(ctx context.Context, info *codeRequestInfo, fn func(context.Context) error) error {
 	log := util.FromContext(ctx)
-	prID := fmt.Sprintf("%s-%s-%s-%d", info.platform, info.org, info.repo, info.num)
+	prID := codeRequestID(info.platform, info.org, info.repo, info.num)
 	if cancel, exists := prMap[prID]; exists {
 		log.Infof("Cancelling processing for Pull Request : %s\n", prID)
 		cancel()
@@ -476,28 +690,45 @@ func (s *Server) initLLMModel() {
 	s.modelClient = modelClient
 }
 
-func (s *Server) initCustomLinters() {
-	for linterName, customLinter := range s.config.CustomLinters {
-		lint.RegisterPullRequestHandler(linterName, lint.GeneralLinterHandler)
-		lint.RegisterLinterLanguages(linterName, customLinter.Languages)
+// currentConfig returns the snapshot of the current config.
+func (s *Server) currentConfig() config.Config {
+	if cfg := s.config.Load(); cfg != nil {
+		return *cfg
 	}
+	return config.Config{}
 }
 
-func (s *Server) initKubernetesRunner() {
-	var toChecks []config.KubernetesAsRunner
-	for _, customLinter := range s.config.CustomLinters {
-		if customLinter.KubernetesAsRunner.Image != "" {
-			toChecks = append(toChecks, customLinter.KubernetesAsRunner)
-		}
+// linterHandler is the handler and the languages of a linter.
+type linterHandler struct {
+	fn        lint.PullRequestHandlerFunc
+	languages []string
+}
+
+// registeredLinters returns the linters registered by the code.
+func registeredLinters() map[string]linterHandler {
+	linters := make(map[string]linterHandler)
+	for name, fn := range lint.TotalPullRequestHandlers() {
+		linters[name] = linterHandler{fn: fn, languages: lint.Languages(name)}
 	}
-	for _, customConfig := range s.config.CustomRepos {
-		for _, linter := range customConfig.Linters {
-			if linter.KubernetesAsRunner.Image != "" {
-				toChecks = append(toChecks, linter.KubernetesAsRunner)
-			}
-		}
+	return linters
+}
+
+// linterHandlers returns the linters to run with the config, i.e. the builtin linters overridden by the custom linters.
+// NOTE: they are derived from the config snapshot of the PR rather than a global registry,
+// so that a run always sees the linters consistent with its config, even if the config is reloaded meanwhile.
+func (s *Server) linterHandlers(cfg config.Config) map[string]linterHandler {
+	linters := make(map[string]linterHandler, len(s.builtinLinters)+len(cfg.CustomLinters))
+	for name, linter := range s.builtinLinters {
+		linters[name] = linter
+	}
+	for name, customLinter := range cfg.CustomLinters {
+		linters[name] = linterHandler{fn: lint.GeneralLinterHandler, languages: customLinter.Languages}
 	}
+	return linters
+}
 
+func (s *Server) initKubernetesRunner() {
+	toChecks := kubernetesRunners(s.currentConfig())
 	if len(toChecks) == 0 {
 		return
 	}
@@ -507,9 +738,7 @@ func (s *Server) initKubernetesRunner() {
 		log.Fatalf("failed to init kubernetes runner: %v", err)
 	}
 
-	s.getKubernetesRunner = func() runner.Runner {
-		return kubeRunner.Clone()
-	}
+	s.kubernetesRunner.Store(&kubeRunner)
 
 	for _, toCheck := range toChecks {
 		if err := kubeRunner.Prepare(context.Background(), &config.Linter{
@@ -528,37 +757,62 @@ func (s *Server) initKubernetesRunner() {
 	log.Infof("init kubernetes runner success")
 }
 
-func (s *Server) initDockerRunner() {
-	var images []string
-	for _, customLinter := range s.config.CustomLinters {
-		if customLinter.DockerAsRunner.Image != "" {
-			images = append(images, customLinter.DockerAsRunner.Image)
+// getKubernetesRunner returns a new kubernetes runner, nil if the kubernetes runner is not initialized.
+func (s *Server) getKubernetesRunner() runner.Runner {
+	kr := s.kubernetesRunner.Load()
+	if kr == nil {
+		return nil
+	}
+	return (*kr).Clone()
+}
+
+// kubernetesRunners returns the kubernetes runners of the linters of every layer in the config.
+func kubernetesRunners(cfg config.Config) []config.KubernetesAsRunner {
+	var runners []config.KubernetesAsRunner
+	_ = cfg.WalkLinters(func(l config.Linter) error {
+		if l.KubernetesAsRunner.Image != "" {
+			runners = append(runners, l.KubernetesAsRunner)
 		}
+		return nil
+	})
+	return runners
+}
+
+func (s *Server) initDockerRunner() {
+	if err := s.pullDockerImages(dockerImages(s.currentConfig())); err != nil {
+		log.Fatalf("failed to init docker runner: %v", err)
 	}
-	for _, customConfig := range s.config.CustomRepos {
-		for _, linter := range customConfig.Linters {
-			if linter.DockerAsRunner.Image != "" {
-				images = append(images, linter.DockerAsRunner.Image)
-			}
+}
+
+// dockerImages returns the images of the linters of every layer running by the docker runner in the config.
+func dockerImages(cfg config.Config) []string {
+	var images []string
+	_ = cfg.WalkLinters(func(l config.Linter) error {
+		if l.DockerAsRunner.Image != "" {
+			images = append(images, l.DockerAsRunner.Image)
 		}
+		return nil
+	})
+	return images
+}
+
+// pullDockerImages pulls the images in background, the docker runner is initialized if not yet.
+func (s *Server) pullDockerImages(images []string) error {
+	if len(images) == 0 {
+		return nil
 	}
 
-	var dockerRunner runner.Runner
-	if len(images) > 0 {
+	if s.dockerRunner.Load() == nil {
 		dr, err := runner.NewDockerRunner(nil)
 		if err != nil {
-			log.Fatalf("failed to init docker runner: %v", err)
+			return err
 		}
-
-		s.getDockerRunner = func() runner.Runner {
-			return dr.Clone()
+		// the runner may be initialized by the reload meanwhile
+		if s.dockerRunner.CompareAndSwap(nil, &dr) {
+			log.Infof("init docker runner success")
 		}
-		dockerRunner = dr
-	}
-
-	if dockerRunner == nil {
-		return
 	}
+	dockerRunner := s.getDockerRunner()
 
 	log.Debugf("total images to pull: %d", len(images))
 	go func() {
@@ -569,7 +823,16 @@ func (s *Server) initDockerRunner() {
 		}
 	}()
 
-	log.Infof("init docker runner success")
+	return nil
+}
+
+// getDockerRunner returns a new docker runner, nil if the docker runner is not initialized.
+func (s *Server) getDockerRunner() runner.Runner {
+	dr := s.dockerRunner.Load()
+	if dr == nil {
+		return nil
+	}
+	return (*dr).Clone()
 }
 
 func (s *Server) pullImageWithRetry(ctx context.Context, image string, dockerRunner runner.Runner) {
@@ -683,7 +946,7 @@ func (s *Server) processPullRequestReviewCommentEvent(ctx context.Context, event
 
 func (s *Server) processPullRequestEvent(ctx context.Context, event *github.PullRequestEvent) error {
 	log := util.FromContext(ctx)
-	if event.GetAction() != "opened" && event.GetAction() != "reopened" && event.GetAction() != "synchronize" {
+	if !isPullRequestRunAction(event.GetAction()) {
 		log.Debugf("skipping action %s\n", event.GetAction())
 		return nil
 	}
@@ -691,6 +954,16 @@ func (s *Server) processPullRequestEvent(ctx context.Context, event *github.Pull
 	return s.handleGitHubEvent(ctx, event)
 }
 
+// isPullRequestRunAction reports whether the linters run on the action of the pull request event.
+func isPullRequestRunAction(action string) bool {
+	return action == "opened" || action == "reopened" || action == "synchronize"
+}
+
+// isMergeRequestRunState reports whether the linters run on the state of the merge request event.
+func isMergeRequestRunState(state string) bool {
+	return state == "opened" || state == "reopened"
+}
+
 func (s *Server) processCheckRunRequestEvent(ctx context.Context, event *github.CheckRunEvent) error {
 	log := util.FromContext(ctx)
 	if event.GetAction() != "rerequested" {
@@ -773,7 +1046,7 @@ func (s *Server) processCheckSuiteEvent(ctx context.Context, event *github.Check
 
 func (s *Server) processMergeRequestEvent(ctx context.Context, event *gitlab.MergeEvent) error {
 	log := util.FromContext(ctx)
-	if event.ObjectAttributes.State != "opened" && event.ObjectAttributes.State != "reopened" {
+	if !isMergeRequestRunState(event.ObjectAttributes.State) {
 		log.Debugf("skipping action %s\n", event.ObjectAttributes.State)
 		return nil
 	}
@@ -794,6 +1067,19 @@ func (s *Server) GitLabClient() *gitlab.Client {
 	return git
 }
 
+// issueContentGetter returns the getter to fetch the issue references with the credentials of the server.
+// The GitHub client of the installation is preferred since the access token is not always configured.
+func (s *Server) issueContentGetter(githubClient *github.Client) lint.IssueContentGetter {
+	if githubClient == nil && s.gitHubAccessTokenAuth != nil {
+		githubClient = s.GithubAccessTokenClient()
+	}
+	var gitlabClient *gitlab.Client
+	if s.gitLabPersonalAccessToken != "" {
+		gitlabClient = s.GitLabClient()
+	}
+	return lint.NewIssueContentGetter(githubClient, gitlabClient)
+}
+
 func (s *Server) githubAppClient(installationID int64) *github.Client {
 	tr, err := ghinstallation.NewKeyFromFile(httpcache.NewMemoryCacheTransport(), s.gitHubAppAuth.AppID, installationID, s.gitHubAppAuth.PrivateKeyPath)
 	if err != nil {
@@ -0,0 +1,176 @@
+/*
+ Copyright 2024 Qiniu Cloud (qiniu.com).
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/qiniu/reviewbot/config"
+	"github.com/qiniu/reviewbot/internal/lint"
+)
+
+func TestLinterHandlers(t *testing.T) {
+	builtin := func(context.Context, lint.Agent) error { return nil }
+	s := &Server{builtinLinters: map[string]linterHandler{
+		"golangci-lint": {fn: builtin, languages: []string{".go"}},
+		"shellcheck":    {fn: builtin, languages: []string{".sh"}},
+	}}
+	old := config.Config{CustomLinters: map[string]config.CustomLinter{
+		"shellcheck": {Languages: []string{".sh", "#!bash"}},
+		"semgrep":    {Languages: []string{".py"}},
+	}}
+	// the config reloaded while the PR with the old config is running
+	reloaded := config.Config{CustomLinters: map[string]config.CustomLinter{
+		"hadolint": {Languages: []string{"Dockerfile"}},
+	}}
+
+	languages := func(linters map[string]linterHandler) map[string][]string {
+		got := make(map[string][]string, len(linters))
+		for name, linter := range linters {
+			got[name] = linter.languages
+		}
+		return got
+	}
+	want := map[string][]string{
+		"golangci-lint": {".go"},
+		"shellcheck":    {".sh", "#!bash"},
+		"semgrep":       {".py"},
+	}
+	if got := languages(s.linterHandlers(old)); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	want = map[string][]string{
+		"golangci-lint": {".go"},
+		"shellcheck":    {".sh"},
+		"hadolint":      {"Dockerfile"},
+	}
+	if got := languages(s.linterHandlers(reloaded)); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// fakeProvider is the provider with the changed files only.
+type fakeProvider struct {
+	lint.Provider
+	files []string
+}
+
+func (p fakeProvider) GetFiles(predicate func(string) bool) []string {
+	var files []string
+	for _, file := range p.files {
+		if predicate == nil || predicate(file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func TestNewAgentRepoDir(t *testing.T) {
+	workspace := t.TempDir()
+	// the repo is checked out under the path alias rather than the repo name
+	workDir := filepath.Join(workspace, "src", "github.com", "qiniu", "reviewbot")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "run"), []byte("#!/bin/bash\necho hi\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{}
+	info := &codeRequestInfo{
+		platform: config.GitHub,
+		org:      "qiniu",
+		repo:     "reviewbot",
+		orgRepo:  "qiniu/reviewbot",
+		workDir:  workDir,
+		repoDir:  workspace,
+		provider: fakeProvider{files: []string{"run"}},
+	}
+	agent, ok := s.newAgent(context.Background(), info, "shellcheck", []string{"#!bash"})
+	if !ok {
+		t.Fatal("expected the linter to be related by the shebang of the file in the checkout")
+	}
+	if agent.RepoDir != workDir {
+		t.Errorf("expected repo dir %s, got %s", workDir, agent.RepoDir)
+	}
+}
+
+func TestRunnersOfAllLayers(t *testing.T) {
+	cfg := config.Config{
+		CustomLinters: map[string]config.CustomLinter{
+			"semgrep": {Linter: config.Linter{DockerAsRunner: config.DockerAsRunner{Image: "semgrep"}}},
+		},
+		Profiles: map[string]config.Profile{
+			"go": {Linters: map[string]config.Linter{
+				"golangci-lint": {DockerAsRunner: config.DockerAsRunner{Image: "golangci-lint"}},
+			}},
+		},
+		CustomRepos: map[string]config.RepoConfig{
+			"qiniu/reviewbot": {
+				Linters: map[string]config.Linter{
+					"shellcheck": {KubernetesAsRunner: config.KubernetesAsRunner{Image: "shellcheck"}},
+				},
+				Branches: map[string]config.BranchConfig{
+					"release-*": {Linters: map[string]config.Linter{
+						"gofmt":      {DockerAsRunner: config.DockerAsRunner{Image: "gofmt"}},
+						"luacheck":   {KubernetesAsRunner: config.KubernetesAsRunner{Image: "luacheck"}},
+						"note-check": {},
+					}},
+				},
+			},
+		},
+	}
+
+	if want, got := []string{"semgrep", "golangci-lint", "gofmt"}, dockerImages(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected images %v, got %v", want, got)
+	}
+	var images []string
+	for _, r := range kubernetesRunners(cfg) {
+		images = append(images, r.Image)
+	}
+	if want := []string{"shellcheck", "luacheck"}; !reflect.DeepEqual(images, want) {
+		t.Errorf("expected kubernetes runners %v, got %v", want, images)
+	}
+}
+
+func TestNewAgentRunnerNotInitialized(t *testing.T) {
+	s := &Server{}
+	info := &codeRequestInfo{
+		platform: config.GitHub,
+		org:      "qiniu",
+		repo:     "reviewbot",
+		orgRepo:  "qiniu/reviewbot",
+		workDir:  t.TempDir(),
+		config: config.Config{CustomRepos: map[string]config.RepoConfig{
+			"qiniu/reviewbot": {Linters: map[string]config.Linter{
+				"golangci-lint": {DockerAsRunner: config.DockerAsRunner{Image: "golangci-lint"}},
+				"shellcheck":    {KubernetesAsRunner: config.KubernetesAsRunner{Image: "shellcheck"}},
+			}},
+		}},
+		provider: fakeProvider{files: []string{"main.go", "run.sh"}},
+	}
+	for _, name := range []string{"golangci-lint", "shellcheck"} {
+		if _, ok := s.newAgent(context.Background(), info, name, []string{"*"}); ok {
+			t.Errorf("expected %s skipped since its runner is not initialized", name)
+		}
+	}
+}